@@ -1,9 +1,12 @@
 package config
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"log"
 	"os"
+	"regexp"
 	"time"
 
 	"mcp-server/internal/core"
@@ -13,9 +16,43 @@ import (
 
 // PrometheusConfig Prometheus服务配置
 type PrometheusConfig struct {
-	Enabled  bool   `yaml:"enabled"`
-	URL      string `yaml:"url"`
-	Endpoint string `yaml:"endpoint"`
+	Name               string            `yaml:"name"`
+	Enabled            bool              `yaml:"enabled"`
+	URL                string            `yaml:"url"`
+	FallbackURL        string            `yaml:"fallback_url"`
+	Username           string            `yaml:"username"`
+	Password           string            `yaml:"password"`
+	BearerToken        string            `yaml:"bearer_token"`
+	CAFile             string            `yaml:"ca_file"`
+	ClientCertFile     string            `yaml:"client_cert_file"`
+	ClientKeyFile      string            `yaml:"client_key_file"`
+	InsecureSkipVerify bool              `yaml:"insecure_skip_verify"`
+	Endpoint           string            `yaml:"endpoint"`
+	Port               string            `yaml:"port"`
+	MaxConnsPerHost    int               `yaml:"max_conns_per_host"`
+	RetryAttempts      int               `yaml:"retry_attempts"`
+	RetryBackoff       time.Duration     `yaml:"retry_backoff"`
+	CacheTTL           time.Duration     `yaml:"cache_ttl"`
+	MetadataLimit      int               `yaml:"metadata_limit"`
+	LabelValuesLimit   int               `yaml:"label_values_limit"`
+	HighCardinality    []string          `yaml:"high_cardinality_labels"`
+	QueryTimeout       time.Duration     `yaml:"query_timeout"`
+	RangeQueryTimeout  time.Duration     `yaml:"range_query_timeout"`
+	ListMetricsTimeout time.Duration     `yaml:"list_metrics_timeout"`
+	MaxRangeWindow     time.Duration     `yaml:"max_range_window"`
+	LabelLookback      time.Duration     `yaml:"label_lookback"`
+	DefaultStep        time.Duration     `yaml:"default_step"`
+	EnableRawAPI       bool              `yaml:"enable_raw_api"`
+	EnableAdminAPI     bool              `yaml:"enable_admin_api"`
+	MetricRename       map[string]string `yaml:"metric_rename"`
+	CommonMetrics      map[string]string `yaml:"common_metrics"`
+	DefaultMatchers    map[string]string `yaml:"default_matchers"`
+	NaNHandling        string            `yaml:"nan_handling"`
+	DiskMountpoint     string            `yaml:"disk_mountpoint"`
+	ReadinessMode      string            `yaml:"readiness_mode"`
+
+	// placeholder 配置文件中没有该段，由setDefaults创建的空配置
+	placeholder bool
 }
 
 // GetType 实现ServiceConfig接口
@@ -28,7 +65,20 @@ func (p *PrometheusConfig) GetEndpoint() string {
 	if p.Endpoint != "" {
 		return p.Endpoint
 	}
-	return "/prometheus/mcp"
+	return instanceEndpoint("prometheus", p.Name)
+}
+
+// GetPort 实现core.PortProvider接口，为空表示使用共享端口
+func (p *PrometheusConfig) GetPort() string {
+	return p.Port
+}
+
+// GetReadinessMode 获取就绪检查模式，默认仅测试连接
+func (p *PrometheusConfig) GetReadinessMode() core.ReadinessMode {
+	if p.ReadinessMode == "" {
+		return core.ReadinessModeConnect
+	}
+	return core.ReadinessMode(p.ReadinessMode)
 }
 
 // IsEnabled 实现ServiceConfig接口
@@ -36,6 +86,11 @@ func (p *PrometheusConfig) IsEnabled() bool {
 	return p.Enabled && p.URL != ""
 }
 
+// isConfigured 判断配置文件中是否提供了该服务（或设置了地址），未提供的服务不视为已禁用
+func (p *PrometheusConfig) isConfigured() bool {
+	return !p.placeholder || p.URL != ""
+}
+
 // Validate 实现ServiceConfig接口
 func (p *PrometheusConfig) Validate() error {
 	if p.Enabled && p.URL == "" {
@@ -44,13 +99,43 @@ func (p *PrometheusConfig) Validate() error {
 	return nil
 }
 
+// instanceEndpoint 生成默认端点路径，命名实例使用 /<服务>-<名称>/mcp
+func instanceEndpoint(service, name string) string {
+	if name == "" {
+		return "/" + service + "/mcp"
+	}
+	return "/" + service + "-" + name + "/mcp"
+}
+
 // SupersetConfig Superset服务配置
 type SupersetConfig struct {
-	Enabled  bool   `yaml:"enabled"`
-	URL      string `yaml:"url"`
-	User     string `yaml:"user"`
-	Pass     string `yaml:"pass"`
-	Endpoint string `yaml:"endpoint"`
+	Name               string        `yaml:"name"`
+	Enabled            bool          `yaml:"enabled"`
+	URL                string        `yaml:"url"`
+	User               string        `yaml:"user"`
+	Pass               string        `yaml:"pass"`
+	Endpoint           string        `yaml:"endpoint"`
+	Port               string        `yaml:"port"`
+	Timeout            time.Duration `yaml:"timeout"`
+	MaxConnsPerHost    int           `yaml:"max_conns_per_host"`
+	CAFile             string        `yaml:"ca_file"`
+	ClientCertFile     string        `yaml:"client_cert_file"`
+	ClientKeyFile      string        `yaml:"client_key_file"`
+	InsecureSkipVerify bool          `yaml:"insecure_skip_verify"`
+	RetryAttempts      int           `yaml:"retry_attempts"`
+	RetryBackoff       time.Duration `yaml:"retry_backoff"`
+	ReadinessMode      string        `yaml:"readiness_mode"`
+	ReadOnly           bool          `yaml:"read_only"`
+	SQLTimeout         time.Duration `yaml:"sql_timeout"`
+	ColumnNameSource   string        `yaml:"column_name_source"`
+	DatabaseCacheTTL   time.Duration `yaml:"database_cache_ttl"`
+	CoalesceQueries    bool          `yaml:"coalesce_queries"`
+	CSRFTokenTTL       time.Duration `yaml:"csrf_token_ttl"`
+	HealthPath         string        `yaml:"health_path"`
+	EnableAdminAPI     bool          `yaml:"enable_admin_api"`
+
+	// placeholder 配置文件中没有该段，由setDefaults创建的空配置
+	placeholder bool
 }
 
 // GetType 实现ServiceConfig接口
@@ -63,7 +148,20 @@ func (s *SupersetConfig) GetEndpoint() string {
 	if s.Endpoint != "" {
 		return s.Endpoint
 	}
-	return "/superset/mcp"
+	return instanceEndpoint("superset", s.Name)
+}
+
+// GetPort 实现core.PortProvider接口，为空表示使用共享端口
+func (s *SupersetConfig) GetPort() string {
+	return s.Port
+}
+
+// GetReadinessMode 获取就绪检查模式，默认仅测试连接
+func (s *SupersetConfig) GetReadinessMode() core.ReadinessMode {
+	if s.ReadinessMode == "" {
+		return core.ReadinessModeConnect
+	}
+	return core.ReadinessMode(s.ReadinessMode)
 }
 
 // IsEnabled 实现ServiceConfig接口
@@ -71,6 +169,11 @@ func (s *SupersetConfig) IsEnabled() bool {
 	return s.Enabled && s.URL != ""
 }
 
+// isConfigured 判断配置文件中是否提供了该服务（或设置了地址），未提供的服务不视为已禁用
+func (s *SupersetConfig) isConfigured() bool {
+	return !s.placeholder || s.URL != ""
+}
+
 // Validate 实现ServiceConfig接口
 func (s *SupersetConfig) Validate() error {
 	if s.Enabled {
@@ -89,10 +192,59 @@ func (s *SupersetConfig) Validate() error {
 
 // Config 应用程序配置
 type Config struct {
-	HTTPPort   string            `yaml:"http_port"`
-	Timeout    time.Duration     `yaml:"timeout"`
-	Prometheus *PrometheusConfig `yaml:"prometheus"`
-	Superset   *SupersetConfig   `yaml:"superset"`
+	HTTPPort            string            `yaml:"http_port"`
+	Timeout             time.Duration     `yaml:"timeout"`
+	MaxResponseBytes    int64             `yaml:"max_response_bytes"`
+	ResponseContentType string            `yaml:"response_content_type"`
+	StartupAttempts     int               `yaml:"startup_attempts"`
+	StartupRetryBackoff time.Duration     `yaml:"startup_retry_backoff"`
+	HTTPAuthToken       string            `yaml:"http_auth_token"`
+	CORSAllowedOrigins  []string          `yaml:"cors_allowed_origins"`
+	MOTD                string            `yaml:"motd"`
+	WaitForReady        bool              `yaml:"wait_for_ready"`
+	StrictStartup       bool              `yaml:"strict_startup"`
+	MetricsEnabled      bool              `yaml:"metrics_enabled"`
+	Prometheus          *PrometheusConfig `yaml:"prometheus"`
+	Superset            *SupersetConfig   `yaml:"superset"`
+
+	// 额外的服务实例，例如分别连接生产和测试环境，每个实例需配置独立的name或endpoint
+	PrometheusInstances []*PrometheusConfig `yaml:"prometheus_instances"`
+	SupersetInstances   []*SupersetConfig   `yaml:"superset_instances"`
+
+	// 外部注册的服务类型配置，键为服务类型，值由该类型注册的解析函数解析
+	Plugins        map[string]yaml.Node `yaml:"plugins"`
+	PluginServices []core.ServiceConfig `yaml:"-"`
+
+	// Path 加载配置的文件路径，用于重新加载
+	Path string `yaml:"-"`
+}
+
+// AllPrometheusConfigs 返回主配置和所有额外实例的Prometheus配置
+func (c *Config) AllPrometheusConfigs() []*PrometheusConfig {
+	var configs []*PrometheusConfig
+	if c.Prometheus != nil {
+		configs = append(configs, c.Prometheus)
+	}
+	for _, instance := range c.PrometheusInstances {
+		if instance != nil {
+			configs = append(configs, instance)
+		}
+	}
+	return configs
+}
+
+// AllSupersetConfigs 返回主配置和所有额外实例的Superset配置
+func (c *Config) AllSupersetConfigs() []*SupersetConfig {
+	var configs []*SupersetConfig
+	if c.Superset != nil {
+		configs = append(configs, c.Superset)
+	}
+	for _, instance := range c.SupersetInstances {
+		if instance != nil {
+			configs = append(configs, instance)
+		}
+	}
+	return configs
 }
 
 // GetServices 获取启用的服务配置列表 (保持向后兼容)
@@ -112,27 +264,80 @@ func (c *Config) Validate() error {
 }
 
 // LoadConfigFromYAML 从YAML文件加载配置
+// 支持在YAML值中使用${ENV_VAR}引用环境变量，并可通过MCP_前缀的环境变量覆盖任意配置项
 func LoadConfigFromYAML(path string) (*Config, error) {
-	f, err := os.Open(path)
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("无法打开配置文件: %w", err)
 	}
-	defer f.Close()
 
-	var cfg Config
-	decoder := yaml.NewDecoder(f)
-	if err := decoder.Decode(&cfg); err != nil {
-		return nil, fmt.Errorf("YAML解析失败: %w", err)
+	cfg, err := parseConfigYAML(data, path, true)
+	if err != nil {
+		return nil, err
 	}
 
-	// 设置默认值
-	setDefaults(&cfg)
-
 	// 验证配置
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("配置验证失败: %w", err)
 	}
 
+	if len(FilterEnabledServices(cfg)) == 0 {
+		log.Printf("警告: 配置文件 %s 中没有启用任何服务，请为需要的服务设置 enabled: true 并提供url等连接信息", path)
+	}
+
+	return cfg, nil
+}
+
+// quotedValuePattern YAML解析错误中以反引号引用的原始值
+var quotedValuePattern = regexp.MustCompile("`[^`]*`")
+
+// ValidateConfigYAML 解析并验证提交的YAML内容，不应用配置
+// 内容可能来自远程调用方，不读取进程环境变量：${ENV_VAR}引用按未设置处理，也不应用MCP_覆盖，
+// 返回的错误信息中不包含字段的值；YAML或插件配置无法解析时返回error，否则返回全部验证错误
+func ValidateConfigYAML(data []byte) (ValidationResult, error) {
+	cfg, err := parseConfigYAML(data, "", false)
+	if err != nil {
+		return ValidationResult{}, errors.New(quotedValuePattern.ReplaceAllString(err.Error(), "`***`"))
+	}
+	return ValidateConfig(cfg), nil
+}
+
+// parseConfigYAML 解析YAML配置内容：展开环境变量、应用环境变量覆盖、解析插件配置并设置默认值，不做验证
+// useEnv为false时不读取进程环境变量，${ENV_VAR}引用展开为空字符串
+func parseConfigYAML(data []byte, path string, useEnv bool) (*Config, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("YAML解析失败: %w", err)
+	}
+	getenv := os.Getenv
+	if !useEnv {
+		getenv = func(string) string { return "" }
+	}
+	expandEnvVars(&document, getenv)
+
+	cfg := Config{Path: path}
+	// 空内容没有文档节点，保持零值配置
+	if document.Kind != 0 {
+		if err := document.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("YAML解析失败: %w", err)
+		}
+	}
+
+	// 环境变量覆盖（优先级高于YAML）
+	if useEnv {
+		if err := applyEnvOverrides(&cfg); err != nil {
+			return nil, fmt.Errorf("环境变量覆盖失败: %w", err)
+		}
+	}
+
+	// 解析外部服务配置
+	if err := parsePluginConfigs(&cfg); err != nil {
+		return nil, fmt.Errorf("插件配置解析失败: %w", err)
+	}
+
+	// 设置默认值
+	setDefaults(&cfg)
+
 	return &cfg, nil
 }
 
@@ -144,31 +349,26 @@ func setDefaults(cfg *Config) {
 	if cfg.Timeout == 0 {
 		cfg.Timeout = 30 * time.Second
 	}
-
-	// 初始化Prometheus配置
-	if cfg.Prometheus == nil {
-		cfg.Prometheus = &PrometheusConfig{}
+	if cfg.StartupAttempts == 0 {
+		cfg.StartupAttempts = 3
 	}
-	if cfg.Prometheus.URL == "" {
-		cfg.Prometheus.URL = "http://hd-piko.prometheus.qiniu.io/"
-		cfg.Prometheus.Enabled = true
+	if cfg.StartupRetryBackoff == 0 {
+		cfg.StartupRetryBackoff = time.Second
 	}
 
-	// 初始化Superset配置
-	if cfg.Superset == nil {
-		cfg.Superset = &SupersetConfig{}
+	// 初始化服务配置：未提供的服务保持禁用，不注入任何默认地址或凭据
+	if cfg.Prometheus == nil {
+		cfg.Prometheus = &PrometheusConfig{placeholder: true}
 	}
-	if cfg.Superset.URL == "" {
-		cfg.Superset.URL = "http://superset.yzh-logverse.k8s.qiniu.io"
-		cfg.Superset.User = "dingnanjia"
-		cfg.Superset.Pass = "nanjia123"
-		cfg.Superset.Enabled = true
+	if cfg.Superset == nil {
+		cfg.Superset = &SupersetConfig{placeholder: true}
 	}
 }
 
 // LoadConfig 加载配置
 func LoadConfig() *Config {
 	configPath := flag.String("config", "config/config.yaml", "YAML配置文件路径")
+	dumpConfig := flag.Bool("dump-config", false, "输出生效的配置（敏感字段已脱敏）后退出")
 	flag.Parse()
 
 	cfg, err := LoadConfigFromYAML(*configPath)
@@ -178,5 +378,15 @@ func LoadConfig() *Config {
 		os.Exit(1)
 	}
 
+	if *dumpConfig {
+		data, err := DumpRedactedYAML(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+			os.Exit(1)
+		}
+		os.Stdout.Write(data)
+		os.Exit(0)
+	}
+
 	return cfg
 }
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"mcp-server/internal/core"
+)
+
+func TestValidateConfigYAML(t *testing.T) {
+	valid := []byte(`
+http_port: "8080"
+prometheus:
+  enabled: true
+  url: http://prometheus:9090
+`)
+	result, err := ValidateConfigYAML(valid)
+	if err != nil {
+		t.Fatalf("ValidateConfigYAML(valid): %v", err)
+	}
+	if !result.IsValid() {
+		t.Errorf("valid config reported errors: %v", result.Errors)
+	}
+
+	invalid := []byte(`
+max_response_bytes: -1
+prometheus:
+  enabled: true
+  port: "70000"
+  metadata_limit: -1
+`)
+	result, err = ValidateConfigYAML(invalid)
+	if err != nil {
+		t.Fatalf("ValidateConfigYAML(invalid): %v", err)
+	}
+	fields := make(map[string]bool)
+	for _, validationErr := range result.Errors {
+		fields[validationErr.Field] = true
+	}
+	for _, field := range []string{"max_response_bytes", "prometheus.url", "prometheus.port", "prometheus.metadata_limit"} {
+		if !fields[field] {
+			t.Errorf("missing error for %s, got %v", field, result.Errors)
+		}
+	}
+}
+
+func TestValidateConfigYAMLDoesNotReadEnvironment(t *testing.T) {
+	const secret = "super-secret-value"
+	t.Setenv("VALIDATE_TEST_SECRET", secret)
+	t.Setenv("MCP_RESPONSE_CONTENT_TYPE", secret)
+
+	result, err := ValidateConfigYAML([]byte("response_content_type: ${VALIDATE_TEST_SECRET}\n"))
+	if err != nil {
+		t.Fatalf("ValidateConfigYAML: %v", err)
+	}
+	for _, validationErr := range result.Errors {
+		if strings.Contains(validationErr.Message, secret) {
+			t.Errorf("validation message leaks the environment: %s", validationErr.Message)
+		}
+	}
+
+	_, err = ValidateConfigYAML([]byte("timeout: not-a-duration\n"))
+	if err == nil {
+		t.Fatal("expected a parse error for an invalid duration")
+	}
+	if strings.Contains(err.Error(), "not-a-duration") {
+		t.Errorf("parse error echoes the field value: %v", err)
+	}
+}
+
+func TestValidateUniqueEndpointsAcrossPorts(t *testing.T) {
+	cfg := &Config{
+		PrometheusInstances: []*PrometheusConfig{
+			{Enabled: true, URL: "http://prod:9090", Endpoint: "/prometheus/mcp", Port: "9091"},
+			{Enabled: true, URL: "http://staging:9090", Endpoint: "/prometheus/mcp", Port: "9092"},
+		},
+	}
+	setDefaults(cfg)
+
+	errors := validateUniqueEndpoints(FilterEnabledServices(cfg))
+	if len(errors) != 1 {
+		t.Fatalf("expected one duplicate endpoint error, got %v", errors)
+	}
+}
+
+func TestFilterDisabledServicesSkipsAbsentSections(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		yaml string
+		want []core.ServiceType
+	}{
+		{
+			name: "absent superset section",
+			yaml: "prometheus:\n  enabled: true\n  url: http://prometheus:9090\n",
+			want: nil,
+		},
+		{
+			name: "configured but disabled",
+			yaml: "prometheus:\n  enabled: true\n  url: http://prometheus:9090\nsuperset:\n  enabled: false\n  url: http://superset:8088\n",
+			want: []core.ServiceType{core.ServiceTypeSuperset},
+		},
+		{
+			name: "disabled section without url",
+			yaml: "prometheus:\n  enabled: false\n",
+			want: []core.ServiceType{core.ServiceTypePrometheus},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := parseConfigYAML([]byte(tc.yaml), "", false)
+			if err != nil {
+				t.Fatalf("parseConfigYAML: %v", err)
+			}
+			var got []core.ServiceType
+			for _, serviceConfig := range FilterDisabledServices(cfg) {
+				got = append(got, serviceConfig.GetType())
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("disabled services = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
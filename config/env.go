@@ -0,0 +1,199 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envPrefix 环境变量覆盖的前缀
+const envPrefix = "MCP_"
+
+// envVarPattern 匹配YAML中的${ENV_VAR}引用
+var envVarPattern = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// expandEnvVars 使用getenv展开YAML节点树中标量值里的${ENV_VAR}引用，未设置的变量展开为空字符串
+// 在解析后的节点上替换，变量值中的换行、冒号、#和引号不会改变文档结构；映射的键不展开
+// 只识别${...}形式，避免误替换密码等值中出现的普通$字符
+func expandEnvVars(node *yaml.Node, getenv func(string) string) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		expanded := envVarPattern.ReplaceAllStringFunc(node.Value, func(match string) string {
+			return getenv(envVarPattern.FindStringSubmatch(match)[1])
+		})
+		if expanded == node.Value {
+			return
+		}
+		node.Value = expanded
+		// 未加引号且未显式指定类型的值按展开后的内容重新推断类型，例如 port: ${PORT}
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			expandEnvVars(node.Content[i], getenv)
+		}
+	default:
+		for _, child := range node.Content {
+			expandEnvVars(child, getenv)
+		}
+	}
+}
+
+// applyEnvOverrides 使用环境变量覆盖配置项，优先级高于YAML
+// 变量名为MCP_前缀加上大写的yaml路径，例如 MCP_HTTP_PORT, MCP_SUPERSET_PASS
+func applyEnvOverrides(cfg *Config) error {
+	return overrideStruct(reflect.ValueOf(cfg).Elem(), envPrefix)
+}
+
+// overrideStruct 递归处理结构体字段
+func overrideStruct(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := yamlFieldName(field)
+		if name == "" {
+			continue
+		}
+
+		envName := prefix + strings.ToUpper(name)
+		if err := overrideValue(v.Field(i), envName); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// overrideValue 根据字段类型解析并设置环境变量的值
+func overrideValue(v reflect.Value, envName string) error {
+	// 嵌套结构体指针：仅在存在相关环境变量时才分配
+	if v.Kind() == reflect.Pointer && v.Type().Elem().Kind() == reflect.Struct {
+		if v.IsNil() {
+			if !hasEnvWithPrefix(envName + "_") {
+				return nil
+			}
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return overrideStruct(v.Elem(), envName+"_")
+	}
+	if v.Kind() == reflect.Struct {
+		return overrideStruct(v, envName+"_")
+	}
+
+	raw, ok := os.LookupEnv(envName)
+	if !ok {
+		return nil
+	}
+
+	if err := setFromString(v, raw); err != nil {
+		return fmt.Errorf("环境变量 %s 的值无效: %w", envName, err)
+	}
+	return nil
+}
+
+// setFromString 将字符串值按字段类型写入
+func setFromString(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("不支持的列表类型: %s", v.Type())
+		}
+		items := splitList(raw)
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			slice.Index(i).SetString(item)
+		}
+		v.Set(slice)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("不支持的映射类型: %s", v.Type())
+		}
+		m := reflect.MakeMap(v.Type())
+		for _, item := range splitList(raw) {
+			key, value, found := strings.Cut(item, "=")
+			if !found {
+				return fmt.Errorf("映射项应为key=value格式: %s", item)
+			}
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)), reflect.ValueOf(strings.TrimSpace(value)))
+		}
+		v.Set(m)
+	default:
+		return fmt.Errorf("不支持的字段类型: %s", v.Type())
+	}
+	return nil
+}
+
+// yamlFieldName 获取字段的yaml名称，忽略未导出和显式跳过的字段
+func yamlFieldName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	tag := field.Tag.Get("yaml")
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return strings.ToLower(field.Name)
+	}
+	return name
+}
+
+// splitList 按逗号拆分并去除空白
+func splitList(raw string) []string {
+	parts := strings.Split(raw, ",")
+	result := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if trimmed := strings.TrimSpace(part); trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+	return result
+}
+
+// hasEnvWithPrefix 检查是否存在指定前缀的环境变量
+func hasEnvWithPrefix(prefix string) bool {
+	for _, env := range os.Environ() {
+		if strings.HasPrefix(env, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEnvOverridePrecedence(t *testing.T) {
+	t.Setenv("SUPERSET_PASS_FROM_FILE", "expanded-pass")
+	t.Setenv("MCP_SUPERSET_PASS", "override-pass")
+	t.Setenv("MCP_HTTP_PORT", "9999")
+
+	data := []byte(`
+http_port: "8081"
+superset:
+  url: http://superset:8088
+  user: admin
+  pass: ${SUPERSET_PASS_FROM_FILE}
+`)
+	cfg, err := parseConfigYAML(data, "", true)
+	if err != nil {
+		t.Fatalf("parseConfigYAML: %v", err)
+	}
+
+	// MCP_前缀的环境变量优先于YAML中的值及${}展开结果
+	if cfg.HTTPPort != "9999" {
+		t.Errorf("HTTPPort = %q, want 9999", cfg.HTTPPort)
+	}
+	if cfg.Superset.Pass != "override-pass" {
+		t.Errorf("Superset.Pass = %q, want override-pass", cfg.Superset.Pass)
+	}
+	// 没有覆盖的字段保持YAML中的值
+	if cfg.Superset.User != "admin" {
+		t.Errorf("Superset.User = %q, want admin", cfg.Superset.User)
+	}
+}
+
+func TestEnvExpansion(t *testing.T) {
+	t.Setenv("TEST_SUPERSET_URL", "http://superset:8088")
+	t.Setenv("TEST_TIMEOUT", "45s")
+	t.Setenv("TEST_PORT", "9091")
+
+	data := []byte(`
+timeout: ${TEST_TIMEOUT}
+superset:
+  url: ${TEST_SUPERSET_URL}
+  port: "${TEST_PORT}"
+`)
+	cfg, err := parseConfigYAML(data, "", true)
+	if err != nil {
+		t.Fatalf("parseConfigYAML: %v", err)
+	}
+	if cfg.Superset.URL != "http://superset:8088" {
+		t.Errorf("Superset.URL = %q", cfg.Superset.URL)
+	}
+	if cfg.Timeout != 45*time.Second {
+		t.Errorf("Timeout = %v, want 45s", cfg.Timeout)
+	}
+	if cfg.Superset.Port != "9091" {
+		t.Errorf("Superset.Port = %q, want 9091", cfg.Superset.Port)
+	}
+}
+
+func TestEnvExpansionMissingVariable(t *testing.T) {
+	data := []byte(`
+superset:
+  user: ${MCP_TEST_UNSET_VARIABLE}
+  pass: "prefix-${MCP_TEST_UNSET_VARIABLE}-suffix"
+`)
+	cfg, err := parseConfigYAML(data, "", true)
+	if err != nil {
+		t.Fatalf("parseConfigYAML: %v", err)
+	}
+	if cfg.Superset.User != "" {
+		t.Errorf("Superset.User = %q, want empty", cfg.Superset.User)
+	}
+	if cfg.Superset.Pass != "prefix--suffix" {
+		t.Errorf("Superset.Pass = %q, want prefix--suffix", cfg.Superset.Pass)
+	}
+}
+
+func TestEnvExpansionCannotInjectKeys(t *testing.T) {
+	t.Setenv("TEST_INJECTED_PASS", "secret\nhttp_port: \"1\"\n# comment: \"x'")
+
+	data := []byte(`
+http_port: "8081"
+superset:
+  pass: ${TEST_INJECTED_PASS}
+`)
+	cfg, err := parseConfigYAML(data, "", true)
+	if err != nil {
+		t.Fatalf("parseConfigYAML: %v", err)
+	}
+	if cfg.HTTPPort != "8081" {
+		t.Errorf("HTTPPort = %q, variable value changed the document structure", cfg.HTTPPort)
+	}
+	if cfg.Superset.Pass != "secret\nhttp_port: \"1\"\n# comment: \"x'" {
+		t.Errorf("Superset.Pass = %q, want the literal variable value", cfg.Superset.Pass)
+	}
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"mcp-server/internal/core"
+)
+
+// parsePluginConfigs 使用已注册的解析函数解析plugins段中的外部服务配置
+func parsePluginConfigs(cfg *Config) error {
+	names := make([]string, 0, len(cfg.Plugins))
+	for name := range cfg.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	cfg.PluginServices = nil
+	for _, name := range names {
+		serviceType := core.ServiceType(name)
+		parser, ok := core.GetConfigParser(serviceType)
+		if !ok {
+			return fmt.Errorf("plugins.%s: 未注册的服务类型", name)
+		}
+
+		node := cfg.Plugins[name]
+		serviceConfig, err := parser(node.Decode)
+		if err != nil {
+			return fmt.Errorf("plugins.%s: %w", name, err)
+		}
+		if serviceConfig == nil {
+			return fmt.Errorf("plugins.%s: 解析函数返回了空配置", name)
+		}
+		if serviceConfig.GetType() != serviceType {
+			return fmt.Errorf("plugins.%s: 配置类型不匹配，得到%s", name, serviceConfig.GetType())
+		}
+
+		cfg.PluginServices = append(cfg.PluginServices, serviceConfig)
+	}
+
+	return nil
+}
@@ -2,8 +2,17 @@ package config
 
 import (
 	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"mcp-server/internal/common"
 	"mcp-server/internal/core"
+
+	"github.com/prometheus/common/model"
 )
 
 // ValidationError 配置验证错误
@@ -35,25 +44,234 @@ func (vr ValidationResult) GetFirstError() error {
 	return nil
 }
 
+// instanceNamePattern 实例名称会用于端点路径，只允许字母、数字、下划线和连字符
+var instanceNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]*$`)
+
 // 纯函数验证器
 
+// validateInstanceName 验证实例名称
+func validateInstanceName(field, name string) *ValidationError {
+	if !instanceNamePattern.MatchString(name) {
+		return &ValidationError{Field: field, Message: "只能包含字母、数字、下划线和连字符"}
+	}
+	return nil
+}
+
+// validateReadinessMode 验证就绪检查模式，为空表示使用默认的connect
+func validateReadinessMode(field, mode string) *ValidationError {
+	switch core.ReadinessMode(mode) {
+	case "", core.ReadinessModeConnect, core.ReadinessModeFull:
+		return nil
+	}
+	return &ValidationError{Field: field, Message: "可选值为 connect 或 full"}
+}
+
+// validateClientCert 验证双向TLS客户端证书配置，证书和私钥需要同时配置
+func validateClientCert(field, certFile, keyFile string) *ValidationError {
+	if (certFile == "") == (keyFile == "") {
+		return nil
+	}
+	if certFile == "" {
+		return &ValidationError{Field: field + ".client_cert_file", Message: "配置了client_key_file但client_cert_file为空"}
+	}
+	return &ValidationError{Field: field + ".client_key_file", Message: "配置了client_cert_file但client_key_file为空"}
+}
+
+// validatePort 验证端口号，为空表示未配置
+func validatePort(field, port string) *ValidationError {
+	if port == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil || n < 1 || n > 65535 {
+		return &ValidationError{Field: field, Message: "端口号应为1-65535之间的整数"}
+	}
+	return nil
+}
+
 // ValidatePrometheusConfig 验证Prometheus配置 (纯函数)
 func ValidatePrometheusConfig(config *PrometheusConfig) ValidationResult {
+	return validatePrometheusConfig("prometheus", config)
+}
+
+// validatePrometheusConfig 验证Prometheus配置，field为错误信息中的字段前缀
+func validatePrometheusConfig(field string, config *PrometheusConfig) ValidationResult {
 	var errors []ValidationError
 
 	if config == nil {
 		return ValidationResult{Valid: false, Errors: []ValidationError{
-			{Field: "prometheus", Message: "配置不能为空"},
+			{Field: field, Message: "配置不能为空"},
 		}}
 	}
 
 	if config.Enabled && config.URL == "" {
 		errors = append(errors, ValidationError{
-			Field:   "prometheus.url",
+			Field:   field + ".url",
 			Message: "服务已启用但URL为空",
 		})
 	}
 
+	if config.FallbackURL != "" {
+		if u, err := url.Parse(config.FallbackURL); err != nil || u.Scheme == "" || u.Host == "" {
+			errors = append(errors, ValidationError{
+				Field:   field + ".fallback_url",
+				Message: "应为包含协议和主机的URL，例如 http://prometheus-0.prometheus:9090",
+			})
+		}
+	}
+
+	if config.BearerToken != "" && (config.Username != "" || config.Password != "") {
+		errors = append(errors, ValidationError{
+			Field:   field + ".bearer_token",
+			Message: "不能与username/password同时配置",
+		})
+	}
+
+	if config.Password != "" && config.Username == "" {
+		errors = append(errors, ValidationError{
+			Field:   field + ".username",
+			Message: "配置了password但username为空",
+		})
+	}
+
+	if nameErr := validateInstanceName(field+".name", config.Name); nameErr != nil {
+		errors = append(errors, *nameErr)
+	}
+
+	if portErr := validatePort(field+".port", config.Port); portErr != nil {
+		errors = append(errors, *portErr)
+	}
+
+	if certErr := validateClientCert(field, config.ClientCertFile, config.ClientKeyFile); certErr != nil {
+		errors = append(errors, *certErr)
+	}
+
+	if modeErr := validateReadinessMode(field+".readiness_mode", config.ReadinessMode); modeErr != nil {
+		errors = append(errors, *modeErr)
+	}
+
+	if config.MaxConnsPerHost < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".max_conns_per_host",
+			Message: "不能为负数",
+		})
+	}
+
+	if config.RetryAttempts < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".retry_attempts",
+			Message: "不能为负数",
+		})
+	}
+
+	if config.RetryBackoff < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".retry_backoff",
+			Message: "不能为负数",
+		})
+	}
+
+	if config.CacheTTL < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".cache_ttl",
+			Message: "不能为负数",
+		})
+	}
+
+	if config.MetadataLimit < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".metadata_limit",
+			Message: "不能为负数",
+		})
+	}
+
+	if config.LabelValuesLimit < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".label_values_limit",
+			Message: "不能为负数",
+		})
+	}
+
+	switch config.NaNHandling {
+	case "", "string", "null":
+	default:
+		errors = append(errors, ValidationError{
+			Field:   field + ".nan_handling",
+			Message: "可选值为 string 或 null",
+		})
+	}
+
+	if config.MaxRangeWindow < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".max_range_window",
+			Message: "不能为负数",
+		})
+	}
+
+	if config.LabelLookback < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".label_lookback",
+			Message: "不能为负数",
+		})
+	}
+
+	if config.DefaultStep < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".default_step",
+			Message: "不能为负数",
+		})
+	}
+
+	matcherLabels := make([]string, 0, len(config.DefaultMatchers))
+	for name := range config.DefaultMatchers {
+		matcherLabels = append(matcherLabels, name)
+	}
+	sort.Strings(matcherLabels)
+	for _, name := range matcherLabels {
+		if !model.LabelName(name).IsValidLegacy() || name == model.MetricNameLabel {
+			errors = append(errors, ValidationError{
+				Field:   field + ".default_matchers." + name,
+				Message: "不是有效的标签名称",
+			})
+		}
+	}
+
+	metricTypes := make([]string, 0, len(config.CommonMetrics))
+	for metricType := range config.CommonMetrics {
+		metricTypes = append(metricTypes, metricType)
+	}
+	sort.Strings(metricTypes)
+	for _, metricType := range metricTypes {
+		if strings.TrimSpace(metricType) == "" {
+			errors = append(errors, ValidationError{
+				Field:   field + ".common_metrics",
+				Message: "指标类型名称不能为空",
+			})
+		} else if strings.TrimSpace(config.CommonMetrics[metricType]) == "" {
+			errors = append(errors, ValidationError{
+				Field:   field + ".common_metrics." + metricType,
+				Message: "查询语句不能为空",
+			})
+		}
+	}
+
+	timeouts := []struct {
+		field string
+		value time.Duration
+	}{
+		{field + ".query_timeout", config.QueryTimeout},
+		{field + ".range_query_timeout", config.RangeQueryTimeout},
+		{field + ".list_metrics_timeout", config.ListMetricsTimeout},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value < 0 {
+			errors = append(errors, ValidationError{
+				Field:   timeout.field,
+				Message: "超时时间不能为负数",
+			})
+		}
+	}
+
 	return ValidationResult{
 		Valid:  len(errors) == 0,
 		Errors: errors,
@@ -62,35 +280,121 @@ func ValidatePrometheusConfig(config *PrometheusConfig) ValidationResult {
 
 // ValidateSupersetConfig 验证Superset配置 (纯函数)
 func ValidateSupersetConfig(config *SupersetConfig) ValidationResult {
+	return validateSupersetConfig("superset", config)
+}
+
+// validateSupersetConfig 验证Superset配置，field为错误信息中的字段前缀
+func validateSupersetConfig(field string, config *SupersetConfig) ValidationResult {
 	var errors []ValidationError
 
 	if config == nil {
 		return ValidationResult{Valid: false, Errors: []ValidationError{
-			{Field: "superset", Message: "配置不能为空"},
+			{Field: field, Message: "配置不能为空"},
 		}}
 	}
 
 	if config.Enabled {
 		if config.URL == "" {
 			errors = append(errors, ValidationError{
-				Field:   "superset.url",
+				Field:   field + ".url",
 				Message: "服务已启用但URL为空",
 			})
 		}
 		if config.User == "" {
 			errors = append(errors, ValidationError{
-				Field:   "superset.user",
+				Field:   field + ".user",
 				Message: "服务已启用但用户名为空",
 			})
 		}
 		if config.Pass == "" {
 			errors = append(errors, ValidationError{
-				Field:   "superset.pass",
+				Field:   field + ".pass",
 				Message: "服务已启用但密码为空",
 			})
 		}
 	}
 
+	if config.RetryAttempts < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".retry_attempts",
+			Message: "不能为负数",
+		})
+	}
+
+	if config.RetryBackoff < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".retry_backoff",
+			Message: "不能为负数",
+		})
+	}
+
+	if config.SQLTimeout < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".sql_timeout",
+			Message: "不能为负数",
+		})
+	}
+
+	if config.DatabaseCacheTTL < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".database_cache_ttl",
+			Message: "不能为负数",
+		})
+	}
+
+	if config.HealthPath != "" && !strings.HasPrefix(config.HealthPath, "/") {
+		errors = append(errors, ValidationError{
+			Field:   field + ".health_path",
+			Message: "必须以/开头",
+		})
+	}
+
+	if config.CSRFTokenTTL < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".csrf_token_ttl",
+			Message: "不能为负数",
+		})
+	}
+
+	switch config.ColumnNameSource {
+	case "", "name", "column_name":
+	default:
+		errors = append(errors, ValidationError{
+			Field:   field + ".column_name_source",
+			Message: "可选值为 name 或 column_name",
+		})
+	}
+
+	if nameErr := validateInstanceName(field+".name", config.Name); nameErr != nil {
+		errors = append(errors, *nameErr)
+	}
+
+	if portErr := validatePort(field+".port", config.Port); portErr != nil {
+		errors = append(errors, *portErr)
+	}
+
+	if certErr := validateClientCert(field, config.ClientCertFile, config.ClientKeyFile); certErr != nil {
+		errors = append(errors, *certErr)
+	}
+
+	if modeErr := validateReadinessMode(field+".readiness_mode", config.ReadinessMode); modeErr != nil {
+		errors = append(errors, *modeErr)
+	}
+
+	if config.MaxConnsPerHost < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".max_conns_per_host",
+			Message: "不能为负数",
+		})
+	}
+
+	if config.Timeout < 0 {
+		errors = append(errors, ValidationError{
+			Field:   field + ".timeout",
+			Message: "超时时间不能为负数",
+		})
+	}
+
 	return ValidationResult{
 		Valid:  len(errors) == 0,
 		Errors: errors,
@@ -107,15 +411,68 @@ func ValidateConfig(config *Config) ValidationResult {
 		}}
 	}
 
+	if config.MaxResponseBytes < 0 {
+		allErrors = append(allErrors, ValidationError{
+			Field:   "max_response_bytes",
+			Message: "不能为负数",
+		})
+	}
+
+	if ct := config.ResponseContentType; ct != "" && ct != common.ContentTypeText && ct != common.ContentTypeJSON {
+		allErrors = append(allErrors, ValidationError{
+			Field:   "response_content_type",
+			Message: fmt.Sprintf("不支持的内容类型，可选值为 %s 或 %s", common.ContentTypeText, common.ContentTypeJSON),
+		})
+	}
+
+	if config.StartupAttempts < 0 {
+		allErrors = append(allErrors, ValidationError{
+			Field:   "startup_attempts",
+			Message: "不能为负数",
+		})
+	}
+
+	if config.StartupRetryBackoff < 0 {
+		allErrors = append(allErrors, ValidationError{
+			Field:   "startup_retry_backoff",
+			Message: "不能为负数",
+		})
+	}
+
 	// 验证Prometheus配置
 	if promResult := ValidatePrometheusConfig(config.Prometheus); !promResult.IsValid() {
 		allErrors = append(allErrors, promResult.Errors...)
 	}
+	for i, instance := range config.PrometheusInstances {
+		field := fmt.Sprintf("prometheus_instances[%d]", i)
+		if promResult := validatePrometheusConfig(field, instance); !promResult.IsValid() {
+			allErrors = append(allErrors, promResult.Errors...)
+		}
+	}
 
 	// 验证Superset配置
 	if supersetResult := ValidateSupersetConfig(config.Superset); !supersetResult.IsValid() {
 		allErrors = append(allErrors, supersetResult.Errors...)
 	}
+	for i, instance := range config.SupersetInstances {
+		field := fmt.Sprintf("superset_instances[%d]", i)
+		if supersetResult := validateSupersetConfig(field, instance); !supersetResult.IsValid() {
+			allErrors = append(allErrors, supersetResult.Errors...)
+		}
+	}
+
+	// 验证外部服务配置
+	for _, pluginConfig := range config.PluginServices {
+		if err := pluginConfig.Validate(); err != nil {
+			allErrors = append(allErrors, ValidationError{
+				Field:   "plugins." + string(pluginConfig.GetType()),
+				Message: err.Error(),
+			})
+		}
+	}
+
+	// 多实例时端点不能重复
+	allErrors = append(allErrors, validateUniqueEndpoints(FilterEnabledServices(config))...)
 
 	return ValidationResult{
 		Valid:  len(allErrors) == 0,
@@ -123,6 +480,27 @@ func ValidateConfig(config *Config) ValidationResult {
 	}
 }
 
+// validateUniqueEndpoints 检查启用的服务没有重复的端点，配置了独立端口的服务也不例外
+func validateUniqueEndpoints(services []core.ServiceConfig) []ValidationError {
+	var errors []ValidationError
+	seen := make(map[string]core.ServiceType)
+
+	for _, service := range services {
+		// 服务器按路径分发请求，不同端口上的服务也不能使用相同的端点
+		key := service.GetEndpoint()
+		if existing, dup := seen[key]; dup {
+			errors = append(errors, ValidationError{
+				Field:   string(service.GetType()) + ".endpoint",
+				Message: fmt.Sprintf("端点 %s 与 %s 服务重复，多实例需配置不同的name或endpoint", service.GetEndpoint(), existing),
+			})
+			continue
+		}
+		seen[key] = service.GetType()
+	}
+
+	return errors
+}
+
 // FilterEnabledServices 过滤启用的服务配置 (纯函数)
 func FilterEnabledServices(config *Config) []core.ServiceConfig {
 	if config == nil {
@@ -131,12 +509,52 @@ func FilterEnabledServices(config *Config) []core.ServiceConfig {
 
 	var services []core.ServiceConfig
 
-	if config.Prometheus != nil && config.Prometheus.IsEnabled() {
-		services = append(services, config.Prometheus)
+	for _, promConfig := range config.AllPrometheusConfigs() {
+		if promConfig.IsEnabled() {
+			services = append(services, promConfig)
+		}
 	}
 
-	if config.Superset != nil && config.Superset.IsEnabled() {
-		services = append(services, config.Superset)
+	for _, supersetConfig := range config.AllSupersetConfigs() {
+		if supersetConfig.IsEnabled() {
+			services = append(services, supersetConfig)
+		}
+	}
+
+	for _, pluginConfig := range config.PluginServices {
+		if pluginConfig.IsEnabled() {
+			services = append(services, pluginConfig)
+		}
+	}
+
+	return services
+}
+
+// FilterDisabledServices 过滤已配置但未启用的服务配置 (纯函数)
+// 配置文件中没有出现的服务段不返回
+func FilterDisabledServices(config *Config) []core.ServiceConfig {
+	if config == nil {
+		return []core.ServiceConfig{}
+	}
+
+	var services []core.ServiceConfig
+
+	for _, promConfig := range config.AllPrometheusConfigs() {
+		if !promConfig.IsEnabled() && promConfig.isConfigured() {
+			services = append(services, promConfig)
+		}
+	}
+
+	for _, supersetConfig := range config.AllSupersetConfigs() {
+		if !supersetConfig.IsEnabled() && supersetConfig.isConfigured() {
+			services = append(services, supersetConfig)
+		}
+	}
+
+	for _, pluginConfig := range config.PluginServices {
+		if !pluginConfig.IsEnabled() {
+			services = append(services, pluginConfig)
+		}
 	}
 
 	return services
@@ -149,6 +567,13 @@ func ValidateServiceConfig(serviceConfig core.ServiceConfig) ValidationResult {
 		return ValidatePrometheusConfig(config)
 	case *SupersetConfig:
 		return ValidateSupersetConfig(config)
+	case core.ServiceConfig:
+		if err := config.Validate(); err != nil {
+			return ValidationResult{Valid: false, Errors: []ValidationError{
+				{Field: "plugins." + string(config.GetType()), Message: err.Error()},
+			}}
+		}
+		return ValidationResult{Valid: true}
 	default:
 		return ValidationResult{Valid: false, Errors: []ValidationError{
 			{Field: "service", Message: fmt.Sprintf("未知的服务配置类型: %T", serviceConfig)},
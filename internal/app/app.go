@@ -0,0 +1,357 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"mcp-server/config"
+	"mcp-server/internal/common"
+	"mcp-server/internal/core"
+	"mcp-server/internal/multiplexer"
+	_ "mcp-server/internal/services" // 导入以确保init()函数执行，注册服务工厂
+)
+
+// Run 加载配置、初始化所有已注册的服务并启动多路复用服务器，阻塞直到收到关闭信号
+func Run() {
+	runtime.GOMAXPROCS(runtime.NumCPU())
+
+	// 加载配置
+	cfg := config.LoadConfig()
+
+	// 打印启动信息
+	printStartupInfo(cfg)
+
+	// 设置响应大小上限
+	common.SetMaxResponseBytes(cfg.MaxResponseBytes)
+	common.SetResponseContentType(cfg.ResponseContentType)
+
+	// 创建上下文用于优雅关闭
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 创建多路复用服务器
+	state := &reloadState{cfg: cfg}
+	server := multiplexer.NewServer(cfg.HTTPPort,
+		multiplexer.WithAuthToken(cfg.HTTPAuthToken),
+		multiplexer.WithCORSAllowedOrigins(cfg.CORSAllowedOrigins),
+		multiplexer.WithMOTD(cfg.MOTD),
+		multiplexer.WithWaitForReady(cfg.WaitForReady),
+		multiplexer.WithMetrics(cfg.MetricsEnabled),
+		multiplexer.WithConfigValidator(validateConfigYAML),
+		multiplexer.WithConfigDumper(state.dumpConfig),
+	)
+
+	// 并发初始化和注册服务，启用wait_for_ready时连接测试推迟到监听端口之后的预热阶段
+	registered, services, err := initializeAndRegisterServices(ctx, cfg, server)
+	if err != nil {
+		log.Fatalf("初始化服务失败: %v", err)
+	}
+	state.registered = registered
+
+	var warmUp func() error
+	if cfg.WaitForReady {
+		warmUp = func() error { return warmUpServices(ctx, cfg, server, services) }
+	}
+
+	// 启动服务器并等待关闭信号
+	if err := runServer(ctx, server, state, warmUp); err != nil {
+		log.Fatalf("启动失败: %v", err)
+	}
+}
+
+// printStartupInfo 打印启动信息
+func printStartupInfo(cfg *config.Config) {
+	log.Printf("启动MCP服务器...")
+	log.Printf("配置信息:")
+	log.Printf("- HTTP端口: %s", cfg.HTTPPort)
+	log.Printf("- 超时时间: %v", cfg.Timeout)
+	if cfg.MaxResponseBytes > 0 {
+		log.Printf("- 响应大小上限: %d 字节", cfg.MaxResponseBytes)
+	}
+	if cfg.ResponseContentType != "" {
+		log.Printf("- 结果内容类型: %s", cfg.ResponseContentType)
+	}
+	if cfg.WaitForReady {
+		log.Printf("- 预热门控: 已启用，预热完成前/readyz和MCP端点返回503")
+	}
+
+	// 打印启用的服务
+	services := cfg.GetServices()
+	log.Printf("- 启用的服务数量: %d", len(services))
+	for _, service := range services {
+		if provider, ok := service.(core.PortProvider); ok && provider.GetPort() != "" {
+			log.Printf("  * %s: %s (独立端口: %s)", service.GetType(), service.GetEndpoint(), provider.GetPort())
+			continue
+		}
+		log.Printf("  * %s: %s", service.GetType(), service.GetEndpoint())
+	}
+}
+
+// serviceResult 服务初始化结果
+type serviceResult struct {
+	config    core.ServiceConfig
+	service   core.Service
+	connected bool // 启动时连接测试是否成功
+}
+
+// createService 创建服务实例并测试连接，连接失败时仍返回服务，只记录警告
+// 启用strict_startup时连接失败视为错误，关闭服务并返回错误
+// testConnection为false时跳过连接测试，由预热阶段负责
+func createService(ctx context.Context, cfg *config.Config, serviceConfig core.ServiceConfig, testConnection bool) (serviceResult, error) {
+	log.Printf("初始化服务: %s (%s)", serviceConfig.GetType(), serviceConfig.GetEndpoint())
+
+	// 使用新的函数式API创建服务实例
+	service, err := core.CreateService(serviceConfig, cfg.Timeout)
+	if err != nil {
+		return serviceResult{}, fmt.Errorf("创建服务 %s (%s) 失败: %w", serviceConfig.GetType(), serviceConfig.GetEndpoint(), err)
+	}
+
+	if !testConnection {
+		return serviceResult{config: serviceConfig, service: service}, nil
+	}
+
+	// 测试连接（上游可能尚未就绪，按配置重试）
+	connected := false
+	if err := testServiceConnection(ctx, service, cfg.StartupAttempts, cfg.StartupRetryBackoff); err != nil {
+		if cfg.StrictStartup {
+			service.Close()
+			return serviceResult{}, fmt.Errorf("%s (%s) 连接测试失败: %w", service.GetType(), service.GetEndpoint(), err)
+		}
+		log.Printf("警告: %s (%s) 连接测试失败: %v", service.GetType(), service.GetEndpoint(), err)
+	} else {
+		log.Printf("✓ %s (%s) 连接正常", service.GetType(), service.GetEndpoint())
+		connected = true
+	}
+
+	return serviceResult{config: serviceConfig, service: service, connected: connected}, nil
+}
+
+// initializeAndRegisterServices 并发初始化并注册所有服务，返回已注册服务的配置（按端点索引）及服务实例
+// 启用strict_startup时任一服务创建或连接失败都返回错误，否则只要有一个服务成功即可启动
+func initializeAndRegisterServices(ctx context.Context, cfg *config.Config, server *multiplexer.Server) (map[string]core.ServiceConfig, []core.Service, error) {
+	// 使用新的函数式API获取服务配置
+	serviceConfigs := config.FilterEnabledServices(cfg)
+
+	if len(serviceConfigs) == 0 {
+		return nil, nil, fmt.Errorf("没有启用的服务配置")
+	}
+
+	var wg sync.WaitGroup
+	serviceChan := make(chan serviceResult, len(serviceConfigs))
+	errorChan := make(chan error, len(serviceConfigs))
+
+	// 并发创建服务
+	for _, serviceConfig := range serviceConfigs {
+		wg.Add(1)
+		go func(serviceConfig core.ServiceConfig) {
+			defer wg.Done()
+
+			result, err := createService(ctx, cfg, serviceConfig, !cfg.WaitForReady)
+			if err != nil {
+				errorChan <- err
+				return
+			}
+			serviceChan <- result
+		}(serviceConfig)
+	}
+
+	// 等待所有服务初始化完成
+	go func() {
+		wg.Wait()
+		close(serviceChan)
+		close(errorChan)
+	}()
+
+	// 收集结果
+	var services []core.Service
+	var errs []error
+	registered := make(map[string]core.ServiceConfig)
+
+	for result := range serviceChan {
+		services = append(services, result.service)
+		registered[result.service.GetEndpoint()] = result.config
+		if result.connected {
+			server.RecordConnectionSuccess(result.service.GetEndpoint())
+		}
+	}
+
+	for err := range errorChan {
+		errs = append(errs, err)
+	}
+
+	if cfg.StrictStartup && len(errs) > 0 {
+		for _, service := range services {
+			service.Close()
+		}
+		return nil, nil, fmt.Errorf("严格启动模式下 %d 个服务初始化失败: %w", len(errs), errors.Join(errs...))
+	}
+
+	// 注册成功创建的服务
+	for _, service := range services {
+		server.AddService(service)
+	}
+
+	// 记录已配置但禁用的服务，便于在信息页面展示
+	for _, disabledConfig := range config.FilterDisabledServices(cfg) {
+		server.AddDisabledService(disabledConfig.GetType(), disabledConfig.GetEndpoint())
+	}
+
+	// 如果有错误但至少有一个服务成功，记录警告
+	if len(errs) > 0 {
+		for _, err := range errs {
+			log.Printf("警告: %v", err)
+		}
+	}
+
+	// 如果没有任何服务成功创建，返回错误
+	if len(services) == 0 {
+		return nil, nil, fmt.Errorf("没有成功创建任何服务")
+	}
+
+	log.Printf("✓ 成功初始化 %d 个服务", len(services))
+	return registered, services, nil
+}
+
+// warmUpServices 预热：并发测试连接，支持完整功能验证的服务同时登录并预取数据（如Superset数据库列表）
+// 全部完成后无论成败都结束预热，之后由常规的就绪检查反映各服务状态；启用strict_startup时连接失败则返回错误
+func warmUpServices(ctx context.Context, cfg *config.Config, server *multiplexer.Server, services []core.Service) error {
+	log.Printf("开始预热 %d 个服务...", len(services))
+
+	var wg sync.WaitGroup
+	var failed atomic.Int32
+	for _, service := range services {
+		wg.Add(1)
+		go func(service core.Service) {
+			defer wg.Done()
+
+			if err := testServiceConnection(ctx, service, cfg.StartupAttempts, cfg.StartupRetryBackoff); err != nil {
+				log.Printf("警告: %s (%s) 连接测试失败: %v", service.GetType(), service.GetEndpoint(), err)
+				failed.Add(1)
+				return
+			}
+			server.RecordConnectionSuccess(service.GetEndpoint())
+
+			if checker, ok := service.(core.FunctionalChecker); ok {
+				checkCtx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+				defer cancel()
+				if err := checker.CheckFunctionality(checkCtx); err != nil {
+					log.Printf("警告: %s (%s) 预热失败: %v", service.GetType(), service.GetEndpoint(), err)
+					return
+				}
+			}
+			log.Printf("✓ %s (%s) 预热完成", service.GetType(), service.GetEndpoint())
+		}(service)
+	}
+	wg.Wait()
+
+	if cfg.StrictStartup && failed.Load() > 0 {
+		return fmt.Errorf("严格启动模式下 %d 个服务预热时连接测试失败", failed.Load())
+	}
+
+	server.MarkReady()
+	return nil
+}
+
+// testServiceConnection 测试服务连接，失败时按指数退避重试
+func testServiceConnection(ctx context.Context, service core.Service, attempts int, backoff time.Duration) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		testCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err = service.TestConnection(testCtx)
+		cancel()
+
+		if err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		log.Printf("%s 连接测试失败 (第%d/%d次): %v，%v后重试", service.GetType(), attempt, attempts, err, backoff)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+
+	return fmt.Errorf("连接测试 %d 次均失败: %w", attempts, err)
+}
+
+// runServer 运行服务器并处理信号：SIGHUP重新加载配置，SIGINT/SIGTERM优雅关闭
+// warmUp非空时在开始监听后于后台执行，返回错误时同样优雅关闭，并在关闭完成后返回该错误
+func runServer(ctx context.Context, server *multiplexer.Server, state *reloadState, warmUp func() error) error {
+	// 设置信号处理
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	// 启动服务器
+	go func() {
+		if err := server.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("启动服务器失败: %v", err)
+		}
+	}()
+
+	warmUpErr := make(chan error, 1)
+	if warmUp != nil {
+		go func() {
+			if err := warmUp(); err != nil {
+				warmUpErr <- err
+			}
+		}()
+	}
+
+	// 配置重新加载在后台执行，连接测试的重试退避期间仍能及时响应关闭信号
+	reloads := startReloader(ctx, func(ctx context.Context) { state.reload(ctx, server) })
+
+	// 等待关闭信号或预热失败，期间处理配置重新加载
+	var runErr error
+wait:
+	for {
+		select {
+		case sig := <-sigChan:
+			if sig != syscall.SIGHUP {
+				log.Printf("收到关闭信号，正在关闭...")
+				break wait
+			}
+			if reloads.trigger() {
+				log.Printf("收到SIGHUP信号，重新加载配置...")
+			} else {
+				log.Printf("收到SIGHUP信号，已有等待执行的重新加载，本次合并执行")
+			}
+		case runErr = <-warmUpErr:
+			log.Printf("预热失败，正在关闭: %v", runErr)
+			break wait
+		}
+	}
+
+	// 等待进行中的重新加载结束，避免关闭后仍注册服务
+	reloads.stop()
+
+	// 优雅关闭
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("关闭服务器时出错: %v", err)
+	} else {
+		log.Printf("服务器已关闭")
+	}
+	return runErr
+}
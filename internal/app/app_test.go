@@ -0,0 +1,156 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-server/config"
+	"mcp-server/internal/core"
+	"mcp-server/internal/multiplexer"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const fakeServiceType core.ServiceType = "app-test"
+
+// fakeConfig 测试用服务配置
+type fakeConfig struct {
+	endpoint string
+}
+
+func (c *fakeConfig) GetType() core.ServiceType { return fakeServiceType }
+func (c *fakeConfig) GetEndpoint() string       { return c.endpoint }
+func (c *fakeConfig) IsEnabled() bool           { return true }
+func (c *fakeConfig) Validate() error           { return nil }
+
+// unreachableService 连接测试始终失败的服务
+type unreachableService struct {
+	endpoint string
+	server   *mcp.Server
+	closed   bool
+}
+
+func newUnreachableService(endpoint string) *unreachableService {
+	return &unreachableService{
+		endpoint: endpoint,
+		server:   mcp.NewServer(&mcp.Implementation{Name: "fake", Version: "test"}, nil),
+	}
+}
+
+func (s *unreachableService) GetServer() *mcp.Server { return s.server }
+func (s *unreachableService) TestConnection(context.Context) error {
+	return errors.New("connection refused")
+}
+func (s *unreachableService) Close() error              { s.closed = true; return nil }
+func (s *unreachableService) GetType() core.ServiceType { return fakeServiceType }
+func (s *unreachableService) GetEndpoint() string       { return s.endpoint }
+
+func init() {
+	core.RegisterServiceFactory(fakeServiceType, func(serviceConfig core.ServiceConfig, _ time.Duration) (core.Service, error) {
+		return newUnreachableService(serviceConfig.GetEndpoint()), nil
+	})
+}
+
+func TestCreateServiceStrictStartup(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		strict  bool
+		wantErr bool
+	}{
+		{name: "non-strict proceeds", strict: false, wantErr: false},
+		{name: "strict fails", strict: true, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{StrictStartup: tc.strict, StartupAttempts: 1, Timeout: time.Second}
+			result, err := createService(context.Background(), cfg, &fakeConfig{endpoint: "/fake/mcp"}, true)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("createService() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if err == nil && (result.service == nil || result.connected) {
+				t.Errorf("result = %+v, want service registered as not connected", result)
+			}
+		})
+	}
+}
+
+func TestWarmUpServicesStrictStartup(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		strict  bool
+		wantErr bool
+	}{
+		{name: "non-strict proceeds", strict: false, wantErr: false},
+		{name: "strict fails", strict: true, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{StrictStartup: tc.strict, StartupAttempts: 1, Timeout: time.Second}
+			server := multiplexer.NewServer("0", multiplexer.WithWaitForReady(true))
+			service := newUnreachableService("/fake/mcp")
+			server.AddService(service)
+
+			err := warmUpServices(context.Background(), cfg, server, []core.Service{service})
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("warmUpServices() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunServerShutsDownOnWarmUpError(t *testing.T) {
+	cfg := &config.Config{HTTPPort: "0"}
+	server := multiplexer.NewServer("0")
+	warmUpErr := errors.New("warm up failed")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- runServer(context.Background(), server, &reloadState{cfg: cfg}, func() error { return warmUpErr })
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, warmUpErr) {
+			t.Errorf("runServer() = %v, want %v", err, warmUpErr)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("runServer did not return after warm-up failure")
+	}
+}
+
+// flakyService 前failures次连接测试失败，之后成功
+type flakyService struct {
+	*unreachableService
+	failures int
+	calls    int
+}
+
+func (s *flakyService) TestConnection(context.Context) error {
+	s.calls++
+	if s.calls <= s.failures {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestServiceConnectionRetries(t *testing.T) {
+	// 第三次尝试时上游恢复
+	service := &flakyService{unreachableService: newUnreachableService("/fake/mcp"), failures: 2}
+	if err := testServiceConnection(context.Background(), service, 3, time.Millisecond); err != nil {
+		t.Fatalf("testServiceConnection() = %v, want success on the third attempt", err)
+	}
+	if service.calls != 3 {
+		t.Errorf("TestConnection calls = %d, want 3", service.calls)
+	}
+
+	// 尝试次数不足时返回包装后的最后一次错误
+	service = &flakyService{unreachableService: newUnreachableService("/fake/mcp"), failures: 2}
+	err := testServiceConnection(context.Background(), service, 2, time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "连接测试 2 次均失败") || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("testServiceConnection() = %v, want wrapped failure after 2 attempts", err)
+	}
+	if service.calls != 2 {
+		t.Errorf("TestConnection calls = %d, want 2", service.calls)
+	}
+}
@@ -0,0 +1,220 @@
+package app
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"mcp-server/config"
+	"mcp-server/internal/common"
+	"mcp-server/internal/core"
+	"mcp-server/internal/multiplexer"
+)
+
+// reloadState 当前生效的配置及已注册服务的配置（按端点索引）
+type reloadState struct {
+	mu         sync.RWMutex // 保护cfg，配置导出端点在HTTP请求中读取
+	cfg        *config.Config
+	registered map[string]core.ServiceConfig
+}
+
+// current 返回当前生效的配置
+func (st *reloadState) current() *config.Config {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.cfg
+}
+
+// dumpConfig 导出当前生效的配置，敏感字段已脱敏
+func (st *reloadState) dumpConfig() ([]byte, error) {
+	return config.DumpRedactedYAML(st.current())
+}
+
+// reloader 在后台执行配置重新加载，同一时间只有一次重新加载在执行
+// 执行期间收到的重新加载请求合并为一次，在当前重新加载结束后执行，信号处理不会被阻塞
+type reloader struct {
+	requests chan struct{}
+	done     chan struct{}
+	cancel   context.CancelFunc
+}
+
+// startReloader 启动后台重新加载协程
+func startReloader(ctx context.Context, reload func(context.Context)) *reloader {
+	ctx, cancel := context.WithCancel(ctx)
+	r := &reloader{
+		requests: make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		cancel:   cancel,
+	}
+	go func() {
+		defer close(r.done)
+		for range r.requests {
+			// 关闭时丢弃排队的请求
+			if ctx.Err() != nil {
+				continue
+			}
+			reload(ctx)
+		}
+	}()
+	return r
+}
+
+// trigger 请求重新加载，已有等待执行的请求时合并并返回false
+func (r *reloader) trigger() bool {
+	select {
+	case r.requests <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// stop 取消进行中的重新加载（中断连接测试的重试退避）并等待其结束，之后不再接受请求
+func (r *reloader) stop() {
+	r.cancel()
+	close(r.requests)
+	<-r.done
+}
+
+// reload 重新读取配置文件，按端点对比启用的服务并增删或替换，配置未变化的服务保持不动
+// 配置文件无效时保留当前配置，HTTP监听不受影响
+func (st *reloadState) reload(ctx context.Context, server *multiplexer.Server) {
+	newCfg, err := config.LoadConfigFromYAML(st.cfg.Path)
+	if err != nil {
+		log.Printf("重新加载配置失败，继续使用当前配置: %v", err)
+		return
+	}
+
+	logRestartRequired(st.cfg, newCfg)
+	common.SetMaxResponseBytes(newCfg.MaxResponseBytes)
+	common.SetResponseContentType(newCfg.ResponseContentType)
+	// 仅在配置文件中的公告变化时更新，避免覆盖通过管理端点设置的公告
+	if newCfg.MOTD != st.cfg.MOTD {
+		server.SetMOTD(newCfg.MOTD)
+	}
+
+	desired := make(map[string]core.ServiceConfig)
+	for _, serviceConfig := range config.FilterEnabledServices(newCfg) {
+		desired[serviceConfig.GetEndpoint()] = serviceConfig
+	}
+
+	next := make(map[string]core.ServiceConfig, len(desired))
+	var added, updated, removed []string
+
+	for _, endpoint := range sortedEndpoints(desired) {
+		serviceConfig := desired[endpoint]
+		previous, exists := st.registered[endpoint]
+		if exists && st.cfg.Timeout == newCfg.Timeout && reflect.DeepEqual(previous, serviceConfig) {
+			next[endpoint] = previous
+			continue
+		}
+
+		result, err := createService(ctx, newCfg, serviceConfig, true)
+		if err != nil {
+			log.Printf("警告: %v", err)
+			if exists {
+				log.Printf("保留服务 %s 的原有配置", endpoint)
+				next[endpoint] = previous
+			}
+			continue
+		}
+
+		server.AddService(result.service)
+		if result.connected {
+			server.RecordConnectionSuccess(endpoint)
+		}
+		next[endpoint] = serviceConfig
+
+		if exists {
+			updated = append(updated, endpoint)
+		} else {
+			added = append(added, endpoint)
+		}
+	}
+
+	for _, endpoint := range sortedEndpoints(st.registered) {
+		if _, exists := desired[endpoint]; !exists {
+			server.RemoveService(endpoint)
+			removed = append(removed, endpoint)
+		}
+	}
+
+	// 刷新信息页面中展示的禁用服务
+	for _, disabledConfig := range config.FilterDisabledServices(st.cfg) {
+		server.RemoveDisabledService(disabledConfig.GetEndpoint())
+	}
+	for _, disabledConfig := range config.FilterDisabledServices(newCfg) {
+		if _, exists := next[disabledConfig.GetEndpoint()]; !exists {
+			server.AddDisabledService(disabledConfig.GetType(), disabledConfig.GetEndpoint())
+		}
+	}
+
+	st.mu.Lock()
+	st.cfg = newCfg
+	st.mu.Unlock()
+	st.registered = next
+
+	log.Printf("✓ 配置重新加载完成: 新增 %d 个%s，更新 %d 个%s，移除 %d 个%s，未变化 %d 个",
+		len(added), formatEndpoints(added),
+		len(updated), formatEndpoints(updated),
+		len(removed), formatEndpoints(removed),
+		len(next)-len(added)-len(updated))
+}
+
+// logRestartRequired 记录需要重启才能生效的全局配置变更
+func logRestartRequired(current, next *config.Config) {
+	var fields []string
+	if current.HTTPPort != next.HTTPPort {
+		fields = append(fields, "http_port")
+	}
+	if current.HTTPAuthToken != next.HTTPAuthToken {
+		fields = append(fields, "http_auth_token")
+	}
+	if !reflect.DeepEqual(current.CORSAllowedOrigins, next.CORSAllowedOrigins) {
+		fields = append(fields, "cors_allowed_origins")
+	}
+	if current.WaitForReady != next.WaitForReady {
+		fields = append(fields, "wait_for_ready")
+	}
+	if current.MetricsEnabled != next.MetricsEnabled {
+		fields = append(fields, "metrics_enabled")
+	}
+	if len(fields) > 0 {
+		log.Printf("警告: 以下配置变更需重启后生效: %s", strings.Join(fields, ", "))
+	}
+}
+
+// sortedEndpoints 获取排序后的端点列表，保证日志顺序稳定
+func sortedEndpoints(services map[string]core.ServiceConfig) []string {
+	endpoints := make([]string, 0, len(services))
+	for endpoint := range services {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+	return endpoints
+}
+
+// formatEndpoints 格式化端点列表用于日志
+func formatEndpoints(endpoints []string) string {
+	if len(endpoints) == 0 {
+		return ""
+	}
+	return "(" + strings.Join(endpoints, ", ") + ")"
+}
+
+// validateConfigYAML 将配置校验结果转换为multiplexer的格式，供server_validate_config工具使用
+func validateConfigYAML(data []byte) ([]multiplexer.ConfigIssue, error) {
+	result, err := config.ValidateConfigYAML(data)
+	if err != nil {
+		return nil, err
+	}
+
+	issues := make([]multiplexer.ConfigIssue, 0, len(result.Errors))
+	for _, validationErr := range result.Errors {
+		issues = append(issues, multiplexer.ConfigIssue{Field: validationErr.Field, Message: validationErr.Message})
+	}
+	return issues, nil
+}
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReloaderRunsInBackgroundAndCoalesces(t *testing.T) {
+	started := make(chan struct{}, 3)
+	var calls atomic.Int32
+	reloads := startReloader(context.Background(), func(ctx context.Context) {
+		calls.Add(1)
+		started <- struct{}{}
+		// 模拟连接测试的重试退避，只能被取消打断
+		<-ctx.Done()
+	})
+
+	if !reloads.trigger() {
+		t.Fatal("first trigger was merged")
+	}
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("reload did not start")
+	}
+
+	// 重新加载进行中时trigger立即返回，第二次排队，第三次合并
+	if !reloads.trigger() {
+		t.Error("second trigger was merged, want queued")
+	}
+	if reloads.trigger() {
+		t.Error("third trigger was queued, want merged")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		reloads.stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stop did not cancel the running reload")
+	}
+	// 排队的请求在关闭时被丢弃
+	if got := calls.Load(); got != 1 {
+		t.Errorf("reload ran %d times, want 1", got)
+	}
+}
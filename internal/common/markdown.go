@@ -0,0 +1,67 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormatMarkdown 各工具统一使用的Markdown输出格式名称
+const FormatMarkdown = "markdown"
+
+// Markdown表格的默认上限，超出部分省略，避免表格过宽或过长难以阅读
+const (
+	markdownMaxColumns = 20
+	markdownMaxRows    = 200
+	markdownEllipsis   = "…"
+)
+
+// markdownCellEscaper 转义单元格中会破坏表格结构的字符
+var markdownCellEscaper = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ", "\r", " ")
+
+// FormatMarkdownTable 将列和数据行渲染为Markdown表格
+// 列数超过上限时以省略号列结尾，行数超过上限时截断并在表格后附加说明
+func FormatMarkdownTable(columns []string, rows [][]string) string {
+	visible := len(columns)
+	truncatedColumns := visible > markdownMaxColumns
+	if truncatedColumns {
+		visible = markdownMaxColumns
+	}
+
+	var b strings.Builder
+	writeRow := func(cells func(i int) string, ellipsis string) {
+		b.WriteString("|")
+		for i := 0; i < visible; i++ {
+			b.WriteString(" ")
+			b.WriteString(markdownCellEscaper.Replace(cells(i)))
+			b.WriteString(" |")
+		}
+		if truncatedColumns {
+			b.WriteString(" " + ellipsis + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	writeRow(func(i int) string { return columns[i] }, markdownEllipsis)
+	writeRow(func(int) string { return "---" }, "---")
+
+	shown := rows
+	if len(shown) > markdownMaxRows {
+		shown = shown[:markdownMaxRows]
+	}
+	for _, row := range shown {
+		writeRow(func(i int) string {
+			if i < len(row) {
+				return row[i]
+			}
+			return ""
+		}, markdownEllipsis)
+	}
+
+	if truncatedColumns {
+		fmt.Fprintf(&b, "\n共 %d 列，仅显示前 %d 列", len(columns), visible)
+	}
+	if len(rows) > len(shown) {
+		fmt.Fprintf(&b, "\n共 %d 行，仅显示前 %d 行", len(rows), len(shown))
+	}
+	return b.String()
+}
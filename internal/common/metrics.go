@@ -0,0 +1,69 @@
+package common
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// MetricsRegistry MCP服务器自身的指标注册表
+// 指标始终采集（开销很小），是否通过/metrics暴露由配置决定
+var MetricsRegistry = prometheus.NewRegistry()
+
+var (
+	toolCallsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mcp_tool_calls_total",
+		Help: "MCP工具调用次数，status为success、error（工具返回错误结果）或failure（协议层错误）",
+	}, []string{"service", "endpoint", "tool", "status"})
+
+	toolDurationSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "mcp_tool_duration_seconds",
+		Help:    "MCP工具调用耗时",
+		Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"service", "endpoint", "tool"})
+
+	upstreamRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "mcp_upstream_requests_total",
+		Help: "发往上游服务的HTTP请求数（每次重试单独计数），code为HTTP状态码，连接失败时为error",
+	}, []string{"service", "code"})
+)
+
+func init() {
+	MetricsRegistry.MustRegister(
+		toolCallsTotal,
+		toolDurationSeconds,
+		upstreamRequestsTotal,
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+}
+
+// ObserveToolCall 记录一次工具调用
+func ObserveToolCall(service, endpoint, tool, status string, duration time.Duration) {
+	toolCallsTotal.WithLabelValues(service, endpoint, tool, status).Inc()
+	toolDurationSeconds.WithLabelValues(service, endpoint, tool).Observe(duration.Seconds())
+}
+
+// upstreamMetricsRoundTripper 统计发往上游的请求数
+type upstreamMetricsRoundTripper struct {
+	service string
+	next    http.RoundTripper
+}
+
+// InstrumentTransport 包装传输层，按服务类型和状态码统计上游请求
+func InstrumentTransport(service string, next http.RoundTripper) http.RoundTripper {
+	return &upstreamMetricsRoundTripper{service: service, next: next}
+}
+
+// RoundTrip 实现http.RoundTripper接口
+func (t *upstreamMetricsRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		upstreamRequestsTotal.WithLabelValues(t.service, "error").Inc()
+		return nil, err
+	}
+	upstreamRequestsTotal.WithLabelValues(t.service, strconv.Itoa(resp.StatusCode)).Inc()
+	return resp, nil
+}
@@ -0,0 +1,17 @@
+package common
+
+import "context"
+
+// requestIDKey 请求ID在上下文中的键
+type requestIDKey struct{}
+
+// WithRequestID 将工具调用的请求ID写入上下文
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID 获取上下文中的请求ID，未设置时返回空字符串
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
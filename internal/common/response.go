@@ -3,6 +3,10 @@ package common
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"sync/atomic"
+
+	"mcp-server/internal/core"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -23,6 +27,88 @@ var (
 	}
 )
 
+// maxResponseBytes 单个响应允许缓冲的最大字节数，0表示不限制
+var maxResponseBytes atomic.Int64
+
+// SetMaxResponseBytes 设置单个响应允许缓冲的最大字节数
+func SetMaxResponseBytes(limit int64) {
+	maxResponseBytes.Store(limit)
+}
+
+// MaxResponseBytes 获取单个响应允许缓冲的最大字节数
+func MaxResponseBytes() int64 {
+	return maxResponseBytes.Load()
+}
+
+// 工具结果的内容类型
+const (
+	ContentTypeText = "text" // 纯文本内容块，默认
+	ContentTypeJSON = "json" // MIME类型为application/json的嵌入资源内容块
+
+	jsonMIMEType   = "application/json"
+	jsonContentURI = "mcp-server://result.json"
+)
+
+// responseContentType 成功结果使用的内容类型
+var responseContentType atomic.Value
+
+// SetResponseContentType 设置成功结果使用的内容类型，空值表示纯文本
+func SetResponseContentType(contentType string) {
+	if contentType == "" {
+		contentType = ContentTypeText
+	}
+	responseContentType.Store(contentType)
+}
+
+// ResponseContentType 获取成功结果使用的内容类型
+func ResponseContentType() string {
+	if contentType, ok := responseContentType.Load().(string); ok {
+		return contentType
+	}
+	return ContentTypeText
+}
+
+// jsonContent 按配置的内容类型包装JSON文本
+func jsonContent(text string) mcp.Content {
+	if ResponseContentType() == ContentTypeJSON {
+		return &mcp.EmbeddedResource{Resource: &mcp.ResourceContents{
+			URI:      jsonContentURI,
+			MIMEType: jsonMIMEType,
+			Text:     text,
+		}}
+	}
+	return &mcp.TextContent{Text: text}
+}
+
+// ReadAllLimited 读取全部内容，超过响应大小上限时返回ResponseTooLargeError
+func ReadAllLimited(r io.Reader) ([]byte, error) {
+	limit := MaxResponseBytes()
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+
+	// 多读一个字节用于判断是否超限
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, core.NewResponseTooLargeError(limit)
+	}
+	return data, nil
+}
+
+// checkResponseSize 检查响应大小是否超限
+func checkResponseSize(size int) *mcp.CallToolResultFor[any] {
+	if limit := MaxResponseBytes(); limit > 0 && int64(size) > limit {
+		return &mcp.CallToolResultFor[any]{
+			IsError: true,
+			Content: []mcp.Content{&mcp.TextContent{Text: core.NewResponseTooLargeError(limit).Error()}},
+		}
+	}
+	return nil
+}
+
 // CreateSuccessResponse 创建成功响应结果
 func CreateSuccessResponse(data any) (*mcp.CallToolResultFor[any], error) {
 	jsonData, err := json.Marshal(data)
@@ -33,8 +119,12 @@ func CreateSuccessResponse(data any) (*mcp.CallToolResultFor[any], error) {
 		}, nil
 	}
 
+	if tooLarge := checkResponseSize(len(jsonData)); tooLarge != nil {
+		return tooLarge, nil
+	}
+
 	return &mcp.CallToolResultFor[any]{
-		Content: []mcp.Content{&mcp.TextContent{Text: string(jsonData)}},
+		Content: []mcp.Content{jsonContent(string(jsonData))},
 	}, nil
 }
 
@@ -75,6 +165,10 @@ func CreateErrorResponseWithKey(errorKey string) (*mcp.CallToolResultFor[any], e
 
 // CreateSimpleSuccessResponse 创建简单字符串成功响应（避免JSON序列化）
 func CreateSimpleSuccessResponse(message string) (*mcp.CallToolResultFor[any], error) {
+	if tooLarge := checkResponseSize(len(message)); tooLarge != nil {
+		return tooLarge, nil
+	}
+
 	return &mcp.CallToolResultFor[any]{
 		Content: []mcp.Content{&mcp.TextContent{Text: message}},
 	}, nil
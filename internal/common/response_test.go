@@ -0,0 +1,71 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"mcp-server/internal/core"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// setMaxResponseBytes 设置响应大小上限，测试结束后恢复
+func setMaxResponseBytes(t *testing.T, limit int64) {
+	t.Helper()
+	previous := MaxResponseBytes()
+	SetMaxResponseBytes(limit)
+	t.Cleanup(func() { SetMaxResponseBytes(previous) })
+}
+
+func TestResponseExceedsCap(t *testing.T) {
+	setMaxResponseBytes(t, 16)
+
+	result, err := CreateSuccessResponse(map[string]string{"data": strings.Repeat("x", 32)})
+	if err != nil {
+		t.Fatalf("CreateSuccessResponse: %v", err)
+	}
+	if !result.IsError || !strings.Contains(result.Content[0].(*mcp.TextContent).Text, "响应过大") {
+		t.Errorf("result = %+v, want response too large error", result.Content[0])
+	}
+
+	result, _ = CreateSuccessResponse("ok")
+	if result.IsError {
+		t.Errorf("small response rejected: %v", result.Content[0].(*mcp.TextContent).Text)
+	}
+
+	_, err = ReadAllLimited(bytes.NewReader(make([]byte, 17)))
+	var tooLarge *core.ResponseTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.Limit != 16 {
+		t.Errorf("ReadAllLimited(17 bytes) error = %v, want ResponseTooLargeError with limit 16", err)
+	}
+	if data, err := ReadAllLimited(bytes.NewReader(make([]byte, 16))); err != nil || len(data) != 16 {
+		t.Errorf("ReadAllLimited(16 bytes) = %d bytes, %v", len(data), err)
+	}
+}
+
+func TestResponseContentType(t *testing.T) {
+	t.Cleanup(func() { SetResponseContentType("") })
+
+	SetResponseContentType(ContentTypeJSON)
+	result, _ := CreateSuccessResponse(map[string]int{"count": 1})
+	resource, ok := result.Content[0].(*mcp.EmbeddedResource)
+	if !ok {
+		t.Fatalf("content = %T, want *mcp.EmbeddedResource", result.Content[0])
+	}
+	if resource.Resource.MIMEType != jsonMIMEType || resource.Resource.Text != `{"count":1}` {
+		t.Errorf("resource = %+v, want application/json with the JSON text", resource.Resource)
+	}
+
+	// 错误结果始终为纯文本
+	if result, _ := CreateErrorResponse("查询失败: %v", "boom"); result.Content[0].(*mcp.TextContent).Text != "查询失败: boom" {
+		t.Errorf("error content = %+v", result.Content[0])
+	}
+
+	SetResponseContentType("")
+	result, _ = CreateSuccessResponse(map[string]int{"count": 1})
+	if text, ok := result.Content[0].(*mcp.TextContent); !ok || text.Text != `{"count":1}` {
+		t.Errorf("default content = %#v, want text content", result.Content[0])
+	}
+}
@@ -0,0 +1,43 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// UpstreamDeadline 上游调用的实际超时信息
+type UpstreamDeadline struct {
+	ctx           context.Context
+	Configured    time.Duration // 配置的上游超时
+	Effective     time.Duration // 实际生效的超时
+	ClientLimited bool          // 调用方的剩余期限是否短于配置的超时
+}
+
+// WithUpstreamTimeout 派生上游调用的上下文，超时取配置值与调用方剩余期限中的较小者
+func WithUpstreamTimeout(ctx context.Context, configured time.Duration) (context.Context, context.CancelFunc, UpstreamDeadline) {
+	deadline := UpstreamDeadline{Configured: configured, Effective: configured}
+
+	if clientDeadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(clientDeadline); remaining < configured {
+			deadline.Effective = remaining
+			deadline.ClientLimited = true
+			log.Printf("调用方剩余期限 %v 短于上游超时配置 %v，以调用方期限为准", remaining.Round(time.Millisecond), configured)
+		}
+	}
+
+	upstreamCtx, cancel := context.WithTimeout(ctx, deadline.Effective)
+	deadline.ctx = upstreamCtx
+	return upstreamCtx, cancel, deadline
+}
+
+// Explain 当调用方期限导致上游调用超时时，返回说明原因的错误，否则原样返回
+func (d UpstreamDeadline) Explain(err error) error {
+	if err == nil || !d.ClientLimited || d.ctx == nil || !errors.Is(d.ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("调用方设置的截止时间（剩余 %v）短于上游超时配置 %v，请求在完成前被取消，请放宽客户端超时: %w",
+		d.Effective.Round(time.Millisecond), d.Configured, err)
+}
@@ -0,0 +1,65 @@
+package common
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+)
+
+// TLSOptions 上游连接的TLS配置
+type TLSOptions struct {
+	CAFile             string // 自定义CA证书文件路径(PEM)
+	ClientCertFile     string // 双向TLS客户端证书文件路径(PEM)
+	ClientKeyFile      string // 双向TLS客户端私钥文件路径(PEM)
+	InsecureSkipVerify bool   // 跳过服务端证书校验，仅用于测试环境
+}
+
+// IsZero 判断是否未配置任何TLS选项
+func (o TLSOptions) IsZero() bool {
+	return o.CAFile == "" && o.ClientCertFile == "" && o.ClientKeyFile == "" && !o.InsecureSkipVerify
+}
+
+// BuildTLSConfig 根据配置构建tls.Config，未配置任何选项时返回nil以使用系统默认配置
+func BuildTLSConfig(serviceName string, opts TLSOptions) (*tls.Config, error) {
+	if opts.IsZero() {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取CA证书文件失败: %w", err)
+		}
+
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA证书文件 %s 中没有有效的PEM证书", opts.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if opts.ClientCertFile != "" || opts.ClientKeyFile != "" {
+		if opts.ClientCertFile == "" || opts.ClientKeyFile == "" {
+			return nil, fmt.Errorf("客户端证书和私钥需要同时配置")
+		}
+		cert, err := tls.LoadX509KeyPair(opts.ClientCertFile, opts.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载客户端证书失败: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if opts.InsecureSkipVerify {
+		log.Printf("警告: %s 已关闭TLS证书校验(insecure_skip_verify)，连接可能遭受中间人攻击，请勿在生产环境使用", serviceName)
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	return tlsConfig, nil
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"strconv"
 	"strings"
 )
 
@@ -93,3 +94,58 @@ func NewConnectionError(serviceType ServiceType, endpoint string, err error) *Co
 		Err:         err,
 	}
 }
+
+// ResponseTooLargeError 响应超过大小限制错误
+type ResponseTooLargeError struct {
+	Limit   int64
+	message string // 缓存错误信息
+}
+
+func (e *ResponseTooLargeError) Error() string {
+	if e.message == "" {
+		e.message = "响应过大: 超过 " + strconv.FormatInt(e.Limit, 10) + " 字节的上限，请缩小查询范围或减少返回数据量"
+	}
+	return e.message
+}
+
+// NewResponseTooLargeError 创建响应过大错误
+func NewResponseTooLargeError(limit int64) *ResponseTooLargeError {
+	return &ResponseTooLargeError{Limit: limit}
+}
+
+// DuplicateToolError 工具重复注册错误
+type DuplicateToolError struct {
+	ToolName string
+	message  string // 缓存错误信息
+}
+
+func (e *DuplicateToolError) Error() string {
+	if e.message == "" {
+		e.message = "工具名称重复注册: " + e.ToolName + "，请检查配置中的工具前缀或别名设置"
+	}
+	return e.message
+}
+
+// NewDuplicateToolError 创建工具重复注册错误
+func NewDuplicateToolError(toolName string) *DuplicateToolError {
+	return &DuplicateToolError{ToolName: toolName}
+}
+
+// ServiceUnavailableError 服务客户端不可用错误
+// 错误信息在创建时生成，同一错误值可能被多个请求并发读取
+type ServiceUnavailableError struct {
+	ServiceType ServiceType
+	message     string
+}
+
+func (e *ServiceUnavailableError) Error() string {
+	return e.message
+}
+
+// NewServiceUnavailableError 创建服务不可用错误
+func NewServiceUnavailableError(serviceType ServiceType) *ServiceUnavailableError {
+	return &ServiceUnavailableError{
+		ServiceType: serviceType,
+		message:     "服务不可用: " + string(serviceType) + " 客户端未初始化",
+	}
+}
@@ -0,0 +1,86 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// ToolRegistrar 工具注册器
+// 在注册前检测重复的工具名称，并将SDK注册时的panic转换为错误，避免启动崩溃
+type ToolRegistrar struct {
+	server *mcp.Server
+	names  map[string]struct{}
+	order  []string
+	errs   []error
+}
+
+// NewToolRegistrar 创建工具注册器
+func NewToolRegistrar(server *mcp.Server) *ToolRegistrar {
+	return &ToolRegistrar{
+		server: server,
+		names:  make(map[string]struct{}),
+	}
+}
+
+// AddTool 注册工具，重复名称或注册失败时记录错误并跳过该工具
+func AddTool[In, Out any](r *ToolRegistrar, tool *mcp.Tool, handler mcp.ToolHandlerFor[In, Out]) {
+	if _, exists := r.names[tool.Name]; exists {
+		r.errs = append(r.errs, NewDuplicateToolError(tool.Name))
+		return
+	}
+
+	if err := safeAddTool(r.server, tool, cancelledHandler(tool.Name, handler)); err != nil {
+		r.errs = append(r.errs, err)
+		return
+	}
+
+	r.names[tool.Name] = struct{}{}
+	r.order = append(r.order, tool.Name)
+}
+
+// safeAddTool 调用SDK注册工具并捕获panic
+func safeAddTool[In, Out any](server *mcp.Server, tool *mcp.Tool, handler mcp.ToolHandlerFor[In, Out]) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("注册工具 %s 失败: %v", tool.Name, r)
+		}
+	}()
+
+	mcp.AddTool(server, tool, handler)
+	return nil
+}
+
+// cancelledHandler 处理器开始前检查请求上下文，客户端已断开或期限已过时直接返回，不再请求上游
+func cancelledHandler[In, Out any](name string, handler mcp.ToolHandlerFor[In, Out]) mcp.ToolHandlerFor[In, Out] {
+	return func(ctx context.Context, session *mcp.ServerSession, params *mcp.CallToolParamsFor[In]) (*mcp.CallToolResultFor[Out], error) {
+		if err := ctx.Err(); err != nil {
+			log.Printf("工具 %s 的请求在执行前已结束: %v", name, err)
+			message := "请求已取消"
+			if errors.Is(err, context.DeadlineExceeded) {
+				message = "请求已超时"
+			}
+			return &mcp.CallToolResultFor[Out]{
+				IsError: true,
+				Content: []mcp.Content{&mcp.TextContent{Text: message + ": 工具 " + name + " 未执行"}},
+			}, nil
+		}
+
+		return handler(ctx, session, params)
+	}
+}
+
+// Names 获取已注册的工具名称（按注册顺序）
+func (r *ToolRegistrar) Names() []string {
+	result := make([]string, len(r.order))
+	copy(result, r.order)
+	return result
+}
+
+// Err 获取注册过程中的所有错误
+func (r *ToolRegistrar) Err() error {
+	return errors.Join(r.errs...)
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type emptyParams struct{}
+
+// okHandler 始终成功的测试处理器
+func okHandler(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[emptyParams]) (*mcp.CallToolResultFor[any], error) {
+	return &mcp.CallToolResultFor[any]{Content: []mcp.Content{&mcp.TextContent{Text: "ok"}}}, nil
+}
+
+func TestToolRegistrarDuplicateName(t *testing.T) {
+	server := mcp.NewServer(&mcp.Implementation{Name: "test", Version: "test"}, nil)
+	registrar := NewToolRegistrar(server)
+
+	AddTool(registrar, &mcp.Tool{Name: "echo", Description: "first"}, okHandler)
+	AddTool(registrar, &mcp.Tool{Name: "other", Description: "other"}, okHandler)
+	AddTool(registrar, &mcp.Tool{Name: "echo", Description: "second"}, okHandler)
+
+	var duplicate *DuplicateToolError
+	if err := registrar.Err(); !errors.As(err, &duplicate) || duplicate.ToolName != "echo" {
+		t.Fatalf("Err() = %v, want DuplicateToolError for echo", err)
+	}
+	if names := registrar.Names(); !slices.Equal(names, []string{"echo", "other"}) {
+		t.Errorf("Names() = %v, want [echo other]", names)
+	}
+}
+
+func TestCancelledHandlerSkipsUpstream(t *testing.T) {
+	var calls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer upstream.Close()
+
+	// 处理器每次执行都会请求上游
+	handler := cancelledHandler("fetch", func(ctx context.Context, _ *mcp.ServerSession, _ *mcp.CallToolParamsFor[emptyParams]) (*mcp.CallToolResultFor[any], error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstream.URL, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		return &mcp.CallToolResultFor[any]{Content: []mcp.Content{&mcp.TextContent{Text: "ok"}}}, nil
+	})
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	for _, tc := range []struct {
+		name    string
+		ctx     context.Context
+		message string
+	}{
+		{"cancelled", cancelled, "请求已取消"},
+		{"deadline", expired, "请求已超时"},
+	} {
+		result, err := handler(tc.ctx, nil, &mcp.CallToolParamsFor[emptyParams]{})
+		if err != nil {
+			t.Fatalf("%s: handler error: %v", tc.name, err)
+		}
+		text := result.Content[0].(*mcp.TextContent).Text
+		if !result.IsError || !strings.HasPrefix(text, tc.message) {
+			t.Errorf("%s: result = %q (IsError %v), want %q", tc.name, text, result.IsError, tc.message)
+		}
+	}
+	if n := calls.Load(); n != 0 {
+		t.Fatalf("upstream calls with finished context = %d, want 0", n)
+	}
+
+	// 上下文有效时正常执行
+	if result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[emptyParams]{}); err != nil || result.IsError {
+		t.Fatalf("live context: result = %+v, err = %v", result, err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("upstream calls with live context = %d, want 1", n)
+	}
+}
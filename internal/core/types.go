@@ -24,9 +24,54 @@ type ServiceConfig interface {
 	Validate() error
 }
 
+// PortProvider 可选接口：配置或服务需要监听独立端口时实现，返回空字符串表示使用共享端口
+type PortProvider interface {
+	GetPort() string
+}
+
+// ReadinessMode 就绪检查模式
+type ReadinessMode string
+
+const (
+	ReadinessModeConnect ReadinessMode = "connect" // 仅测试连接
+	ReadinessModeFull    ReadinessMode = "full"    // 执行完整的功能验证
+)
+
+// FunctionalChecker 可选接口：服务支持完整功能验证时实现，用于就绪检查
+type FunctionalChecker interface {
+	// ReadinessMode 返回配置的就绪检查模式
+	ReadinessMode() ReadinessMode
+
+	// CheckFunctionality 执行完整的功能验证，例如登录并列出数据库
+	CheckFunctionality(ctx context.Context) error
+}
+
+// ContextCloser 可选接口：服务关闭时需要等待进行中的请求完成时实现，ctx截止后应立即返回
+type ContextCloser interface {
+	CloseContext(ctx context.Context) error
+}
+
 // ServiceFactory 服务工厂函数类型
 type ServiceFactory func(config ServiceConfig, timeout time.Duration) (Service, error)
 
+// ConfigParser 服务配置解析函数，decode将配置文件中该服务的配置段解码到传入的结构体指针
+type ConfigParser func(decode func(out any) error) (ServiceConfig, error)
+
+// ServiceDescriber 可选接口：服务需要在信息页面展示自定义描述和工具列表时实现
+type ServiceDescriber interface {
+	// Description 服务描述
+	Description() string
+
+	// ToolSummaries 工具列表，每项格式为 "工具名 - 说明"
+	ToolSummaries() []string
+}
+
+// ToolLister 可选接口：服务提供实际注册的工具名称时实现，信息页面据此隐藏未启用的工具
+type ToolLister interface {
+	// ToolNames 已注册的工具名称（按注册顺序）
+	ToolNames() []string
+}
+
 // Service MCP服务接口
 type Service interface {
 	// GetServer 获取MCP服务器实例
@@ -47,6 +92,7 @@ type Service interface {
 
 // 函数式Registry设计 - 使用全局不可变映射
 var serviceFactories = make(map[ServiceType]ServiceFactory)
+var configParsers = make(map[ServiceType]ConfigParser)
 var factoriesMutex sync.RWMutex
 var supportedTypesCache []ServiceType
 var cacheValid bool
@@ -60,6 +106,21 @@ func RegisterServiceFactory(serviceType ServiceType, factory ServiceFactory) {
 	cacheValid = false
 }
 
+// RegisterConfigParser 注册服务配置解析函数，用于解析配置文件plugins段中的外部服务配置
+func RegisterConfigParser(serviceType ServiceType, parser ConfigParser) {
+	factoriesMutex.Lock()
+	defer factoriesMutex.Unlock()
+	configParsers[serviceType] = parser
+}
+
+// GetConfigParser 获取服务配置解析函数
+func GetConfigParser(serviceType ServiceType) (ConfigParser, bool) {
+	factoriesMutex.RLock()
+	defer factoriesMutex.RUnlock()
+	parser, exists := configParsers[serviceType]
+	return parser, exists
+}
+
 // CreateService 创建服务实例
 func CreateService(config ServiceConfig, timeout time.Duration) (Service, error) {
 	factoriesMutex.RLock()
@@ -0,0 +1,74 @@
+package multiplexer
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// concurrencyCounter 单个端点的并发请求计数
+type concurrencyCounter struct {
+	current atomic.Int64
+	peak    atomic.Int64
+}
+
+// enter 请求开始，必要时更新峰值
+func (c *concurrencyCounter) enter() {
+	n := c.current.Add(1)
+	for {
+		peak := c.peak.Load()
+		if n <= peak || c.peak.CompareAndSwap(peak, n) {
+			return
+		}
+	}
+}
+
+// leave 请求结束
+func (c *concurrencyCounter) leave() {
+	c.current.Add(-1)
+}
+
+// concurrencyStats 并发请求统计快照
+type concurrencyStats struct {
+	current int64
+	peak    int64
+}
+
+// snapshot 获取当前并发数和峰值
+func (c *concurrencyCounter) snapshot() concurrencyStats {
+	return concurrencyStats{current: c.current.Load(), peak: c.peak.Load()}
+}
+
+// withConcurrency 统计端点的并发请求数
+// 仅统计POST请求（MCP消息），GET建立的SSE长连接不计入，避免空闲会话抬高并发数
+func withConcurrency(counter *concurrencyCounter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+		counter.enter()
+		defer counter.leave()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// endpointCounter 获取端点的并发计数，不存在时创建；替换服务时沿用原计数
+func (s *Server) endpointCounter(endpoint string) *concurrencyCounter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counter, ok := s.concurrency[endpoint]
+	if !ok {
+		counter = &concurrencyCounter{}
+		s.concurrency[endpoint] = counter
+	}
+	return counter
+}
+
+// concurrencySnapshot 获取所有端点的并发统计，调用方需持有读锁
+func (s *Server) concurrencySnapshot() map[string]concurrencyStats {
+	stats := make(map[string]concurrencyStats, len(s.concurrency))
+	for endpoint, counter := range s.concurrency {
+		stats[endpoint] = counter.snapshot()
+	}
+	return stats
+}
@@ -0,0 +1,70 @@
+package multiplexer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"mcp-server/internal/core"
+)
+
+func TestPeakInFlightTracksConcurrentRequests(t *testing.T) {
+	const endpoint = "/prometheus/mcp"
+	server := NewServer("0")
+	server.AddService(newFakeService(core.ServiceTypePrometheus, endpoint))
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := withConcurrency(server.endpointCounter(endpoint), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			entered <- struct{}{}
+			<-release
+		}
+	}))
+
+	const concurrent = 3
+	var wg sync.WaitGroup
+	for range concurrent {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, endpoint, nil))
+		}()
+	}
+	for range concurrent {
+		select {
+		case <-entered:
+		case <-time.After(5 * time.Second):
+			t.Fatal("requests did not reach the handler")
+		}
+	}
+	// GET建立的SSE连接不计入并发数
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, endpoint, nil))
+
+	info := serviceInfo(t, server, endpoint)
+	if info.InFlight != concurrent || info.PeakInFlight != concurrent {
+		t.Errorf("during requests: in_flight = %d, peak = %d, want %d and %d", info.InFlight, info.PeakInFlight, concurrent, concurrent)
+	}
+
+	close(release)
+	wg.Wait()
+	info = serviceInfo(t, server, endpoint)
+	if info.InFlight != 0 || info.PeakInFlight != concurrent {
+		t.Errorf("after requests: in_flight = %d, peak = %d, want 0 and %d", info.InFlight, info.PeakInFlight, concurrent)
+	}
+}
+
+// serviceInfo 获取指定端点的服务信息
+func serviceInfo(t *testing.T, server *Server, endpoint string) ServiceInfo {
+	t.Helper()
+	for _, info := range server.GetServiceInfo(context.Background()) {
+		if info.Endpoint == endpoint {
+			return info
+		}
+	}
+	t.Fatalf("no service info for %s", endpoint)
+	return ServiceInfo{}
+}
@@ -0,0 +1,50 @@
+package multiplexer
+
+import (
+	"log"
+	"net/http"
+)
+
+// 生效配置导出相关常量
+const (
+	configDumpPath = "/admin/config"
+
+	contentTypeYAML = "application/yaml; charset=utf-8"
+
+	httpErrorConfigDumpDisabled = "未配置http_auth_token，不提供配置导出"
+)
+
+// ConfigDumper 导出当前生效的配置（敏感字段已脱敏），配置重新加载后返回新的配置
+type ConfigDumper func() ([]byte, error)
+
+// WithConfigDumper 设置配置导出函数，同时配置了http_auth_token时通过/admin/config提供
+func WithConfigDumper(dumper ConfigDumper) ServerOption {
+	return func(s *Server) {
+		s.configDumper = dumper
+	}
+}
+
+// handleConfigDump 返回运行中服务器的生效配置，用于复现问题
+// 脱敏后的配置仍包含内部地址等信息，未配置令牌时不提供
+func (s *Server) handleConfigDump(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	if s.configDumper == nil || s.authToken == "" {
+		http.Error(w, httpErrorConfigDumpDisabled, http.StatusForbidden)
+		return
+	}
+
+	requireBearerToken(s.authToken, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, err := s.configDumper()
+		if err != nil {
+			log.Printf("导出配置失败: %v", err)
+			http.Error(w, httpErrorInternal, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", contentTypeYAML)
+		_, _ = w.Write(data)
+	})).ServeHTTP(w, r)
+}
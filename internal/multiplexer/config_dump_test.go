@@ -0,0 +1,38 @@
+package multiplexer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestConfigDumpRequiresToken(t *testing.T) {
+	dumper := func() ([]byte, error) { return []byte("http_auth_token: '***'\n"), nil }
+
+	get := func(server *Server, header string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, configDumpPath, nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		server.handleConfigDump(rec, req)
+		return rec
+	}
+
+	// 未配置令牌时不提供配置导出
+	if rec := get(NewServer("0", WithConfigDumper(dumper)), ""); rec.Code != http.StatusForbidden {
+		t.Errorf("without auth token: status = %d, want 403", rec.Code)
+	}
+
+	server := NewServer("0", WithAuthToken("secret"), WithConfigDumper(dumper))
+	if rec := get(server, ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("without bearer: status = %d, want 401", rec.Code)
+	}
+	rec := get(server, "Bearer secret")
+	if rec.Code != http.StatusOK || rec.Body.String() != "http_auth_token: '***'\n" {
+		t.Errorf("with bearer: status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != contentTypeYAML {
+		t.Errorf("Content-Type = %q, want %q", got, contentTypeYAML)
+	}
+}
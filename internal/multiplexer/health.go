@@ -0,0 +1,351 @@
+package multiplexer
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"mcp-server/internal/core"
+)
+
+// 健康检查相关常量
+const (
+	healthzPath = "/healthz"
+	readyzPath  = "/readyz"
+
+	contentTypeJSON = "application/json; charset=utf-8"
+
+	// readinessProbeTimeout 就绪检查时单个服务的连接测试超时
+	readinessProbeTimeout = 3 * time.Second
+	// readinessSuccessTTL 连接测试成功记录的有效期，过期后就绪检查重新探测上游
+	readinessSuccessTTL = 30 * time.Second
+	// functionalCheckTimeout 就绪检查时单个服务完整功能验证的超时
+	functionalCheckTimeout = 10 * time.Second
+
+	// livenessCheckTimeout 信息页面存活检查的连接测试超时
+	livenessCheckTimeout = 3 * time.Second
+	// livenessCacheTTL 存活检查结果的缓存时间，避免频繁请求上游
+	livenessCacheTTL = 30 * time.Second
+)
+
+// livenessResult 缓存的存活检查结果
+type livenessResult struct {
+	checkedAt time.Time
+	err       error
+}
+
+// healthTracker 记录每个服务最近一次连接测试成功的时间及存活检查缓存
+type healthTracker struct {
+	mu          sync.RWMutex
+	lastSuccess map[string]time.Time      // endpoint -> 最近成功时间
+	liveness    map[string]livenessResult // endpoint -> 最近一次存活检查结果
+	functional  map[string]livenessResult // endpoint -> 最近一次完整功能验证结果
+}
+
+// newHealthTracker 创建健康状态记录器
+func newHealthTracker() *healthTracker {
+	return &healthTracker{
+		lastSuccess: make(map[string]time.Time),
+		liveness:    make(map[string]livenessResult),
+		functional:  make(map[string]livenessResult),
+	}
+}
+
+// cachedLiveness 获取未过期的存活检查结果
+func (h *healthTracker) cachedLiveness(endpoint string, now time.Time) (livenessResult, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	result, ok := h.liveness[endpoint]
+	if !ok || now.Sub(result.checkedAt) >= livenessCacheTTL {
+		return livenessResult{}, false
+	}
+	return result, true
+}
+
+// recordLiveness 记录存活检查结果，成功时同时更新最近成功时间
+func (h *healthTracker) recordLiveness(endpoint string, result livenessResult) {
+	h.mu.Lock()
+	h.liveness[endpoint] = result
+	if result.err == nil {
+		h.lastSuccess[endpoint] = result.checkedAt
+	}
+	h.mu.Unlock()
+}
+
+// cachedFunctional 获取未过期的完整功能验证结果
+func (h *healthTracker) cachedFunctional(endpoint string, now time.Time) (livenessResult, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	result, ok := h.functional[endpoint]
+	if !ok || now.Sub(result.checkedAt) >= livenessCacheTTL {
+		return livenessResult{}, false
+	}
+	return result, true
+}
+
+// recordFunctional 记录完整功能验证结果
+func (h *healthTracker) recordFunctional(endpoint string, result livenessResult) {
+	h.mu.Lock()
+	h.functional[endpoint] = result
+	h.mu.Unlock()
+}
+
+// recordSuccess 记录连接测试成功
+func (h *healthTracker) recordSuccess(endpoint string, at time.Time) {
+	h.mu.Lock()
+	h.lastSuccess[endpoint] = at
+	h.mu.Unlock()
+}
+
+// fresh 判断服务最近一次成功是否仍在有效期内
+func (h *healthTracker) fresh(endpoint string, now time.Time) bool {
+	at, ok := h.get(endpoint)
+	return ok && now.Sub(at) < readinessSuccessTTL
+}
+
+// forget 清除服务的健康记录，服务移除或替换时调用
+func (h *healthTracker) forget(endpoint string) {
+	h.mu.Lock()
+	delete(h.lastSuccess, endpoint)
+	delete(h.liveness, endpoint)
+	delete(h.functional, endpoint)
+	h.mu.Unlock()
+}
+
+// get 获取服务最近一次成功时间
+func (h *healthTracker) get(endpoint string) (time.Time, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	at, ok := h.lastSuccess[endpoint]
+	return at, ok
+}
+
+// RecordConnectionSuccess 记录服务连接测试成功，用于就绪检查
+func (s *Server) RecordConnectionSuccess(endpoint string) {
+	s.health.recordSuccess(endpoint, time.Now())
+}
+
+// readinessInfo 单个服务的就绪信息
+type readinessInfo struct {
+	Type        core.ServiceType   `json:"type"`
+	Mode        core.ReadinessMode `json:"mode"`
+	LastSuccess string             `json:"last_success,omitempty"`
+	Error       string             `json:"error,omitempty"`
+
+	// 并发请求统计，仅统计MCP消息(POST)
+	InFlight     int64 `json:"in_flight"`
+	PeakInFlight int64 `json:"peak_in_flight"`
+}
+
+// handleHealthz 存活检查，进程能响应即返回200
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	count := len(s.services)
+	s.mu.RUnlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":   "ok",
+		"services": count,
+	})
+}
+
+// handleReadyz 就绪检查，至少一个服务就绪后返回200，否则返回503
+// connect模式的服务以有效期内的连接测试成功为准，full模式的服务每次（缓存过期后）执行完整功能验证
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if s.isWarmingUp() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{
+			"status": "warming_up",
+		})
+		return
+	}
+
+	s.mu.RLock()
+	connectServices := make(map[string]core.Service, len(s.services))
+	fullServices := make(map[string]core.Service)
+	for endpoint, service := range s.services {
+		if checker, ok := service.(core.FunctionalChecker); ok && checker.ReadinessMode() == core.ReadinessModeFull {
+			fullServices[endpoint] = service
+			continue
+		}
+		connectServices[endpoint] = service
+	}
+	concurrency := s.concurrencySnapshot()
+	s.mu.RUnlock()
+
+	// 没有有效期内的成功记录时主动探测一次，使上游故障能反映到就绪状态，恢复后也能重新就绪
+	ready := false
+	now := time.Now()
+	for endpoint := range connectServices {
+		if s.health.fresh(endpoint, now) {
+			ready = true
+			break
+		}
+	}
+	if !ready && len(connectServices) > 0 {
+		ready = s.probeServices(r.Context(), connectServices)
+	}
+
+	functional := s.checkFunctionality(r.Context(), fullServices)
+	for _, err := range functional {
+		if err == nil {
+			ready = true
+		}
+	}
+
+	details := make(map[string]readinessInfo, len(connectServices)+len(fullServices))
+	for endpoint, service := range connectServices {
+		info := readinessInfo{
+			Type:         service.GetType(),
+			Mode:         core.ReadinessModeConnect,
+			InFlight:     concurrency[endpoint].current,
+			PeakInFlight: concurrency[endpoint].peak,
+		}
+		if at, ok := s.health.get(endpoint); ok {
+			info.LastSuccess = at.Format(time.RFC3339)
+		}
+		details[endpoint] = info
+	}
+	for endpoint, err := range functional {
+		info := readinessInfo{
+			Type:         fullServices[endpoint].GetType(),
+			Mode:         core.ReadinessModeFull,
+			InFlight:     concurrency[endpoint].current,
+			PeakInFlight: concurrency[endpoint].peak,
+		}
+		if at, ok := s.health.get(endpoint); ok {
+			info.LastSuccess = at.Format(time.RFC3339)
+		}
+		if err != nil {
+			info.Error = err.Error()
+		}
+		details[endpoint] = info
+	}
+
+	status, code := "ready", http.StatusOK
+	if !ready {
+		status, code = "not_ready", http.StatusServiceUnavailable
+	}
+	writeJSON(w, code, map[string]any{
+		"status":   status,
+		"services": details,
+	})
+}
+
+// probeServices 并发测试所有服务连接，返回是否至少有一个成功
+func (s *Server) probeServices(ctx context.Context, services map[string]core.Service) bool {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	ready := false
+
+	for endpoint, service := range services {
+		wg.Add(1)
+		go func(endpoint string, service core.Service) {
+			defer wg.Done()
+
+			probeCtx, cancel := context.WithTimeout(ctx, readinessProbeTimeout)
+			defer cancel()
+
+			err := service.TestConnection(probeCtx)
+			s.health.recordLiveness(endpoint, livenessResult{checkedAt: time.Now(), err: err})
+			if err != nil {
+				return
+			}
+
+			mu.Lock()
+			ready = true
+			mu.Unlock()
+		}(endpoint, service)
+	}
+
+	wg.Wait()
+	return ready
+}
+
+// checkFunctionality 并发执行完整功能验证，缓存有效期内直接返回缓存结果
+// services中的服务均需实现core.FunctionalChecker
+func (s *Server) checkFunctionality(ctx context.Context, services map[string]core.Service) map[string]error {
+	results := make(map[string]error, len(services))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for endpoint, service := range services {
+		checker := service.(core.FunctionalChecker)
+		if cached, ok := s.health.cachedFunctional(endpoint, time.Now()); ok {
+			results[endpoint] = cached.err
+			continue
+		}
+
+		wg.Add(1)
+		go func(endpoint string, checker core.FunctionalChecker) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, functionalCheckTimeout)
+			defer cancel()
+
+			err := checker.CheckFunctionality(checkCtx)
+			now := time.Now()
+			s.health.recordFunctional(endpoint, livenessResult{checkedAt: now, err: err})
+			if err == nil {
+				s.health.recordSuccess(endpoint, now)
+			}
+
+			mu.Lock()
+			results[endpoint] = err
+			mu.Unlock()
+		}(endpoint, checker)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// checkLiveness 并发检查服务存活状态，缓存有效期内直接返回缓存结果
+func (s *Server) checkLiveness(ctx context.Context, services map[string]core.Service) map[string]error {
+	results := make(map[string]error, len(services))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for endpoint, service := range services {
+		if cached, ok := s.health.cachedLiveness(endpoint, time.Now()); ok {
+			results[endpoint] = cached.err
+			continue
+		}
+
+		wg.Add(1)
+		go func(endpoint string, service core.Service) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, livenessCheckTimeout)
+			defer cancel()
+
+			err := service.TestConnection(checkCtx)
+			s.health.recordLiveness(endpoint, livenessResult{checkedAt: time.Now(), err: err})
+
+			mu.Lock()
+			results[endpoint] = err
+			mu.Unlock()
+		}(endpoint, service)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// writeJSON 写入JSON响应
+func writeJSON(w http.ResponseWriter, code int, body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("JSON序列化错误: %v", err)
+		http.Error(w, httpErrorInternal, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentTypeJSON)
+	w.WriteHeader(code)
+	if _, err := w.Write(data); err != nil {
+		log.Printf("写入响应错误: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package multiplexer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"mcp-server/internal/core"
+)
+
+func TestReadyzReprobesStaleSuccess(t *testing.T) {
+	server := NewServer("0")
+	service := newFakeService("fake", "/fake/mcp")
+	server.AddService(service)
+
+	readyz := func() int {
+		rec := httptest.NewRecorder()
+		server.handleReadyz(rec, httptest.NewRequest(http.MethodGet, readyzPath, nil))
+		return rec.Code
+	}
+
+	// 有效期内的成功记录不会触发探测
+	server.health.recordSuccess(service.endpoint, time.Now())
+	service.setTestErr(errors.New("connection refused"))
+	if code := readyz(); code != http.StatusOK {
+		t.Fatalf("fresh success: status = %d, want %d", code, http.StatusOK)
+	}
+	if calls := service.testCalls.Load(); calls != 0 {
+		t.Fatalf("fresh success: TestConnection calls = %d, want 0", calls)
+	}
+
+	// 成功记录过期后重新探测，上游故障时返回503
+	server.health.recordSuccess(service.endpoint, time.Now().Add(-2*readinessSuccessTTL))
+	if code := readyz(); code != http.StatusServiceUnavailable {
+		t.Fatalf("stale success with failing upstream: status = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+	if calls := service.testCalls.Load(); calls != 1 {
+		t.Fatalf("stale success: TestConnection calls = %d, want 1", calls)
+	}
+
+	// 上游恢复后重新就绪
+	service.setTestErr(nil)
+	if code := readyz(); code != http.StatusOK {
+		t.Fatalf("recovered upstream: status = %d, want %d", code, http.StatusOK)
+	}
+}
+
+// checkerService 支持完整功能验证的测试服务
+type checkerService struct {
+	*fakeService
+	mode          core.ReadinessMode
+	functionalErr error
+	functional    atomic.Int64
+}
+
+func (s *checkerService) ReadinessMode() core.ReadinessMode { return s.mode }
+
+func (s *checkerService) CheckFunctionality(context.Context) error {
+	s.functional.Add(1)
+	return s.functionalErr
+}
+
+func TestReadyzReadinessModes(t *testing.T) {
+	for _, tc := range []struct {
+		name           string
+		mode           core.ReadinessMode
+		connectErr     error
+		functionalErr  error
+		wantCode       int
+		wantConnects   int64
+		wantFunctional int64
+	}{
+		// connect模式只测试连接，功能验证失败不影响就绪
+		{name: "connect ignores functional failure", mode: core.ReadinessModeConnect, functionalErr: errors.New("list databases timed out"), wantCode: http.StatusOK, wantConnects: 1},
+		{name: "connect failure", mode: core.ReadinessModeConnect, connectErr: errors.New("connection refused"), wantCode: http.StatusServiceUnavailable, wantConnects: 1},
+		// full模式执行功能验证，不单独测试连接
+		{name: "full success", mode: core.ReadinessModeFull, wantCode: http.StatusOK, wantFunctional: 1},
+		{name: "full failure", mode: core.ReadinessModeFull, functionalErr: errors.New("list databases failed"), wantCode: http.StatusServiceUnavailable, wantFunctional: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := NewServer("0")
+			service := &checkerService{
+				fakeService:   newFakeService("fake", "/fake/mcp"),
+				mode:          tc.mode,
+				functionalErr: tc.functionalErr,
+			}
+			service.setTestErr(tc.connectErr)
+			server.AddService(service)
+
+			rec := httptest.NewRecorder()
+			server.handleReadyz(rec, httptest.NewRequest(http.MethodGet, readyzPath, nil))
+			if rec.Code != tc.wantCode {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tc.wantCode, rec.Body.String())
+			}
+			if got := service.testCalls.Load(); got != tc.wantConnects {
+				t.Errorf("TestConnection calls = %d, want %d", got, tc.wantConnects)
+			}
+			if got := service.functional.Load(); got != tc.wantFunctional {
+				t.Errorf("CheckFunctionality calls = %d, want %d", got, tc.wantFunctional)
+			}
+
+			var body struct {
+				Services map[string]readinessInfo `json:"services"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if got := body.Services["/fake/mcp"].Mode; got != tc.mode {
+				t.Errorf("reported mode = %q, want %q", got, tc.mode)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package multiplexer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"mcp-server/internal/common"
+	"mcp-server/internal/core"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsPath MCP服务器自身指标的暴露路径
+const metricsPath = "/metrics"
+
+// 工具调用结果状态
+const (
+	toolStatusSuccess = "success"
+	toolStatusError   = "error"
+	toolStatusFailure = "failure"
+)
+
+// WithMetrics 启用/metrics端点和工具调用指标
+func WithMetrics(enabled bool) ServerOption {
+	return func(s *Server) {
+		s.metricsEnabled = enabled
+	}
+}
+
+// metricsHandler 暴露MCP服务器自身指标的HTTP处理器
+func metricsHandler() http.Handler {
+	return promhttp.HandlerFor(common.MetricsRegistry, promhttp.HandlerOpts{})
+}
+
+// instrumentTools 为服务的MCP服务器添加请求ID、工具调用指标和工具开关中间件，同一服务器只添加一次
+// 工具开关位于最内层，被禁用的调用同样记录请求ID日志并计入指标
+func (s *Server) instrumentTools(service core.Service) {
+	server := service.GetServer()
+	if server == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if _, done := s.instrumented[server]; done {
+		s.mu.Unlock()
+		return
+	}
+	s.instrumented[server] = struct{}{}
+	s.mu.Unlock()
+
+	serviceType, endpoint := string(service.GetType()), service.GetEndpoint()
+	middleware := []mcp.Middleware[*mcp.ServerSession]{requestIDMiddleware(s.requestIDs, serviceType, endpoint)}
+	if s.metricsEnabled {
+		middleware = append(middleware, toolMetricsMiddleware(serviceType, endpoint))
+	}
+	middleware = append(middleware, toolToggleMiddleware(s.toolToggles, endpoint))
+	server.AddReceivingMiddleware(middleware...)
+}
+
+// toolMetricsMiddleware 统计tools/call请求的次数、结果状态和耗时
+func toolMetricsMiddleware(serviceType, endpoint string) mcp.Middleware[*mcp.ServerSession] {
+	return func(next mcp.MethodHandler[*mcp.ServerSession]) mcp.MethodHandler[*mcp.ServerSession] {
+		return func(ctx context.Context, session *mcp.ServerSession, method string, params mcp.Params) (mcp.Result, error) {
+			callParams, ok := params.(*mcp.CallToolParamsFor[json.RawMessage])
+			if !ok {
+				return next(ctx, session, method, params)
+			}
+
+			start := time.Now()
+			result, err := next(ctx, session, method, params)
+
+			status := toolStatusSuccess
+			switch {
+			case err != nil:
+				status = toolStatusFailure
+			case isErrorResult(result):
+				status = toolStatusError
+			}
+			common.ObserveToolCall(serviceType, endpoint, callParams.Name, status, time.Since(start))
+
+			return result, err
+		}
+	}
+}
+
+// isErrorResult 判断工具调用结果是否为错误结果
+func isErrorResult(result mcp.Result) bool {
+	toolResult, ok := result.(*mcp.CallToolResult)
+	return ok && toolResult != nil && toolResult.IsError
+}
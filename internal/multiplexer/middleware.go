@@ -0,0 +1,145 @@
+package multiplexer
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"mcp-server/internal/core"
+)
+
+// 认证相关常量
+const (
+	headerAuthorization = "Authorization"
+	bearerPrefix        = "Bearer "
+	httpErrorAuth       = "未授权：缺少或无效的Bearer令牌"
+
+	// CORS相关常量
+	corsWildcard     = "*"
+	corsAllowMethods = "GET, POST, DELETE, OPTIONS"
+	corsAllowHeaders = "Authorization, Content-Type, Accept, Mcp-Session-Id, Mcp-Protocol-Version, Last-Event-ID, X-Request-ID"
+	corsExposeHeader = "Mcp-Session-Id"
+	corsMaxAge       = "600"
+)
+
+// requireBearerToken 校验Authorization请求头中的Bearer令牌，未配置令牌时直接放行
+func requireBearerToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+
+	expected := []byte(token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get(headerAuthorization)
+		provided, ok := strings.CutPrefix(header, bearerPrefix)
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(provided)), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="mcp-server"`)
+			http.Error(w, httpErrorAuth, http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withCORS 为允许的来源设置CORS响应头并处理OPTIONS预检请求，未配置来源时直接放行
+func withCORS(allowedOrigins []string, next http.Handler) http.Handler {
+	if len(allowedOrigins) == 0 {
+		return next
+	}
+
+	allowAll := false
+	origins := make(map[string]bool, len(allowedOrigins))
+	for _, origin := range allowedOrigins {
+		origin = strings.TrimRight(strings.TrimSpace(origin), "/")
+		if origin == corsWildcard {
+			allowAll = true
+		}
+		origins[origin] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || (!allowAll && !origins[origin]) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		if allowAll {
+			header.Set("Access-Control-Allow-Origin", corsWildcard)
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		header.Set("Access-Control-Expose-Headers", corsExposeHeader)
+
+		// 预检请求直接返回，不经过认证和MCP处理器
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			header.Set("Access-Control-Allow-Methods", corsAllowMethods)
+			header.Set("Access-Control-Allow-Headers", corsAllowHeaders)
+			header.Set("Access-Control-Max-Age", corsMaxAge)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// statusRecorder 记录响应状态码，同时保留Flush能力以支持SSE流式响应
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader 记录状态码
+func (r *statusRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Write 未显式设置状态码时按200记录
+func (r *statusRecorder) Write(data []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.ResponseWriter.Write(data)
+}
+
+// Flush 实现http.Flusher接口
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap 供http.ResponseController访问底层ResponseWriter
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// withAccessLog 记录MCP端点的访问日志：方法、路径、状态码、耗时和远端地址，客户端携带X-Request-ID时一并记录
+func withAccessLog(serviceType core.ServiceType, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w}
+
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		line := fmt.Sprintf("access service=%s method=%s path=%s status=%d duration_ms=%d remote=%s",
+			serviceType, r.Method, r.URL.Path, status, time.Since(start).Milliseconds(), r.RemoteAddr)
+		if requestID := clientRequestID(r); requestID != "" {
+			line += " request_id=" + requestID
+		}
+		log.Print(line)
+	})
+}
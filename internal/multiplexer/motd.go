@@ -0,0 +1,121 @@
+package multiplexer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// 运维公告（MOTD）相关常量
+const (
+	motdPath        = "/admin/motd"
+	motdResourceURI = "mcp-server://motd"
+	motdMIMEType    = "text/plain"
+
+	// maxMOTDBytes 管理端点接受的公告请求体上限
+	maxMOTDBytes = 64 << 10
+
+	httpErrorMOTDReadOnly = "未配置http_auth_token，公告只读"
+	httpErrorMOTDBody     = "请求体应为JSON: {\"motd\": \"...\"}"
+)
+
+// motdPayload 管理端点的请求和响应格式
+type motdPayload struct {
+	MOTD string `json:"motd"`
+}
+
+// WithMOTD 设置启动时的运维公告
+func WithMOTD(motd string) ServerOption {
+	return func(s *Server) {
+		s.motd = motd
+	}
+}
+
+// MOTD 获取当前的运维公告
+func (s *Server) MOTD() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.motd
+}
+
+// SetMOTD 更新运维公告，已注册服务的公告资源重新注册以通知已连接的客户端
+func (s *Server) SetMOTD(motd string) {
+	s.mu.Lock()
+	changed := s.motd != motd
+	s.motd = motd
+	services := make([]*mcp.Server, 0, len(s.services))
+	for _, service := range s.services {
+		services = append(services, service.GetServer())
+	}
+	s.mu.Unlock()
+
+	if !changed {
+		return
+	}
+	for _, server := range services {
+		s.registerMOTDResource(server)
+	}
+	log.Printf("运维公告已更新: %q", motd)
+}
+
+// registerMOTDResource 在服务的MCP服务器上注册公告资源，读取时返回当前公告
+func (s *Server) registerMOTDResource(server *mcp.Server) {
+	if server == nil {
+		return
+	}
+	server.AddResource(&mcp.Resource{
+		URI:         motdResourceURI,
+		Name:        "motd",
+		Description: "服务器运维公告，例如维护窗口通知，为空表示当前没有公告",
+		MIMEType:    motdMIMEType,
+	}, func(_ context.Context, _ *mcp.ServerSession, params *mcp.ReadResourceParams) (*mcp.ReadResourceResult, error) {
+		return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{{
+			URI:      params.URI,
+			MIMEType: motdMIMEType,
+			Text:     s.MOTD(),
+		}}}, nil
+	})
+}
+
+// handleMOTD 查询或更新运维公告
+// GET无需认证；PUT/POST需要Bearer令牌，未配置http_auth_token时拒绝修改
+func (s *Server) handleMOTD(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeMOTD(w, s.MOTD())
+	case http.MethodPut, http.MethodPost:
+		if s.authToken == "" {
+			http.Error(w, httpErrorMOTDReadOnly, http.StatusForbidden)
+			return
+		}
+		requireBearerToken(s.authToken, http.HandlerFunc(s.updateMOTD)).ServeHTTP(w, r)
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// updateMOTD 解析请求体并更新公告
+func (s *Server) updateMOTD(w http.ResponseWriter, r *http.Request) {
+	var payload motdPayload
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMOTDBytes+1))
+	if err != nil || len(body) > maxMOTDBytes || json.Unmarshal(body, &payload) != nil {
+		http.Error(w, httpErrorMOTDBody, http.StatusBadRequest)
+		return
+	}
+
+	s.SetMOTD(payload.MOTD)
+	writeMOTD(w, payload.MOTD)
+}
+
+// writeMOTD 以JSON格式返回公告
+func writeMOTD(w http.ResponseWriter, motd string) {
+	w.Header().Set("Content-Type", contentTypeJSON)
+	if err := json.NewEncoder(w).Encode(motdPayload{MOTD: motd}); err != nil {
+		log.Printf("写入公告响应失败: %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package multiplexer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mcp-server/internal/core"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// readMOTD 通过内存传输连接MCP服务器并读取公告资源
+func readMOTD(t *testing.T, server *mcp.Server) string {
+	t.Helper()
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport)
+	if err != nil {
+		t.Fatalf("connect server: %v", err)
+	}
+	defer serverSession.Close()
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "test"}, nil)
+	session, err := client.Connect(ctx, clientTransport)
+	if err != nil {
+		t.Fatalf("connect client: %v", err)
+	}
+	defer session.Close()
+
+	result, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: motdResourceURI})
+	if err != nil {
+		t.Fatalf("ReadResource: %v", err)
+	}
+	if len(result.Contents) != 1 {
+		t.Fatalf("contents = %+v, want one entry", result.Contents)
+	}
+	return result.Contents[0].Text
+}
+
+func TestMOTDResourceAndUpdate(t *testing.T) {
+	service := newFakeService(core.ServiceTypePrometheus, "/prometheus/mcp")
+	server := NewServer("0", WithAuthToken("secret"), WithMOTD("维护窗口 02:00"))
+	server.AddService(service)
+
+	if got := readMOTD(t, service.server); got != "维护窗口 02:00" {
+		t.Errorf("initial motd = %q, want the configured one", got)
+	}
+
+	put := func(header, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, motdPath, strings.NewReader(body))
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		rec := httptest.NewRecorder()
+		server.handleMOTD(rec, req)
+		return rec
+	}
+
+	// 修改公告需要令牌
+	if rec := put("", `{"motd":"ignored"}`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("without bearer: status = %d, want 401", rec.Code)
+	}
+	if rec := put("Bearer secret", `not json`); rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid body: status = %d, want 400", rec.Code)
+	}
+	if rec := put("Bearer secret", `{"motd":"升级完成"}`); rec.Code != http.StatusOK {
+		t.Fatalf("update: status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+
+	if got := readMOTD(t, service.server); got != "升级完成" {
+		t.Errorf("updated motd = %q, want 升级完成", got)
+	}
+	rec := httptest.NewRecorder()
+	server.handleMOTD(rec, httptest.NewRequest(http.MethodGet, motdPath, nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "升级完成") {
+		t.Errorf("GET: status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+
+	// 未配置令牌时公告只读
+	readOnly := NewServer("0")
+	rec = httptest.NewRecorder()
+	readOnly.handleMOTD(rec, httptest.NewRequest(http.MethodPut, motdPath, strings.NewReader(`{"motd":"x"}`)))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("read-only PUT: status = %d, want 403", rec.Code)
+	}
+}
@@ -0,0 +1,238 @@
+package multiplexer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"mcp-server/internal/common"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// 请求ID相关常量
+const (
+	headerRequestID = "X-Request-ID"
+	headerSessionID = "Mcp-Session-Id"
+
+	methodCallTool = "tools/call"
+
+	// pendingRequestIDTTL 客户端请求ID等待对应工具调用的最长时间，超时未匹配的记录被清除
+	pendingRequestIDTTL = time.Minute
+	// maxRequestIDLength 客户端请求ID的最大长度，超出时忽略并生成新的ID
+	maxRequestIDLength = 128
+	// maxMessageBodyBytes 携带X-Request-ID时读取的MCP消息体上限，超出时返回413
+	maxMessageBodyBytes = 8 << 20
+)
+
+// pendingRequestID 等待匹配工具调用的客户端请求ID
+type pendingRequestID struct {
+	requestID string
+	createdAt time.Time
+}
+
+// requestIDRegistry 关联HTTP请求头中的X-Request-ID与SDK分发的工具调用
+// SDK不向工具处理器传递HTTP请求上下文，因此按会话ID、工具名称和原始参数匹配同一次调用
+type requestIDRegistry struct {
+	mu      sync.Mutex
+	pending map[string][]pendingRequestID
+}
+
+// newRequestIDRegistry 创建请求ID登记表
+func newRequestIDRegistry() *requestIDRegistry {
+	return &requestIDRegistry{pending: make(map[string][]pendingRequestID)}
+}
+
+// requestIDKey 生成工具调用的匹配键
+func requestIDKey(sessionID, tool string, arguments json.RawMessage) string {
+	return sessionID + "\x00" + tool + "\x00" + string(arguments)
+}
+
+// put 登记客户端请求ID
+func (r *requestIDRegistry) put(key, requestID string, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.evictLocked(now)
+	r.pending[key] = append(r.pending[key], pendingRequestID{requestID: requestID, createdAt: now})
+}
+
+// take 取出并移除最早登记的客户端请求ID
+func (r *requestIDRegistry) take(key string, now time.Time) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.evictLocked(now)
+	queue := r.pending[key]
+	if len(queue) == 0 {
+		return "", false
+	}
+	if len(queue) == 1 {
+		delete(r.pending, key)
+	} else {
+		r.pending[key] = queue[1:]
+	}
+	return queue[0].requestID, true
+}
+
+// evictLocked 清除超时未匹配的记录，调用方需持有锁
+func (r *requestIDRegistry) evictLocked(now time.Time) {
+	for key, queue := range r.pending {
+		kept := queue[:0]
+		for _, item := range queue {
+			if now.Sub(item.createdAt) < pendingRequestIDTTL {
+				kept = append(kept, item)
+			}
+		}
+		if len(kept) == 0 {
+			delete(r.pending, key)
+			continue
+		}
+		r.pending[key] = kept
+	}
+}
+
+// newRequestID 生成请求ID
+func newRequestID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// clientRequestID 获取客户端提供的请求ID，为空或过长时返回空字符串
+func clientRequestID(r *http.Request) string {
+	requestID := strings.TrimSpace(r.Header.Get(headerRequestID))
+	if len(requestID) > maxRequestIDLength {
+		return ""
+	}
+	return requestID
+}
+
+// withRequestID 登记POST请求中工具调用对应的X-Request-ID，供工具调用中间件复用
+// SDK以会话而不是单个HTTP请求的上下文调用工具处理器，无法通过请求上下文传递ID，因此需要读取请求体匹配工具调用
+// 未携带请求头时直接放行，不读取请求体
+func withRequestID(registry *requestIDRegistry, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := clientRequestID(r)
+		sessionID := r.Header.Get(headerSessionID)
+		if requestID == "" || sessionID == "" || r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxMessageBodyBytes))
+		r.Body.Close()
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "请求体过大", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "读取请求体失败", http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := time.Now()
+		for _, call := range parseToolCalls(body) {
+			registry.put(requestIDKey(sessionID, call.Params.Name, call.Params.Arguments), requestID, now)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// toolCallMessage JSON-RPC工具调用消息中匹配所需的字段
+type toolCallMessage struct {
+	Method string `json:"method"`
+	Params struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	} `json:"params"`
+}
+
+// parseToolCalls 解析单条或批量JSON-RPC消息中的工具调用，格式错误时返回空，由SDK返回错误
+func parseToolCalls(body []byte) []toolCallMessage {
+	var messages []toolCallMessage
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &messages); err != nil {
+			return nil
+		}
+	} else {
+		var message toolCallMessage
+		if err := json.Unmarshal(trimmed, &message); err != nil {
+			return nil
+		}
+		messages = append(messages, message)
+	}
+
+	calls := messages[:0]
+	for _, message := range messages {
+		if message.Method == methodCallTool {
+			calls = append(calls, message)
+		}
+	}
+	return calls
+}
+
+// requestIDMiddleware 为每次工具调用分配请求ID（优先复用客户端的X-Request-ID），
+// 写入上下文并记录工具调用日志，错误结果末尾附加请求ID便于对照服务端日志
+func requestIDMiddleware(registry *requestIDRegistry, serviceType, endpoint string) mcp.Middleware[*mcp.ServerSession] {
+	return func(next mcp.MethodHandler[*mcp.ServerSession]) mcp.MethodHandler[*mcp.ServerSession] {
+		return func(ctx context.Context, session *mcp.ServerSession, method string, params mcp.Params) (mcp.Result, error) {
+			callParams, ok := params.(*mcp.CallToolParamsFor[json.RawMessage])
+			if !ok {
+				return next(ctx, session, method, params)
+			}
+
+			requestID := ""
+			if session != nil {
+				requestID, _ = registry.take(requestIDKey(session.ID(), callParams.Name, callParams.Arguments), time.Now())
+			}
+			if requestID == "" {
+				requestID = newRequestID()
+			}
+
+			start := time.Now()
+			result, err := next(common.WithRequestID(ctx, requestID), session, method, params)
+
+			status := toolStatusSuccess
+			switch {
+			case err != nil:
+				status = toolStatusFailure
+			case isErrorResult(result):
+				status = toolStatusError
+				appendRequestID(result, requestID)
+			}
+			log.Printf("tool request_id=%s service=%s endpoint=%s tool=%s status=%s duration_ms=%d",
+				requestID, serviceType, endpoint, callParams.Name, status, time.Since(start).Milliseconds())
+
+			return result, err
+		}
+	}
+}
+
+// appendRequestID 在错误结果的最后一个文本内容末尾附加请求ID
+func appendRequestID(result mcp.Result, requestID string) {
+	toolResult, ok := result.(*mcp.CallToolResult)
+	if !ok || toolResult == nil {
+		return
+	}
+	for i := len(toolResult.Content) - 1; i >= 0; i-- {
+		if text, ok := toolResult.Content[i].(*mcp.TextContent); ok {
+			text.Text += " (request_id: " + requestID + ")"
+			return
+		}
+	}
+	toolResult.Content = append(toolResult.Content, &mcp.TextContent{Text: "request_id: " + requestID})
+}
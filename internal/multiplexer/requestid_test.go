@@ -0,0 +1,46 @@
+package multiplexer
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithRequestIDLimitsBody(t *testing.T) {
+	registry := newRequestIDRegistry()
+	var received []byte
+	handler := withRequestID(registry, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = io.ReadAll(r.Body)
+	}))
+
+	send := func(body []byte) int {
+		req := httptest.NewRequest(http.MethodPost, "/fake/mcp", bytes.NewReader(body))
+		req.Header.Set(headerRequestID, "req-1")
+		req.Header.Set(headerSessionID, "session-1")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	call := []byte(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"fake_echo","arguments":{}}}`)
+	if code := send(call); code != http.StatusOK {
+		t.Fatalf("small body: status = %d, want %d", code, http.StatusOK)
+	}
+	if !bytes.Equal(received, call) {
+		t.Errorf("next handler received %q, want original body", received)
+	}
+	if id, ok := registry.take(requestIDKey("session-1", "fake_echo", []byte("{}")), time.Now()); !ok || id != "req-1" {
+		t.Errorf("registered request id = %q, %v, want req-1", id, ok)
+	}
+
+	received = nil
+	if code := send(bytes.Repeat([]byte(" "), maxMessageBodyBytes+1)); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("oversized body: status = %d, want %d", code, http.StatusRequestEntityTooLarge)
+	}
+	if received != nil {
+		t.Error("oversized body reached next handler")
+	}
+}
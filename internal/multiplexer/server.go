@@ -3,11 +3,16 @@ package multiplexer
 import (
 	"bytes"
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"net"
 	"net/http"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"mcp-server/internal/core"
@@ -29,6 +34,11 @@ const (
 	// HTTP响应
 	contentTypeHTML   = "text/html; charset=utf-8"
 	httpErrorInternal = "内部服务器错误"
+
+	// 服务状态
+	serviceStatusAvailable   = "available"
+	serviceStatusUnavailable = "unavailable"
+	serviceStatusDisabled    = "disabled"
 )
 
 // ServiceInfo 服务信息
@@ -36,16 +46,39 @@ type ServiceInfo struct {
 	Type        core.ServiceType
 	Endpoint    string
 	Available   bool
+	Status      string
 	Tools       []string
 	Description string
+	Error       string // 不可用时的错误信息
+	Port        string // 独立监听端口，为空表示使用共享端口
+
+	// 并发请求统计，仅统计MCP消息(POST)
+	InFlight     int64
+	PeakInFlight int64
 }
 
 // Server HTTP多路复用服务器
 type Server struct {
-	services        map[string]core.Service // endpoint -> service 映射
-	server          *http.Server
+	services        map[string]core.Service        // endpoint -> service 映射
+	handlers        map[string]http.Handler        // endpoint -> MCP HTTP处理器
+	concurrency     map[string]*concurrencyCounter // endpoint -> 并发请求计数
+	disabled        map[string]core.ServiceType    // endpoint -> 已配置但禁用的服务类型
+	servers         []*http.Server                 // 共享端口及各独立端口的HTTP服务器
+	listening       map[string]bool                // 已启动监听的端口
 	port            string
 	serverAddresses []string
+	health          *healthTracker
+	status          *statusCache             // /status的连接测试结果缓存
+	authToken       string                   // MCP端点的Bearer认证令牌，为空表示不启用认证
+	corsOrigins     []string                 // 允许跨域访问MCP端点的来源
+	motd            string                   // 运维公告，通过各服务的公告资源提供给客户端
+	warmingUp       atomic.Bool              // 启用wait_for_ready时，预热完成前拒绝MCP请求
+	metricsEnabled  bool                     // 是否暴露/metrics并统计工具调用
+	instrumented    map[*mcp.Server]struct{} // 已添加工具调用中间件的MCP服务器
+	requestIDs      *requestIDRegistry       // 客户端X-Request-ID与工具调用的关联
+	configValidator ConfigValidator          // 配置校验函数，为nil时不注册server_validate_config工具
+	configDumper    ConfigDumper             // 生效配置导出函数，为nil时/admin/config返回403
+	toolToggles     *toolToggles             // 运行期间被临时禁用的工具
 	mu              sync.RWMutex
 
 	// 网络地址缓存优化
@@ -54,11 +87,39 @@ type Server struct {
 	cacheMutex       sync.RWMutex
 }
 
+// ServerOption 服务器配置选项
+type ServerOption func(*Server)
+
+// WithAuthToken 设置MCP端点的Bearer认证令牌
+func WithAuthToken(token string) ServerOption {
+	return func(s *Server) {
+		s.authToken = token
+	}
+}
+
+// WithCORSAllowedOrigins 设置允许跨域访问MCP端点的来源
+func WithCORSAllowedOrigins(origins []string) ServerOption {
+	return func(s *Server) {
+		s.corsOrigins = origins
+	}
+}
+
 // NewServer 创建新的多路复用服务器
-func NewServer(port string) *Server {
+func NewServer(port string, opts ...ServerOption) *Server {
 	server := &Server{
-		services: make(map[string]core.Service),
-		port:     port,
+		services:     make(map[string]core.Service),
+		handlers:     make(map[string]http.Handler),
+		concurrency:  make(map[string]*concurrencyCounter),
+		disabled:     make(map[string]core.ServiceType),
+		instrumented: make(map[*mcp.Server]struct{}),
+		requestIDs:   newRequestIDRegistry(),
+		toolToggles:  newToolToggles(),
+		health:       newHealthTracker(),
+		status:       newStatusCache(),
+		port:         port,
+	}
+	for _, opt := range opts {
+		opt(server)
 	}
 	// 初始化时获取网络地址
 	server.serverAddresses = server.getCachedServerAddresses()
@@ -124,34 +185,102 @@ func endpointFormatting(addresses []string, port, endpoint string) string {
 	return builder.String()
 }
 
-// AddService 添加服务
+// AddService 添加服务，服务器运行期间添加的服务立即生效
+// 端点上已有其他服务时替换并关闭旧服务
 func (s *Server) AddService(service core.Service) {
 	endpoint := service.GetEndpoint()
 	serviceType := service.GetType()
+	port := servicePort(service, s.port)
+	handler := s.newServiceHandler(service)
+	s.registerMOTDResource(service.GetServer())
+	s.registerValidateConfigTool(service.GetServer())
+	s.instrumentTools(service)
 
 	s.mu.Lock()
+	previous, replaced := s.services[endpoint]
 	s.services[endpoint] = service
+	s.handlers[endpoint] = handler
+	started := s.listening != nil
+	listening := s.listening[port]
 	s.mu.Unlock()
 
-	log.Printf("✓ 注册服务: %s -> %s", serviceType, endpoint)
+	if replaced && previous != service {
+		previous.Close()
+		s.health.forget(endpoint)
+		log.Printf("✓ 替换服务: %s -> %s", serviceType, endpoint)
+	} else {
+		log.Printf("✓ 注册服务: %s -> %s", serviceType, endpoint)
+	}
+
+	if !started {
+		return
+	}
+	if !listening {
+		log.Printf("警告: 服务 %s 的独立端口 %s 未在监听，需重启后生效", endpoint, port)
+		return
+	}
+	log.Printf("%s MCP端点: %s", serviceType, endpointFormatting(s.serverAddresses, port, endpoint))
+}
+
+// newServiceHandler 创建服务的MCP HTTP处理器，包含访问日志、CORS和认证
+func (s *Server) newServiceHandler(service core.Service) http.Handler {
+	handler := mcp.NewStreamableHTTPHandler(
+		func(request *http.Request) *mcp.Server {
+			return service.GetServer()
+		},
+		&mcp.StreamableHTTPOptions{},
+	)
+	counter := s.endpointCounter(service.GetEndpoint())
+	return withAccessLog(service.GetType(), withCORS(s.corsOrigins, requireBearerToken(s.authToken, withConcurrency(counter, withRequestID(s.requestIDs, handler)))))
+}
+
+// AddDisabledService 记录已配置但禁用的服务，仅用于信息页面展示
+func (s *Server) AddDisabledService(serviceType core.ServiceType, endpoint string) {
+	s.mu.Lock()
+	s.disabled[endpoint] = serviceType
+	s.mu.Unlock()
+
+	log.Printf("服务已禁用: %s -> %s", serviceType, endpoint)
+}
+
+// RemoveDisabledService 移除禁用服务的展示记录
+func (s *Server) RemoveDisabledService(endpoint string) {
+	s.mu.Lock()
+	delete(s.disabled, endpoint)
+	s.mu.Unlock()
 }
 
 // RemoveService 移除服务
 func (s *Server) RemoveService(endpoint string) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
+	service, exists := s.services[endpoint]
+	if !exists {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.services, endpoint)
+	delete(s.handlers, endpoint)
+	delete(s.concurrency, endpoint)
+	s.mu.Unlock()
 
-	if service, exists := s.services[endpoint]; exists {
-		service.Close()
-		delete(s.services, endpoint)
-		log.Printf("移除服务: %s", endpoint)
+	// 锁外关闭，服务可能需要等待进行中的请求完成
+	s.health.forget(endpoint)
+	service.Close()
+	log.Printf("移除服务: %s", endpoint)
+}
+
+// servicePort 获取服务的监听端口，未配置独立端口时使用共享端口
+func servicePort(service core.Service, defaultPort string) string {
+	if provider, ok := service.(core.PortProvider); ok {
+		if port := provider.GetPort(); port != "" {
+			return port
+		}
 	}
+	return defaultPort
 }
 
-// Start 启动服务器
+// Start 启动服务器，配置了独立端口的服务在各自端口上监听
 func (s *Server) Start() error {
-	mux := http.NewServeMux()
-
 	s.mu.RLock()
 	servicesCopy := make(map[string]core.Service, len(s.services))
 	for k, v := range s.services {
@@ -159,38 +288,110 @@ func (s *Server) Start() error {
 	}
 	s.mu.RUnlock()
 
+	// 按端口分组，共享端口同时提供信息页面和就绪检查
+	// MCP端点在请求时按当前注册的服务分发，运行期间增删服务无需重建监听
+	mainMux := http.NewServeMux()
+	muxes := map[string]*http.ServeMux{s.port: mainMux}
+
 	for endpoint, service := range servicesCopy {
-		// 创建服务处理器
-		handler := mcp.NewStreamableHTTPHandler(
-			func(request *http.Request) *mcp.Server {
-				return service.GetServer()
-			},
-			&mcp.StreamableHTTPOptions{},
-		)
-		mux.Handle(endpoint, handler)
+		port := servicePort(service, s.port)
+		if _, exists := muxes[port]; !exists {
+			mux := http.NewServeMux()
+			mux.HandleFunc(healthzPath, s.handleHealthz)
+			muxes[port] = mux
+		}
 
 		// 使用字符串格式化
-		endpointsStr := endpointFormatting(s.serverAddresses, s.port, endpoint)
+		endpointsStr := endpointFormatting(s.serverAddresses, port, endpoint)
 		log.Printf("%s MCP端点: %s", service.GetType(), endpointsStr)
 	}
 
+	// 添加健康检查端点
+	mainMux.HandleFunc(healthzPath, s.handleHealthz)
+	mainMux.HandleFunc(readyzPath, s.handleReadyz)
+	mainMux.Handle(statusPath, requireBearerToken(s.authToken, http.HandlerFunc(s.handleStatus)))
+
+	// 添加服务器自身指标端点
+	if s.metricsEnabled {
+		mainMux.Handle(metricsPath, metricsHandler())
+	}
+
+	// 添加运维公告管理端点
+	mainMux.HandleFunc(motdPath, s.handleMOTD)
+
+	// 添加工具开关管理端点
+	mainMux.HandleFunc(toolsAdminPath, s.handleToolToggles)
+
+	// 添加生效配置导出端点
+	mainMux.HandleFunc(configDumpPath, s.handleConfigDump)
+
 	// 添加根路径信息页面
-	mux.HandleFunc(rootPath, s.handleRoot)
+	mainMux.HandleFunc(rootPath, s.handleRoot)
 
-	serverAddrsStr := endpointFormatting(s.serverAddresses, s.port, "")
-	log.Printf("服务器监听地址: %s", serverAddrsStr)
+	if s.authToken != "" {
+		log.Printf("MCP端点已启用Bearer令牌认证")
+	}
 
 	// 创建HTTP服务器
-	s.server = &http.Server{
-		Addr:           ":" + s.port,
-		Handler:        mux,
+	servers := make([]*http.Server, 0, len(muxes))
+	listening := make(map[string]bool, len(muxes))
+	for port, mux := range muxes {
+		servers = append(servers, newHTTPServer(port, s.warmUpGate(s.router(port, mux))))
+		listening[port] = true
+		log.Printf("服务器监听地址: %s", endpointFormatting(s.serverAddresses, port, ""))
+	}
+
+	s.mu.Lock()
+	s.servers = servers
+	s.listening = listening
+	s.mu.Unlock()
+
+	errChan := make(chan error, len(servers))
+	for _, server := range servers {
+		go func(server *http.Server) {
+			errChan <- server.ListenAndServe()
+		}(server)
+	}
+
+	// 任一端口监听失败时关闭其余服务器，保证要么全部可用要么整体退出
+	err := <-errChan
+	if !errors.Is(err, http.ErrServerClosed) {
+		for _, server := range servers {
+			server.Close()
+		}
+	}
+	for i := 1; i < len(servers); i++ {
+		<-errChan
+	}
+	return err
+}
+
+// router 将请求分发到当前注册在该端口上的服务，未匹配的路径交给fallback处理
+func (s *Server) router(port string, fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.RLock()
+		service, exists := s.services[r.URL.Path]
+		handler := s.handlers[r.URL.Path]
+		s.mu.RUnlock()
+
+		if exists && servicePort(service, s.port) == port {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		fallback.ServeHTTP(w, r)
+	})
+}
+
+// newHTTPServer 创建HTTP服务器
+func newHTTPServer(port string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:           ":" + port,
+		Handler:        handler,
 		ReadTimeout:    readTimeout,
 		WriteTimeout:   writeTimeout,
 		IdleTimeout:    idleTimeout,
 		MaxHeaderBytes: maxHeaderBytes,
 	}
-
-	return s.server.ListenAndServe()
 }
 
 // Shutdown 优雅关闭服务器
@@ -200,36 +401,108 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	for _, service := range s.services {
 		servicesCopy = append(servicesCopy, service)
 	}
+	servers := s.servers
 	s.mu.RUnlock()
 
+	// 并发关闭服务，支持ContextCloser的服务在ctx截止前等待进行中的请求完成
+	var wg sync.WaitGroup
+	var errMu sync.Mutex
+	var errs []error
 	for _, service := range servicesCopy {
-		service.Close()
+		wg.Add(1)
+		go func(service core.Service) {
+			defer wg.Done()
+
+			var err error
+			if closer, ok := service.(core.ContextCloser); ok {
+				err = closer.CloseContext(ctx)
+			} else {
+				err = service.Close()
+			}
+			if err != nil {
+				errMu.Lock()
+				errs = append(errs, fmt.Errorf("关闭服务 %s 失败: %w", service.GetEndpoint(), err))
+				errMu.Unlock()
+			}
+		}(service)
 	}
+	wg.Wait()
 
-	// 关闭HTTP服务器
-	if s.server != nil {
-		return s.server.Shutdown(ctx)
+	// 关闭所有HTTP服务器
+	for _, server := range servers {
+		if err := server.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
 	}
-	return nil
+	return errors.Join(errs...)
 }
 
-// GetServiceInfo 获取服务信息
-func (s *Server) GetServiceInfo() []ServiceInfo {
+// GetServiceInfo 获取服务信息，已注册服务的可用状态来自缓存的连接测试结果
+func (s *Server) GetServiceInfo(ctx context.Context) []ServiceInfo {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	infos := make([]ServiceInfo, 0, len(s.services))
+	servicesCopy := make(map[string]core.Service, len(s.services))
 	for endpoint, service := range s.services {
+		servicesCopy[endpoint] = service
+	}
+	disabledCopy := make(map[string]core.ServiceType, len(s.disabled))
+	for endpoint, serviceType := range s.disabled {
+		disabledCopy[endpoint] = serviceType
+	}
+	concurrency := s.concurrencySnapshot()
+	s.mu.RUnlock()
+
+	// 锁外执行连接测试，避免阻塞服务注册
+	liveness := s.checkLiveness(ctx, servicesCopy)
+
+	infos := make([]ServiceInfo, 0, len(servicesCopy)+len(disabledCopy))
+	for endpoint, service := range servicesCopy {
 		info := ServiceInfo{
 			Type:        service.GetType(),
 			Endpoint:    endpoint,
-			Available:   true, // 已注册的服务都是可用的
+			Available:   true,
+			Status:      serviceStatusAvailable,
 			Tools:       getToolsForService(service.GetType()),
 			Description: getDescriptionForService(service.GetType()),
+
+			InFlight:     concurrency[endpoint].current,
+			PeakInFlight: concurrency[endpoint].peak,
+		}
+		if lister, ok := service.(core.ToolLister); ok {
+			info.Tools = filterToolSummaries(info.Tools, lister.ToolNames())
+		}
+		if describer, ok := service.(core.ServiceDescriber); ok {
+			info.Tools = describer.ToolSummaries()
+			info.Description = describer.Description()
+		}
+		if port := servicePort(service, s.port); port != s.port {
+			info.Port = port
+		}
+		if err := liveness[endpoint]; err != nil {
+			info.Available = false
+			info.Status = serviceStatusUnavailable
+			info.Error = err.Error()
 		}
 		infos = append(infos, info)
 	}
 
+	for endpoint, serviceType := range disabledCopy {
+		if _, registered := servicesCopy[endpoint]; registered {
+			continue
+		}
+		infos = append(infos, ServiceInfo{
+			Type:        serviceType,
+			Endpoint:    endpoint,
+			Available:   false,
+			Status:      serviceStatusDisabled,
+			Description: getDescriptionForService(serviceType),
+		})
+	}
+
+	// 按端点排序，保证页面展示顺序稳定
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Endpoint < infos[j].Endpoint
+	})
+
 	return infos
 }
 
@@ -243,7 +516,7 @@ func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", contentTypeHTML)
 
 	// 准备模板数据
-	serviceInfos := s.GetServiceInfo()
+	serviceInfos := s.GetServiceInfo(r.Context())
 	data := struct {
 		ServerAddresses []string
 		Port            string
@@ -348,20 +621,62 @@ func getToolsForService(serviceType core.ServiceType) []string {
 			"prometheus_targets - 获取监控目标",
 			"prometheus_status - 检查服务状态",
 			"prometheus_common_metrics - 查询常用指标",
+			"prometheus_common_metrics_range - 常用指标的范围查询",
+			"prometheus_common_metrics_all - 批量查询所有常用指标",
+			"prometheus_list_common_metrics - 列出常用指标类型及查询",
 			"prometheus_list_metrics - 获取所有指标",
+			"prometheus_snapshot_metrics - 保存指标名称快照",
+			"prometheus_diff_metrics - 对比指标名称快照",
+			"prometheus_alerts - 获取活跃告警",
+			"prometheus_query_with_modifiers - 带offset/@修饰符的查询",
+			"prometheus_histogram_quantile - 计算直方图分位数",
+			"prometheus_counter_increase - 计算计数器在时间窗口内的增量",
+			"prometheus_rules - 获取告警和记录规则",
+			"prometheus_label_values - 获取标签取值",
+			"prometheus_series - 获取序列标签集合",
+			"prometheus_query_exemplars - 查询exemplar及trace_id",
+			"prometheus_tsdb_stats - 获取TSDB统计和标签基数",
+			"prometheus_metric_metadata - 获取指标类型和帮助信息",
+			"prometheus_raw_api - 透传/api/v1/原始接口（需开启enable_raw_api）",
+			"prometheus_reload - 重载Prometheus配置（需开启enable_admin_api）",
 		}
 	case core.ServiceTypeSuperset:
 		return []string{
 			"superset_list_databases - 获取数据库列表",
 			"superset_execute_sql - 执行SQL查询",
 			"superset_execute_sql_with_schema - 在指定schema中执行SQL",
+			"superset_next_page - 获取分页查询的下一页",
+			"superset_validate_sql - 校验SQL语法（不执行）",
 			"superset_status - 检查服务状态",
+			"superset_export_csv - 获取查询结果CSV下载链接",
+			"superset_list_saved_queries - 获取已保存查询",
+			"superset_run_saved_query - 执行已保存查询",
+			"superset_list_dashboards - 获取看板列表",
+			"superset_list_charts - 获取图表列表",
+			"superset_get_chart_data - 获取图表数据",
+			"superset_query_error - 获取查询失败详情",
+			"superset_my_queries - 获取当前用户查询历史",
+			"superset_database_permissions - 获取可访问数据库的角色",
+			"superset_version - 获取Superset版本和功能开关",
+			"superset_logout - 登出并清除本地会话（需开启enable_admin_api）",
 		}
 	default:
 		return []string{}
 	}
 }
 
+// filterToolSummaries 只保留已注册工具的说明，例如未开启enable_raw_api时不展示prometheus_raw_api
+func filterToolSummaries(summaries, names []string) []string {
+	result := make([]string, 0, len(summaries))
+	for _, summary := range summaries {
+		name, _, _ := strings.Cut(summary, " - ")
+		if slices.Contains(names, name) {
+			result = append(result, summary)
+		}
+	}
+	return result
+}
+
 // getDescriptionForService 获取服务描述
 func getDescriptionForService(serviceType core.ServiceType) string {
 	switch serviceType {
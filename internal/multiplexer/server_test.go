@@ -0,0 +1,141 @@
+package multiplexer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-server/internal/core"
+)
+
+// listedService 提供已注册工具名称的测试服务
+type listedService struct {
+	*fakeService
+	names []string
+}
+
+func (s *listedService) ToolNames() []string { return s.names }
+
+func TestServiceInfoListsOnlyRegisteredTools(t *testing.T) {
+	server := NewServer("0")
+	server.AddService(&listedService{
+		fakeService: newFakeService(core.ServiceTypePrometheus, "/prometheus/mcp"),
+		names:       []string{"prometheus_query", "prometheus_status"},
+	})
+
+	infos := server.GetServiceInfo(context.Background())
+	if len(infos) != 1 {
+		t.Fatalf("infos = %+v, want 1 service", infos)
+	}
+	var names []string
+	for _, summary := range infos[0].Tools {
+		name, _, _ := strings.Cut(summary, " - ")
+		names = append(names, name)
+	}
+	if want := []string{"prometheus_query", "prometheus_status"}; !slices.Equal(names, want) {
+		t.Errorf("tools = %v, want %v", names, want)
+	}
+}
+
+func TestServiceInfoShowsDisabledService(t *testing.T) {
+	server := NewServer("0")
+	server.AddService(newFakeService(core.ServiceTypePrometheus, "/prometheus/mcp"))
+	server.AddDisabledService(core.ServiceTypeSuperset, "/superset/mcp")
+
+	infos := server.GetServiceInfo(context.Background())
+	if len(infos) != 2 {
+		t.Fatalf("infos = %+v, want 2 services", infos)
+	}
+	status := make(map[string]ServiceInfo, len(infos))
+	for _, info := range infos {
+		status[info.Endpoint] = info
+	}
+	if info := status["/superset/mcp"]; info.Status != serviceStatusDisabled || info.Available || info.Type != core.ServiceTypeSuperset {
+		t.Errorf("disabled service info = %+v, want type superset with status %q", info, serviceStatusDisabled)
+	}
+	if info := status["/prometheus/mcp"]; info.Status != serviceStatusAvailable || !info.Available {
+		t.Errorf("registered service info = %+v, want status %q", info, serviceStatusAvailable)
+	}
+
+	// 同一端点重新启用后不再显示为禁用
+	server.AddService(newFakeService(core.ServiceTypeSuperset, "/superset/mcp"))
+	for _, info := range server.GetServiceInfo(context.Background()) {
+		if info.Endpoint == "/superset/mcp" && info.Status == serviceStatusDisabled {
+			t.Errorf("registered service still shown as disabled: %+v", info)
+		}
+	}
+}
+
+// freePort 获取一个当前空闲的本地端口
+func freePort(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+}
+
+func TestServicesBindSeparatePorts(t *testing.T) {
+	mainPort, promPort, supersetPort := freePort(t), freePort(t), freePort(t)
+	server := NewServer(mainPort)
+	prom := newFakeService(core.ServiceTypePrometheus, "/prometheus/mcp")
+	prom.port = promPort
+	superset := newFakeService(core.ServiceTypeSuperset, "/superset/mcp")
+	superset.port = supersetPort
+	server.AddService(prom)
+	server.AddService(superset)
+
+	started := make(chan error, 1)
+	go func() { started <- server.Start() }()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			t.Errorf("Shutdown: %v", err)
+		}
+		if err := <-started; !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("Start() = %v, want http.ErrServerClosed", err)
+		}
+	})
+
+	get := func(port, path string) int {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			resp, err := http.Get("http://127.0.0.1:" + port + path)
+			if err == nil {
+				resp.Body.Close()
+				return resp.StatusCode
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("GET :%s%s: %v", port, path, err)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	for _, port := range []string{mainPort, promPort, supersetPort} {
+		if code := get(port, healthzPath); code != http.StatusOK {
+			t.Errorf("GET :%s%s = %d, want 200", port, healthzPath, code)
+		}
+	}
+
+	// 每个服务只在自己的端口上提供MCP端点
+	if code := get(promPort, "/prometheus/mcp"); code == http.StatusNotFound {
+		t.Errorf("prometheus endpoint not served on its own port")
+	}
+	if code := get(supersetPort, "/prometheus/mcp"); code != http.StatusNotFound {
+		t.Errorf("prometheus endpoint on superset port = %d, want 404", code)
+	}
+	if code := get(mainPort, "/superset/mcp"); code != http.StatusNotFound {
+		t.Errorf("superset endpoint on shared port = %d, want 404", code)
+	}
+}
@@ -0,0 +1,84 @@
+package multiplexer
+
+import (
+	"context"
+	"sync/atomic"
+
+	"mcp-server/internal/core"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// fakeService 测试用服务，连接测试结果和调用次数可控
+type fakeService struct {
+	serviceType core.ServiceType
+	endpoint    string
+	port        string
+	server      *mcp.Server
+
+	testErr   atomic.Pointer[error]
+	testCalls atomic.Int64
+	closed    atomic.Bool
+}
+
+// newFakeService 创建测试用服务
+func newFakeService(serviceType core.ServiceType, endpoint string) *fakeService {
+	return &fakeService{
+		serviceType: serviceType,
+		endpoint:    endpoint,
+		server:      mcp.NewServer(&mcp.Implementation{Name: "fake", Version: "test"}, nil),
+	}
+}
+
+// setTestErr 设置后续连接测试返回的错误，nil表示连接正常
+func (f *fakeService) setTestErr(err error) {
+	f.testErr.Store(&err)
+}
+
+func (f *fakeService) GetServer() *mcp.Server { return f.server }
+
+func (f *fakeService) TestConnection(context.Context) error {
+	f.testCalls.Add(1)
+	if err := f.testErr.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+func (f *fakeService) Close() error {
+	f.closed.Store(true)
+	return nil
+}
+
+func (f *fakeService) GetType() core.ServiceType { return f.serviceType }
+
+func (f *fakeService) GetEndpoint() string { return f.endpoint }
+
+func (f *fakeService) GetPort() string { return f.port }
+
+// listToolNames 通过内存传输连接MCP服务器并列出工具名称
+func listToolNames(ctx context.Context, server *mcp.Server) ([]string, error) {
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport)
+	if err != nil {
+		return nil, err
+	}
+	defer serverSession.Close()
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "test"}, nil)
+	session, err := client.Connect(ctx, clientTransport)
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	result, err := session.ListTools(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(result.Tools))
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+	}
+	return names, nil
+}
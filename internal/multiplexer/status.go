@@ -0,0 +1,149 @@
+package multiplexer
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"mcp-server/internal/core"
+)
+
+// 状态汇总相关常量
+const (
+	statusPath = "/status"
+
+	// statusProbeTimeout 状态汇总时单个服务的连接测试超时
+	statusProbeTimeout = 5 * time.Second
+	// maxStatusConcurrency 状态汇总时同时测试的服务数上限
+	maxStatusConcurrency = 4
+	// statusMinProbeInterval 同一服务两次连接测试的最小间隔，间隔内的请求直接返回缓存结果
+	statusMinProbeInterval = 5 * time.Second
+)
+
+// serviceStatus 单个服务的连接状态
+type serviceStatus struct {
+	Type      core.ServiceType `json:"type"`
+	Available bool             `json:"available"`
+	LatencyMS int64            `json:"latency_ms"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// statusEntry 缓存的单个服务连接状态
+type statusEntry struct {
+	checkedAt time.Time
+	result    serviceStatus
+}
+
+// statusCache 缓存状态汇总结果，同一时间只有一个请求测试上游，并发请求等待后复用其结果
+type statusCache struct {
+	mu      sync.Mutex
+	entries map[string]statusEntry // endpoint -> 最近一次连接测试结果
+}
+
+// newStatusCache 创建状态汇总缓存
+func newStatusCache() *statusCache {
+	return &statusCache{entries: make(map[string]statusEntry)}
+}
+
+// handleStatus 汇总所有服务的连接状态，同一服务在statusMinProbeInterval内只测试一次连接
+// 部分服务不可用时仍返回200，由调用方根据available判断，只有全部不可用时返回503
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	services := make(map[string]core.Service, len(s.services))
+	for endpoint, service := range s.services {
+		services[endpoint] = service
+	}
+	s.mu.RUnlock()
+
+	results := s.cachedStatus(r.Context(), services)
+
+	available := 0
+	for _, result := range results {
+		if result.Available {
+			available++
+		}
+	}
+
+	status, code := "ok", http.StatusOK
+	switch {
+	case len(results) > 0 && available == 0:
+		status, code = "unavailable", http.StatusServiceUnavailable
+	case available < len(results):
+		status = "degraded"
+	}
+	writeJSON(w, code, map[string]any{
+		"status":    status,
+		"available": available,
+		"total":     len(results),
+		"services":  results,
+	})
+}
+
+// cachedStatus 返回服务连接状态，只对没有缓存或缓存过期的服务重新测试
+// 测试期间持有锁，并发的状态请求不会重复测试同一上游
+func (s *Server) cachedStatus(ctx context.Context, services map[string]core.Service) map[string]serviceStatus {
+	s.status.mu.Lock()
+	defer s.status.mu.Unlock()
+
+	now := time.Now()
+	stale := make(map[string]core.Service)
+	for endpoint, service := range services {
+		if entry, ok := s.status.entries[endpoint]; !ok || now.Sub(entry.checkedAt) >= statusMinProbeInterval {
+			stale[endpoint] = service
+		}
+	}
+	if len(stale) > 0 {
+		for endpoint, result := range s.collectStatus(ctx, stale) {
+			s.status.entries[endpoint] = statusEntry{checkedAt: time.Now(), result: result}
+		}
+	}
+
+	results := make(map[string]serviceStatus, len(services))
+	for endpoint := range services {
+		results[endpoint] = s.status.entries[endpoint].result
+	}
+	return results
+}
+
+// collectStatus 以有限并发测试所有服务连接，单个上游较慢不会阻塞其他服务的测试
+func (s *Server) collectStatus(ctx context.Context, services map[string]core.Service) map[string]serviceStatus {
+	results := make(map[string]serviceStatus, len(services))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	sem := make(chan struct{}, maxStatusConcurrency)
+
+	for endpoint, service := range services {
+		wg.Add(1)
+		go func(endpoint string, service core.Service) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			probeCtx, cancel := context.WithTimeout(ctx, statusProbeTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := service.TestConnection(probeCtx)
+			now := time.Now()
+			s.health.recordLiveness(endpoint, livenessResult{checkedAt: now, err: err})
+
+			result := serviceStatus{
+				Type:      service.GetType(),
+				Available: err == nil,
+				LatencyMS: now.Sub(start).Milliseconds(),
+			}
+			if err != nil {
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			results[endpoint] = result
+			mu.Unlock()
+		}(endpoint, service)
+	}
+
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,97 @@
+package multiplexer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowService 连接测试耗时固定的服务，记录同时进行的连接测试数峰值
+type slowService struct {
+	*fakeService
+	delay    time.Duration
+	inFlight *atomic.Int64
+	peak     *atomic.Int64
+}
+
+func (s *slowService) TestConnection(ctx context.Context) error {
+	current := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		peak := s.peak.Load()
+		if current <= peak || s.peak.CompareAndSwap(peak, current) {
+			break
+		}
+	}
+	time.Sleep(s.delay)
+	return s.fakeService.TestConnection(ctx)
+}
+
+func TestStatusBoundedConcurrency(t *testing.T) {
+	server := NewServer("0")
+	var inFlight, peak atomic.Int64
+	services := make([]*slowService, 0, 3*maxStatusConcurrency)
+	for i := range 3 * maxStatusConcurrency {
+		service := &slowService{
+			fakeService: newFakeService("fake", fmt.Sprintf("/fake-%d/mcp", i)),
+			delay:       20 * time.Millisecond,
+			inFlight:    &inFlight,
+			peak:        &peak,
+		}
+		services = append(services, service)
+		server.AddService(service)
+	}
+
+	// 并发的状态请求共享同一次测试
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			server.handleStatus(rec, httptest.NewRequest(http.MethodGet, statusPath, nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got > maxStatusConcurrency {
+		t.Errorf("peak concurrent probes = %d, want <= %d", got, maxStatusConcurrency)
+	}
+	for _, service := range services {
+		if calls := service.testCalls.Load(); calls != 1 {
+			t.Errorf("%s: TestConnection calls = %d, want 1", service.endpoint, calls)
+		}
+	}
+}
+
+func TestStatusRequiresAuthToken(t *testing.T) {
+	server := NewServer("0", WithAuthToken("secret"))
+	service := newFakeService("fake", "/fake/mcp")
+	server.AddService(service)
+	handler := requireBearerToken(server.authToken, http.HandlerFunc(server.handleStatus))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, statusPath, nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("without token: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+	if calls := service.testCalls.Load(); calls != 0 {
+		t.Fatalf("without token: TestConnection calls = %d, want 0", calls)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, statusPath, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("with token: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -16,6 +16,8 @@ var htmlTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
         .status { padding: 5px 10px; border-radius: 3px; font-size: 12px; font-weight: bold; }
         .status.available { background: #d4edda; color: #155724; }
         .status.unavailable { background: #f8d7da; color: #721c24; }
+        .status.disabled { background: #e2e3e5; color: #383d41; }
+        .error { background: #f8d7da; color: #721c24; padding: 8px; border-radius: 3px; font-family: monospace; font-size: 13px; }
         .server-addresses { background: #e9ecef; padding: 10px; margin: 10px 0; border-radius: 3px; font-family: monospace; }
         .service-grid { display: grid; grid-template-columns: repeat(auto-fit, minmax(400px, 1fr)); gap: 20px; }
         .tools-list { margin: 10px 0; }
@@ -38,12 +40,26 @@ var htmlTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
             <h3>{{.Type}} MCP服务器 
                 {{if .Available}}
                 <span class="status available">可用</span>
+                {{else if eq .Status "disabled"}}
+                <span class="status disabled">已禁用</span>
                 {{else}}
                 <span class="status unavailable">不可用</span>
                 {{end}}
             </h3>
             <p>{{.Description}}</p>
+            {{if .Error}}
+            <p class="error"><strong>错误:</strong> {{.Error}}</p>
+            {{end}}
+            {{if eq .Status "disabled"}}
+            <p><strong>端点:</strong> {{.Endpoint}}（配置中已禁用）</p>
+            {{else if .Port}}
+            <p><strong>端点:</strong> {{.Endpoint}}（独立端口: {{.Port}}）</p>
+            {{else}}
             <p><strong>端点:</strong> <a href="{{.Endpoint}}">{{.Endpoint}}</a></p>
+            {{end}}
+            {{if ne .Status "disabled"}}
+            <p><strong>并发请求:</strong> 当前 {{.InFlight}}，峰值 {{.PeakInFlight}}</p>
+            {{end}}
             {{if .Tools}}
             <p><strong>可用工具:</strong></p>
             <ul class="tools-list">
@@ -0,0 +1,181 @@
+package multiplexer
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// 工具开关管理端点相关常量
+const (
+	toolsAdminPath = "/admin/tools"
+
+	// maxToolToggleBytes 管理端点接受的请求体上限
+	maxToolToggleBytes = 4 << 10
+
+	httpErrorToolsReadOnly = "未配置http_auth_token，工具开关只读"
+	httpErrorToolsBody     = "请求体应为JSON: {\"tool\": \"...\", \"enabled\": false, \"endpoint\": \"...\"}，endpoint可选"
+)
+
+// toolToggleRequest 管理端点的请求格式，endpoint为空表示作用于所有端点
+type toolToggleRequest struct {
+	Tool     string `json:"tool"`
+	Enabled  *bool  `json:"enabled"`
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// disabledTool 被禁用的工具，endpoint为空表示在所有端点上禁用
+type disabledTool struct {
+	Tool     string `json:"tool"`
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// toolToggles 运行期间被临时禁用的工具，重启后恢复
+type toolToggles struct {
+	mu       sync.RWMutex
+	disabled map[disabledTool]struct{}
+}
+
+// newToolToggles 创建工具开关
+func newToolToggles() *toolToggles {
+	return &toolToggles{disabled: make(map[disabledTool]struct{})}
+}
+
+// set 启用或禁用工具，在所有端点上启用时同时清除各端点上的禁用
+// 在单个端点上启用不会覆盖所有端点上的禁用，此时返回true表示工具在该端点上仍被禁用
+func (t *toolToggles) set(tool, endpoint string, enabled bool) (stillDisabled bool) {
+	key := disabledTool{Tool: tool, Endpoint: endpoint}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch {
+	case !enabled:
+		t.disabled[key] = struct{}{}
+	case endpoint == "":
+		for disabled := range t.disabled {
+			if disabled.Tool == tool {
+				delete(t.disabled, disabled)
+			}
+		}
+	default:
+		delete(t.disabled, key)
+		_, stillDisabled = t.disabled[disabledTool{Tool: tool}]
+	}
+	return stillDisabled
+}
+
+// isDisabled 判断工具在指定端点上是否被禁用
+func (t *toolToggles) isDisabled(tool, endpoint string) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if _, ok := t.disabled[disabledTool{Tool: tool}]; ok {
+		return true
+	}
+	_, ok := t.disabled[disabledTool{Tool: tool, Endpoint: endpoint}]
+	return ok
+}
+
+// list 按工具名称和端点排序返回被禁用的工具
+func (t *toolToggles) list() []disabledTool {
+	t.mu.RLock()
+	tools := make([]disabledTool, 0, len(t.disabled))
+	for key := range t.disabled {
+		tools = append(tools, key)
+	}
+	t.mu.RUnlock()
+
+	sort.Slice(tools, func(i, j int) bool {
+		if tools[i].Tool != tools[j].Tool {
+			return tools[i].Tool < tools[j].Tool
+		}
+		return tools[i].Endpoint < tools[j].Endpoint
+	})
+	return tools
+}
+
+// toolToggleMiddleware 拦截对已禁用工具的tools/call请求，返回错误结果而不执行工具
+func toolToggleMiddleware(toggles *toolToggles, endpoint string) mcp.Middleware[*mcp.ServerSession] {
+	return func(next mcp.MethodHandler[*mcp.ServerSession]) mcp.MethodHandler[*mcp.ServerSession] {
+		return func(ctx context.Context, session *mcp.ServerSession, method string, params mcp.Params) (mcp.Result, error) {
+			callParams, ok := params.(*mcp.CallToolParamsFor[json.RawMessage])
+			if !ok || !toggles.isDisabled(callParams.Name, endpoint) {
+				return next(ctx, session, method, params)
+			}
+
+			return &mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{&mcp.TextContent{Text: "工具 " + callParams.Name + " 已被管理员临时禁用，请稍后重试"}},
+			}, nil
+		}
+	}
+}
+
+// handleToolToggles 查询或修改工具开关
+// 配置http_auth_token时GET同样需要Bearer令牌；PUT/POST需要Bearer令牌，未配置http_auth_token时拒绝修改
+func (s *Server) handleToolToggles(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		requireBearerToken(s.authToken, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeDisabledTools(w, s.toolToggles.list(), "")
+		})).ServeHTTP(w, r)
+	case http.MethodPut, http.MethodPost:
+		if s.authToken == "" {
+			http.Error(w, httpErrorToolsReadOnly, http.StatusForbidden)
+			return
+		}
+		requireBearerToken(s.authToken, http.HandlerFunc(s.updateToolToggle)).ServeHTTP(w, r)
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+// updateToolToggle 解析请求体并启用或禁用工具
+func (s *Server) updateToolToggle(w http.ResponseWriter, r *http.Request) {
+	var request toolToggleRequest
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxToolToggleBytes+1))
+	if err != nil || len(body) > maxToolToggleBytes || json.Unmarshal(body, &request) != nil ||
+		strings.TrimSpace(request.Tool) == "" || request.Enabled == nil {
+		http.Error(w, httpErrorToolsBody, http.StatusBadRequest)
+		return
+	}
+
+	tool := strings.TrimSpace(request.Tool)
+	stillDisabled := s.toolToggles.set(tool, request.Endpoint, *request.Enabled)
+
+	scope := "所有端点"
+	if request.Endpoint != "" {
+		scope = "端点 " + request.Endpoint + " "
+	}
+	warning := ""
+	switch {
+	case stillDisabled:
+		warning = "工具 " + tool + " 在所有端点上被禁用，仍不可用，请在所有端点上启用（省略endpoint）"
+		log.Printf("工具 %s 已清除%s上的禁用，但仍在所有端点上被禁用", tool, scope)
+	case *request.Enabled:
+		log.Printf("工具 %s 已在%s上恢复启用", tool, scope)
+	default:
+		log.Printf("工具 %s 已在%s上临时禁用", tool, scope)
+	}
+
+	writeDisabledTools(w, s.toolToggles.list(), warning)
+}
+
+// writeDisabledTools 以JSON格式返回当前被禁用的工具，warning非空时一并返回
+func writeDisabledTools(w http.ResponseWriter, tools []disabledTool, warning string) {
+	response := map[string]any{"disabled": tools}
+	if warning != "" {
+		response["warning"] = warning
+	}
+	w.Header().Set("Content-Type", contentTypeJSON)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		log.Printf("写入工具开关响应失败: %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package multiplexer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+type echoParams struct{}
+
+func TestToolToggleDisableThenRecover(t *testing.T) {
+	ctx := context.Background()
+	server := NewServer("0", WithAuthToken("secret"))
+	service := newFakeService("fake", "/fake/mcp")
+	mcp.AddTool(service.GetServer(), &mcp.Tool{Name: "fake_echo", Description: "echo"},
+		func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[echoParams]) (*mcp.CallToolResultFor[any], error) {
+			return &mcp.CallToolResultFor[any]{Content: []mcp.Content{&mcp.TextContent{Text: "ok"}}}, nil
+		})
+	server.AddService(service)
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := service.GetServer().Connect(ctx, serverTransport)
+	if err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	defer serverSession.Close()
+	session, err := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "test"}, nil).Connect(ctx, clientTransport)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	defer session.Close()
+
+	call := func() (bool, string) {
+		t.Helper()
+		result, err := session.CallTool(ctx, &mcp.CallToolParams{Name: "fake_echo", Arguments: map[string]any{}})
+		if err != nil {
+			t.Fatalf("call tool: %v", err)
+		}
+		return result.IsError, result.Content[0].(*mcp.TextContent).Text
+	}
+	toggle := func(body string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPut, toolsAdminPath, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		server.handleToolToggles(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("PUT %s: status = %d, body = %s", body, rec.Code, rec.Body.String())
+		}
+	}
+
+	if isError, text := call(); isError {
+		t.Fatalf("before toggle: got error %q", text)
+	}
+
+	toggle(`{"tool": "fake_echo", "enabled": false, "endpoint": "/fake/mcp"}`)
+	if isError, text := call(); !isError || !strings.Contains(text, "已被管理员临时禁用") {
+		t.Fatalf("after disable: IsError=%v text=%q, want disabled error", isError, text)
+	}
+
+	toggle(`{"tool": "fake_echo", "enabled": true, "endpoint": "/fake/mcp"}`)
+	if isError, text := call(); isError {
+		t.Fatalf("after enable: got error %q", text)
+	}
+}
+
+func TestToolToggleListRequiresAuthToken(t *testing.T) {
+	server := NewServer("0", WithAuthToken("secret"))
+
+	rec := httptest.NewRecorder()
+	server.handleToolToggles(rec, httptest.NewRequest(http.MethodGet, toolsAdminPath, nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("without token: status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, toolsAdminPath, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	server.handleToolToggles(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("with token: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
+
+func TestToolToggleGlobalAndEndpointScopes(t *testing.T) {
+	server := NewServer("0", WithAuthToken("secret"))
+	toggle := func(body string) string {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPut, toolsAdminPath, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		server.handleToolToggles(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("PUT %s: status = %d, body = %s", body, rec.Code, rec.Body.String())
+		}
+		return rec.Body.String()
+	}
+	disabled := func(endpoint string) bool {
+		return server.toolToggles.isDisabled("fake_echo", endpoint)
+	}
+
+	// 在所有端点上启用时清除各端点上的禁用
+	toggle(`{"tool": "fake_echo", "enabled": false, "endpoint": "/a/mcp"}`)
+	toggle(`{"tool": "fake_echo", "enabled": false, "endpoint": "/b/mcp"}`)
+	toggle(`{"tool": "fake_echo", "enabled": true}`)
+	if disabled("/a/mcp") || disabled("/b/mcp") {
+		t.Fatalf("after global enable: still disabled, list = %v", server.toolToggles.list())
+	}
+
+	// 在单个端点上启用不覆盖所有端点上的禁用，响应中给出警告
+	toggle(`{"tool": "fake_echo", "enabled": false}`)
+	body := toggle(`{"tool": "fake_echo", "enabled": true, "endpoint": "/a/mcp"}`)
+	if !disabled("/a/mcp") || !strings.Contains(body, `"warning"`) {
+		t.Errorf("endpoint enable under global disable: disabled=%v, body = %s, want still disabled with a warning", disabled("/a/mcp"), body)
+	}
+
+	body = toggle(`{"tool": "fake_echo", "enabled": true}`)
+	if disabled("/a/mcp") || strings.Contains(body, `"warning"`) {
+		t.Errorf("after global enable: disabled=%v, body = %s", disabled("/a/mcp"), body)
+	}
+}
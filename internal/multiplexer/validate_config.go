@@ -0,0 +1,81 @@
+package multiplexer
+
+import (
+	"context"
+	"log"
+
+	"mcp-server/internal/common"
+	"mcp-server/internal/core"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// validateConfigToolName 配置校验工具名称，配置了http_auth_token时在每个服务的MCP服务器上注册
+const validateConfigToolName = "server_validate_config"
+
+// maxConfigYAMLBytes 配置校验工具接受的YAML内容上限
+const maxConfigYAMLBytes = 1 << 20
+
+// ConfigIssue 配置校验发现的问题
+type ConfigIssue struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ConfigValidator 解析并校验完整的配置文件内容，不应用配置；内容无法解析时返回error
+type ConfigValidator func(data []byte) ([]ConfigIssue, error)
+
+// ValidateConfigParams 配置校验工具参数
+type ValidateConfigParams struct {
+	YAML string `json:"yaml" jsonschema:"完整的配置文件内容（YAML），与配置文件的格式相同"`
+}
+
+// WithConfigValidator 设置配置校验函数，同时配置了http_auth_token时每个服务都提供server_validate_config工具
+func WithConfigValidator(validator ConfigValidator) ServerOption {
+	return func(s *Server) {
+		s.configValidator = validator
+	}
+}
+
+// registerValidateConfigTool 在服务的MCP服务器上注册配置校验工具
+// 属于管理类工具，只在MCP端点要求Bearer令牌认证时注册
+func (s *Server) registerValidateConfigTool(server *mcp.Server) {
+	if server == nil || s.configValidator == nil || s.authToken == "" {
+		return
+	}
+
+	registrar := core.NewToolRegistrar(server)
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        validateConfigToolName,
+		Description: "校验完整的配置文件内容（YAML）而不应用，返回全部配置错误，用于在触发配置重载前预先检查",
+	}, createValidateConfigHandler(s.configValidator))
+	if err := registrar.Err(); err != nil {
+		log.Printf("注册工具 %s 失败: %v", validateConfigToolName, err)
+	}
+}
+
+// createValidateConfigHandler 创建配置校验处理器
+func createValidateConfigHandler(validator ConfigValidator) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[ValidateConfigParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(_ context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[ValidateConfigParams]) (*mcp.CallToolResultFor[any], error) {
+		content := params.Arguments.YAML
+		if content == "" {
+			return common.CreateErrorResponse("配置内容不能为空")
+		}
+		if len(content) > maxConfigYAMLBytes {
+			return common.CreateErrorResponse("配置内容超过上限 %d 字节", maxConfigYAMLBytes)
+		}
+
+		issues, err := validator([]byte(content))
+		if err != nil {
+			return common.CreateErrorResponse("配置解析失败: %v", err)
+		}
+		if issues == nil {
+			issues = []ConfigIssue{}
+		}
+
+		return common.CreateSuccessResponse(map[string]any{
+			"valid":  len(issues) == 0,
+			"errors": issues,
+		})
+	}
+}
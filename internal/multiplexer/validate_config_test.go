@@ -0,0 +1,71 @@
+package multiplexer
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// stubValidator 按内容返回固定问题的校验函数
+func stubValidator(data []byte) ([]ConfigIssue, error) {
+	if strings.Contains(string(data), "http_port: abc") {
+		return []ConfigIssue{{Field: "http_port", Message: "端口号应为1-65535之间的整数"}}, nil
+	}
+	return nil, nil
+}
+
+func TestValidateConfigToolRequiresAuthToken(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name  string
+		token string
+		want  bool
+	}{
+		{name: "without token", token: "", want: false},
+		{name: "with token", token: "secret", want: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := NewServer("0", WithAuthToken(tc.token), WithConfigValidator(stubValidator))
+			service := newFakeService("fake", "/fake/mcp")
+			server.AddService(service)
+
+			names, err := listToolNames(ctx, service.GetServer())
+			if err != nil {
+				t.Fatalf("list tools: %v", err)
+			}
+			if got := slices.Contains(names, validateConfigToolName); got != tc.want {
+				t.Errorf("tool registered = %v, want %v (tools: %v)", got, tc.want, names)
+			}
+		})
+	}
+}
+
+func TestValidateConfigHandler(t *testing.T) {
+	handler := createValidateConfigHandler(stubValidator)
+	for _, tc := range []struct {
+		name string
+		yaml string
+		want string
+	}{
+		{name: "valid", yaml: "http_port: \"8080\"\n", want: `{"errors":[],"valid":true}`},
+		{name: "invalid", yaml: "http_port: abc\n", want: `{"errors":[{"field":"http_port","message":"端口号应为1-65535之间的整数"}],"valid":false}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[ValidateConfigParams]{
+				Arguments: ValidateConfigParams{YAML: tc.yaml},
+			})
+			if err != nil {
+				t.Fatalf("handler: %v", err)
+			}
+			if result.IsError {
+				t.Fatalf("unexpected error result: %v", result.Content)
+			}
+			if got := result.Content[0].(*mcp.TextContent).Text; got != tc.want {
+				t.Errorf("result = %s, want %s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package multiplexer
+
+import (
+	"log"
+	"net/http"
+)
+
+// 预热相关常量
+const (
+	httpErrorWarmingUp = "服务器正在预热，请稍后重试"
+
+	// warmUpRetryAfter 预热期间建议客户端重试的间隔（秒）
+	warmUpRetryAfter = "1"
+)
+
+// WithWaitForReady 启用预热门控：端口先开始监听，MarkReady之前/readyz和MCP端点返回503
+func WithWaitForReady(enabled bool) ServerOption {
+	return func(s *Server) {
+		s.warmingUp.Store(enabled)
+	}
+}
+
+// MarkReady 预热完成，开始接受MCP请求
+func (s *Server) MarkReady() {
+	if s.warmingUp.CompareAndSwap(true, false) {
+		log.Printf("✓ 预热完成，开始接受请求")
+	}
+}
+
+// isWarmingUp 判断是否仍在预热
+func (s *Server) isWarmingUp() bool {
+	return s.warmingUp.Load()
+}
+
+// warmUpGate 预热期间除健康检查和指标外的请求均返回503，就绪检查由handleReadyz自行返回预热状态
+func (s *Server) warmUpGate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.isWarmingUp() && r.URL.Path != healthzPath && r.URL.Path != readyzPath && r.URL.Path != metricsPath {
+			w.Header().Set("Retry-After", warmUpRetryAfter)
+			http.Error(w, httpErrorWarmingUp, http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,52 @@
+package multiplexer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mcp-server/internal/core"
+)
+
+func TestWarmUpGateUntilMarkReady(t *testing.T) {
+	const endpoint = "/prometheus/mcp"
+	server := NewServer("0", WithWaitForReady(true))
+	server.AddService(newFakeService(core.ServiceTypePrometheus, endpoint))
+
+	gate := server.warmUpGate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == readyzPath {
+			server.handleReadyz(w, r)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		gate.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// 预热期间MCP端点返回503并建议重试，健康检查和指标不受影响
+	rec := get(endpoint)
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") != warmUpRetryAfter {
+		t.Errorf("warming up: %s status = %d, Retry-After = %q", endpoint, rec.Code, rec.Header().Get("Retry-After"))
+	}
+	for _, path := range []string{healthzPath, metricsPath} {
+		if rec := get(path); rec.Code != http.StatusOK {
+			t.Errorf("warming up: %s status = %d, want 200", path, rec.Code)
+		}
+	}
+	if rec := get(readyzPath); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("warming up: %s status = %d, want 503", readyzPath, rec.Code)
+	}
+
+	server.MarkReady()
+	server.MarkReady() // 重复调用无副作用
+
+	if rec := get(endpoint); rec.Code != http.StatusOK {
+		t.Errorf("ready: %s status = %d, want 200", endpoint, rec.Code)
+	}
+	if rec := get(readyzPath); rec.Code != http.StatusOK {
+		t.Errorf("ready: %s status = %d, body = %q", readyzPath, rec.Code, rec.Body.String())
+	}
+}
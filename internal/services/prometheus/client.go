@@ -4,30 +4,100 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
 	"time"
 
+	"mcp-server/internal/common"
+	"mcp-server/internal/core"
+
 	"github.com/prometheus/client_golang/api"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 )
 
 // 常量定义
 const (
 	defaultConnectionTimeout = 5 * time.Second
-	logPrefixQuery           = "Prometheus查询警告 [query=%s]: %v"
-	logPrefixRangeQuery      = "Prometheus范围查询警告 [query=%s]: %v"
+
+	// defaultLabelLookback 查询指标名称和标签值时默认回溯的时间窗口
+	defaultLabelLookback = time.Hour
+
+	// HTTP传输层配置
+	defaultMaxConnsPerHost = 50
+	dialTimeout            = 30 * time.Second
+	dialKeepAlive          = 30 * time.Second
+	tlsHandshakeTimeout    = 10 * time.Second
+	logPrefixQuery         = "Prometheus查询警告 [query=%s]: %v"
+	logPrefixRangeQuery    = "Prometheus范围查询警告 [query=%s]: %v"
+	logPrefixSeries        = "Prometheus序列查询警告 [match=%v]: %v"
+
+	// rawAPIPrefix 原始API透传允许访问的路径前缀
+	rawAPIPrefix = "/api/v1/"
+
+	// reloadPath 配置重载的生命周期接口
+	reloadPath = "/-/reload"
 )
 
 // Client Prometheus客户端
 type Client struct {
 	client v1.API
+	api    api.Client // 底层HTTP客户端，用于原始API透传
+
+	// 重试配置
+	retryAttempts int
+	retryBackoff  time.Duration
+
+	// cache 即时查询结果缓存，未启用时为nil
+	cache *queryCache
+
+	// defaultMatchers 注入到所有查询选择器中的默认匹配器
+	defaultMatchers []*labels.Matcher
+}
+
+// ClientOptions Prometheus客户端可选配置
+type ClientOptions struct {
+	MaxConnsPerHost int           // 到Prometheus的最大并发连接数，0使用默认值
+	RetryAttempts   int           // 查询最大尝试次数，0使用默认值，1表示不重试
+	RetryBackoff    time.Duration // 重试初始退避时间，每次翻倍，0使用默认值
+
+	// 认证配置，Basic认证与Bearer令牌二选一
+	Username    string
+	Password    string
+	BearerToken string
+
+	// FallbackURL 备用地址，主地址连接失败时（非查询错误）改用该地址，为空表示不启用
+	FallbackURL string
+
+	// CacheTTL 即时查询结果缓存的有效期，0表示不启用缓存
+	CacheTTL time.Duration
+
+	// DefaultMatchers 注入到所有查询选择器中的标签等值匹配，查询已指定同名标签时不覆盖
+	DefaultMatchers map[string]string
+
+	TLS common.TLSOptions
 }
 
 // NewClient 创建新的Prometheus客户端
-func NewClient(serverURL string) (*Client, error) {
+func NewClient(serverURL string, opts ClientOptions) (*Client, error) {
+	transport, err := newTransport(opts)
+	if err != nil {
+		return nil, fmt.Errorf("创建prometheus传输层失败: %w", err)
+	}
+
+	address := normalizeBaseURL(serverURL)
+	roundTripper, err := withFallback(withAuth(withGzip(common.InstrumentTransport(string(core.ServiceTypePrometheus), transport)), opts), address, opts.FallbackURL)
+	if err != nil {
+		return nil, fmt.Errorf("配置备用地址失败: %w", err)
+	}
+
 	config := api.Config{
-		Address:      serverURL,
-		RoundTripper: api.DefaultRoundTripper,
+		Address:      address,
+		RoundTripper: roundTripper,
 	}
 
 	client, err := api.NewClient(config)
@@ -35,13 +105,111 @@ func NewClient(serverURL string) (*Client, error) {
 		return nil, fmt.Errorf("创建prometheus客户端失败: %w", err)
 	}
 
+	defaultMatchers, err := newDefaultMatchers(opts.DefaultMatchers)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.RetryAttempts <= 0 {
+		opts.RetryAttempts = defaultRetryAttempts
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaultRetryBackoff
+	}
+
 	v1api := v1.NewAPI(client)
-	return &Client{client: v1api}, nil
+	return &Client{
+		client:          v1api,
+		api:             client,
+		retryAttempts:   opts.RetryAttempts,
+		retryBackoff:    opts.RetryBackoff,
+		cache:           newQueryCache(opts.CacheTTL),
+		defaultMatchers: defaultMatchers,
+	}, nil
+}
+
+// newTransport 创建HTTP传输层，在默认传输层配置基础上限制单主机连接数并应用TLS配置
+func newTransport(opts ClientOptions) (*http.Transport, error) {
+	maxConns := opts.MaxConnsPerHost
+	if maxConns <= 0 {
+		maxConns = defaultMaxConnsPerHost
+	}
+
+	tlsConfig, err := common.BuildTLSConfig("Prometheus", opts.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   dialTimeout,
+			KeepAlive: dialKeepAlive,
+		}).DialContext,
+		TLSHandshakeTimeout: tlsHandshakeTimeout,
+		TLSClientConfig:     tlsConfig,
+		MaxConnsPerHost:     maxConns,
+	}, nil
+}
+
+// authRoundTripper 为每个请求注入认证请求头
+type authRoundTripper struct {
+	next        http.RoundTripper
+	username    string
+	password    string
+	bearerToken string
+}
+
+// RoundTrip 实现http.RoundTripper接口
+func (t *authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	// RoundTripper不应修改原始请求
+	req = req.Clone(req.Context())
+	if t.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+t.bearerToken)
+	} else {
+		req.SetBasicAuth(t.username, t.password)
+	}
+	return t.next.RoundTrip(req)
+}
+
+// withAuth 按配置包装认证RoundTripper，未配置认证时原样返回
+func withAuth(next http.RoundTripper, opts ClientOptions) http.RoundTripper {
+	if opts.BearerToken == "" && opts.Username == "" {
+		return next
+	}
+	return &authRoundTripper{
+		next:        next,
+		username:    opts.Username,
+		password:    opts.Password,
+		bearerToken: opts.BearerToken,
+	}
+}
+
+// normalizeBaseURL 去除基础URL末尾的斜杠，避免拼接出 //api/v1 形式的路径
+func normalizeBaseURL(serverURL string) string {
+	return strings.TrimRight(strings.TrimSpace(serverURL), "/")
 }
 
 // QueryInstant 执行即时查询
 func (c *Client) QueryInstant(ctx context.Context, query string) (model.Value, error) {
-	result, warnings, err := c.client.Query(ctx, query, time.Now())
+	query, err := injectMatchers(query, c.defaultMatchers)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if c.cache != nil {
+		if result, ok := c.cache.get(query, now); ok {
+			return result, nil
+		}
+	}
+
+	var result model.Value
+	var warnings v1.Warnings
+	err = c.withRetry(ctx, query, func() (err error) {
+		result, warnings, err = c.client.Query(ctx, query, now)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("查询失败: %w", err)
 	}
@@ -50,18 +218,33 @@ func (c *Client) QueryInstant(ctx context.Context, query string) (model.Value, e
 		log.Printf(logPrefixQuery, query, warnings)
 	}
 
+	// 失败及带警告的结果不缓存
+	if c.cache != nil && len(warnings) == 0 {
+		c.cache.put(query, now, result)
+	}
+
 	return result, nil
 }
 
 // QueryRange 执行范围查询
 func (c *Client) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (model.Value, error) {
+	query, err := injectMatchers(query, c.defaultMatchers)
+	if err != nil {
+		return nil, err
+	}
+
 	r := v1.Range{
 		Start: start,
 		End:   end,
 		Step:  step,
 	}
 
-	result, warnings, err := c.client.QueryRange(ctx, query, r)
+	var result model.Value
+	var warnings v1.Warnings
+	err = c.withRetry(ctx, query, func() (err error) {
+		result, warnings, err = c.client.QueryRange(ctx, query, r)
+		return err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("范围查询失败: %w", err)
 	}
@@ -82,6 +265,95 @@ func (c *Client) GetTargets(ctx context.Context) (v1.TargetsResult, error) {
 	return targets, nil
 }
 
+// GetAlerts 获取当前活跃的告警
+func (c *Client) GetAlerts(ctx context.Context) (v1.AlertsResult, error) {
+	alerts, err := c.client.Alerts(ctx)
+	if err != nil {
+		return v1.AlertsResult{}, fmt.Errorf("获取告警失败: %w", err)
+	}
+	return alerts, nil
+}
+
+// GetRules 获取告警规则和记录规则
+func (c *Client) GetRules(ctx context.Context) (v1.RulesResult, error) {
+	rules, err := c.client.Rules(ctx)
+	if err != nil {
+		return v1.RulesResult{}, fmt.Errorf("获取规则失败: %w", err)
+	}
+	return rules, nil
+}
+
+// GetSeries 根据序列选择器查找时间范围内的序列
+func (c *Client) GetSeries(ctx context.Context, matches []string, start, end time.Time) ([]model.LabelSet, error) {
+	matches, err := c.scopeSelectors(matches)
+	if err != nil {
+		return nil, err
+	}
+
+	series, warnings, err := c.client.Series(ctx, matches, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("获取序列失败: %w", err)
+	}
+
+	if len(warnings) > 0 {
+		log.Printf(logPrefixSeries, matches, warnings)
+	}
+
+	return series, nil
+}
+
+// scopeSelectors 为序列选择器注入默认匹配器，未指定选择器时使用仅包含默认匹配器的选择器
+func (c *Client) scopeSelectors(matches []string) ([]string, error) {
+	if len(c.defaultMatchers) == 0 {
+		return matches, nil
+	}
+	if len(matches) == 0 {
+		return []string{matchersSelector(c.defaultMatchers)}, nil
+	}
+
+	scoped := make([]string, 0, len(matches))
+	for _, match := range matches {
+		selector, err := injectMatchers(match, c.defaultMatchers)
+		if err != nil {
+			return nil, err
+		}
+		scoped = append(scoped, selector)
+	}
+	return scoped, nil
+}
+
+// QueryExemplars 查询时间范围内匹配PromQL的exemplar
+func (c *Client) QueryExemplars(ctx context.Context, query string, start, end time.Time) ([]v1.ExemplarQueryResult, error) {
+	query, err := injectMatchers(query, c.defaultMatchers)
+	if err != nil {
+		return nil, err
+	}
+
+	exemplars, err := c.client.QueryExemplars(ctx, query, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("查询exemplar失败: %w", err)
+	}
+	return exemplars, nil
+}
+
+// GetMetricMetadata 获取指标元数据，metric为空时返回所有指标
+func (c *Client) GetMetricMetadata(ctx context.Context, metric string) (map[string][]v1.Metadata, error) {
+	metadata, err := c.client.Metadata(ctx, metric, "")
+	if err != nil {
+		return nil, fmt.Errorf("获取指标元数据失败: %w", err)
+	}
+	return metadata, nil
+}
+
+// GetTSDBStats 获取TSDB头块统计和基数信息
+func (c *Client) GetTSDBStats(ctx context.Context) (v1.TSDBResult, error) {
+	stats, err := c.client.TSDB(ctx)
+	if err != nil {
+		return v1.TSDBResult{}, fmt.Errorf("获取TSDB统计失败: %w", err)
+	}
+	return stats, nil
+}
+
 // TestConnection 测试连接
 func (c *Client) TestConnection(ctx context.Context) error {
 	testCtx, cancel := context.WithTimeout(ctx, defaultConnectionTimeout)
@@ -91,26 +363,109 @@ func (c *Client) TestConnection(ctx context.Context) error {
 	return err
 }
 
-// GetMetricNames 获取指标名称列表
-func (c *Client) GetMetricNames(ctx context.Context) ([]string, error) {
-	names, _, err := c.client.LabelValues(ctx, "__name__", nil, time.Now().Add(-time.Hour), time.Now())
+// GetMetricNames 获取回溯窗口内出现过的指标名称列表，lookback<=0时使用默认的1小时
+func (c *Client) GetMetricNames(ctx context.Context, lookback time.Duration) ([]string, error) {
+	names, err := c.GetLabelValues(ctx, model.MetricNameLabel, nil, lookback)
 	if err != nil {
 		return nil, fmt.Errorf("获取指标名称失败: %w", err)
 	}
+	return names, nil
+}
 
-	result := make([]string, 0, len(names))
-	for _, name := range names {
-		result = append(result, string(name))
+// GetLabelValues 获取回溯窗口内指定标签的取值列表，可通过序列选择器过滤，lookback<=0时使用默认的1小时
+func (c *Client) GetLabelValues(ctx context.Context, label string, matches []string, lookback time.Duration) ([]string, error) {
+	if lookback <= 0 {
+		lookback = defaultLabelLookback
+	}
+	matches, err := c.scopeSelectors(matches)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	values, _, err := c.client.LabelValues(ctx, label, matches, end.Add(-lookback), end)
+	if err != nil {
+		return nil, fmt.Errorf("获取标签值失败: %w", err)
+	}
+
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		result = append(result, string(value))
 	}
 
 	return result, nil
 }
 
+// labelSetToMap 将LabelSet转换为普通映射
+func labelSetToMap(labels model.LabelSet) map[string]string {
+	result := make(map[string]string, len(labels))
+	for name, value := range labels {
+		result[string(name)] = string(value)
+	}
+	return result
+}
+
 // MetricQueries 预定义的指标查询
 var MetricQueries = map[string]string{
 	"cpu":     `100 - (avg by (instance) (irate(node_cpu_seconds_total{mode="idle"}[5m])) * 100)`,
 	"memory":  "(1 - (node_memory_MemAvailable_bytes / node_memory_MemTotal_bytes)) * 100",
-	"disk":    "(1 - (node_filesystem_avail_bytes{mountpoint=\"/\"} / node_filesystem_size_bytes{mountpoint=\"/\"})) * 100",
+	"disk":    "(1 - (node_filesystem_avail_bytes{mountpoint=" + mountpointPlaceholder + "} / node_filesystem_size_bytes{mountpoint=" + mountpointPlaceholder + "})) * 100",
 	"network": "rate(node_network_receive_bytes_total[5m])",
 	"up":      "up",
 }
+
+// RawGet 以GET方式透传请求到/api/v1/下的任意路径，返回原始响应体
+func (c *Client) RawGet(ctx context.Context, apiPath string, params map[string]string) ([]byte, error) {
+	cleaned := path.Clean("/" + strings.TrimSpace(apiPath))
+	if !strings.HasPrefix(cleaned, rawAPIPrefix) {
+		return nil, fmt.Errorf("路径必须以 %s 开头: %s", rawAPIPrefix, apiPath)
+	}
+	// 透传的参数无法可靠地改写，配置默认匹配器时拒绝请求以免绕过查询范围限制
+	if len(c.defaultMatchers) > 0 {
+		return nil, fmt.Errorf("已配置default_matchers，不支持透传原始API请求")
+	}
+
+	u := c.api.URL(cleaned, nil)
+	query := url.Values{}
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	resp, body, err := c.api.Do(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("API请求失败，状态码: %d, 响应: %s", resp.StatusCode, string(body))
+	}
+
+	return body, nil
+}
+
+// ReloadConfig 触发Prometheus重新加载配置，需Prometheus以--web.enable-lifecycle启动
+func (c *Client) ReloadConfig(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.api.URL(reloadPath, nil).String(), nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	resp, body, err := c.api.Do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("Prometheus未开启生命周期接口，请使用--web.enable-lifecycle参数启动后重试: %s", strings.TrimSpace(string(body)))
+	case resp.StatusCode/100 != 2:
+		return fmt.Errorf("配置重载失败，状态码: %d, 响应: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
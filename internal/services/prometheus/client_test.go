@@ -0,0 +1,234 @@
+package prometheus
+
+import (
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newTestClient 创建指向测试服务器的客户端
+func newTestClient(t *testing.T, handler http.Handler, opts ClientOptions) *Client {
+	t.Helper()
+	upstream := httptest.NewServer(handler)
+	t.Cleanup(upstream.Close)
+
+	client, err := NewClient(upstream.URL, opts)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+func TestGetMetricNamesScopedByDefaultMatchers(t *testing.T) {
+	var matches []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		matches = r.Form["match[]"]
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":["up"]}`))
+	}), ClientOptions{DefaultMatchers: map[string]string{"cluster": "prod"}})
+
+	names, err := client.GetMetricNames(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("GetMetricNames: %v", err)
+	}
+	if !slices.Equal(names, []string{"up"}) {
+		t.Errorf("names = %v, want [up]", names)
+	}
+	if want := []string{`{cluster="prod"}`}; !slices.Equal(matches, want) {
+		t.Errorf("match[] = %v, want %v", matches, want)
+	}
+}
+
+func TestRawGetRefusedWithDefaultMatchers(t *testing.T) {
+	called := false
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}), ClientOptions{DefaultMatchers: map[string]string{"cluster": "prod"}})
+
+	if _, err := client.RawGet(context.Background(), "/api/v1/query", map[string]string{"query": "up"}); err == nil {
+		t.Fatal("RawGet succeeded, want error")
+	}
+	if called {
+		t.Error("RawGet reached upstream")
+	}
+}
+
+func TestBaseURLTrailingSlash(t *testing.T) {
+	var paths []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}))
+	defer upstream.Close()
+
+	for _, serverURL := range []string{upstream.URL, upstream.URL + "/", upstream.URL + "//"} {
+		client, err := NewClient(serverURL, ClientOptions{})
+		if err != nil {
+			t.Fatalf("NewClient(%q): %v", serverURL, err)
+		}
+		if _, err := client.QueryInstant(context.Background(), "up"); err != nil {
+			t.Fatalf("QueryInstant via %q: %v", serverURL, err)
+		}
+	}
+
+	if want := []string{"/api/v1/query", "/api/v1/query", "/api/v1/query"}; !slices.Equal(paths, want) {
+		t.Errorf("request paths = %v, want %v", paths, want)
+	}
+}
+
+func TestReloadConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		status  int
+		wantErr string
+	}{
+		{name: "success", status: http.StatusOK},
+		{name: "lifecycle disabled", status: http.StatusForbidden, wantErr: "--web.enable-lifecycle"},
+		{name: "reload failed", status: http.StatusInternalServerError, wantErr: "状态码: 500"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var method, path string
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				method, path = r.Method, r.URL.Path
+				w.WriteHeader(tc.status)
+			}), ClientOptions{})
+
+			err := client.ReloadConfig(context.Background())
+			if method != http.MethodPost || path != reloadPath {
+				t.Errorf("request = %s %s, want POST %s", method, path, reloadPath)
+			}
+			switch {
+			case tc.wantErr == "" && err != nil:
+				t.Errorf("ReloadConfig: %v", err)
+			case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+				t.Errorf("ReloadConfig() error = %v, want containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestTransportMaxConnsPerHost(t *testing.T) {
+	for _, tc := range []struct {
+		configured int
+		want       int
+	}{
+		{configured: 7, want: 7},
+		{configured: 0, want: defaultMaxConnsPerHost},
+	} {
+		transport, err := newTransport(ClientOptions{MaxConnsPerHost: tc.configured})
+		if err != nil {
+			t.Fatalf("newTransport: %v", err)
+		}
+		if transport.MaxConnsPerHost != tc.want {
+			t.Errorf("MaxConnsPerHost(configured %d) = %d, want %d", tc.configured, transport.MaxConnsPerHost, tc.want)
+		}
+	}
+}
+
+func TestFallbackServesWhenPrimaryUnreachable(t *testing.T) {
+	// 关闭的测试服务器地址用作连接被拒绝的主地址
+	dead := httptest.NewServer(http.NotFoundHandler())
+	primaryURL := dead.URL + "/prom"
+	dead.Close()
+
+	var paths []string
+	var queries []string
+	fallback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		paths = append(paths, r.URL.Path)
+		queries = append(queries, r.Form.Get("query"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"node"},"value":[1700000000,"1"]}]}}`))
+	}))
+	defer fallback.Close()
+
+	client, err := NewClient(primaryURL, ClientOptions{FallbackURL: fallback.URL + "/backup"})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	value, err := client.QueryInstant(context.Background(), "up")
+	if err != nil {
+		t.Fatalf("QueryInstant: %v", err)
+	}
+	if !strings.Contains(value.String(), `job="node"`) {
+		t.Errorf("value = %s, want the fallback sample", value)
+	}
+	// 请求路径从主地址前缀改写为备用地址前缀，请求体完整重放
+	if want := []string{"/backup/api/v1/query"}; !slices.Equal(paths, want) {
+		t.Errorf("fallback paths = %v, want %v", paths, want)
+	}
+	if want := []string{"up"}; !slices.Equal(queries, want) {
+		t.Errorf("fallback queries = %v, want %v", queries, want)
+	}
+}
+
+func TestGzipEncodedResponseDecoded(t *testing.T) {
+	var acceptEncoding string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		_, _ = gz.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"node"},"value":[1700000000,"1"]}]}}`))
+		if err := gz.Close(); err != nil {
+			t.Errorf("gzip close: %v", err)
+		}
+	}), ClientOptions{})
+
+	value, err := client.QueryInstant(context.Background(), "up")
+	if err != nil {
+		t.Fatalf("QueryInstant: %v", err)
+	}
+	if !strings.Contains(value.String(), `job="node"`) {
+		t.Errorf("value = %s, want the decoded sample", value)
+	}
+	if acceptEncoding != "gzip" {
+		t.Errorf("Accept-Encoding = %q, want gzip", acceptEncoding)
+	}
+}
+
+func TestRawGetStatusConfig(t *testing.T) {
+	const body = `{"status":"success","data":{"yaml":"global:\n  scrape_interval: 15s\n"}}`
+	var requests []*http.Request
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}), ClientOptions{})
+
+	got, err := client.RawGet(context.Background(), "/api/v1/status/config", map[string]string{"format": "yaml"})
+	if err != nil {
+		t.Fatalf("RawGet: %v", err)
+	}
+	if string(got) != body {
+		t.Errorf("body = %q, want the upstream body unchanged", got)
+	}
+	if len(requests) != 1 {
+		t.Fatalf("upstream requests = %d, want 1", len(requests))
+	}
+	req := requests[0]
+	if req.Method != http.MethodGet || req.URL.Path != "/api/v1/status/config" || req.URL.Query().Get("format") != "yaml" {
+		t.Errorf("request = %s %s, want GET /api/v1/status/config?format=yaml", req.Method, req.URL)
+	}
+
+	// 跳出/api/v1/的路径在请求上游前被拒绝
+	for _, apiPath := range []string{"/api/v1/../../-/reload", "../api/v2/status", "/federate", "/api/v1x/status"} {
+		if _, err := client.RawGet(context.Background(), apiPath, nil); err == nil {
+			t.Errorf("RawGet(%q) succeeded, want error", apiPath)
+		}
+	}
+	if len(requests) != 1 {
+		t.Errorf("rejected paths reached upstream: %d requests", len(requests))
+	}
+}
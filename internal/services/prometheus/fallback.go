@@ -0,0 +1,83 @@
+package prometheus
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// fallbackRoundTripper 主地址连接失败时改用备用地址重发请求
+// 只处理传输层错误（连接被拒绝、DNS解析失败、TLS握手失败等），
+// Prometheus返回的HTTP响应（包括PromQL错误和5xx）原样返回，不切换地址
+type fallbackRoundTripper struct {
+	next     http.RoundTripper
+	primary  *url.URL
+	fallback *url.URL
+}
+
+// withFallback 配置了备用地址时包装fallbackRoundTripper，否则原样返回
+func withFallback(next http.RoundTripper, primaryURL, fallbackURL string) (http.RoundTripper, error) {
+	if fallbackURL == "" {
+		return next, nil
+	}
+
+	primary, err := url.Parse(primaryURL)
+	if err != nil {
+		return nil, fmt.Errorf("解析主地址失败: %w", err)
+	}
+	fallback, err := url.Parse(normalizeBaseURL(fallbackURL))
+	if err != nil {
+		return nil, fmt.Errorf("解析备用地址失败: %w", err)
+	}
+	if fallback.Scheme == "" || fallback.Host == "" {
+		return nil, fmt.Errorf("备用地址需包含协议和主机: %s", fallbackURL)
+	}
+
+	return &fallbackRoundTripper{next: next, primary: primary, fallback: fallback}, nil
+}
+
+// RoundTrip 实现http.RoundTripper接口
+func (t *fallbackRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err == nil || req.Context().Err() != nil {
+		return resp, err
+	}
+
+	fallbackReq, rewindErr := t.rewrite(req)
+	if rewindErr != nil {
+		return nil, err
+	}
+
+	log.Printf("Prometheus主地址连接失败，改用备用地址 %s: %v", t.fallback.Host, err)
+	resp, fallbackErr := t.next.RoundTrip(fallbackReq)
+	if fallbackErr != nil {
+		return nil, fmt.Errorf("主地址: %v; 备用地址: %w", err, fallbackErr)
+	}
+	return resp, nil
+}
+
+// rewrite 将请求地址从主地址替换为备用地址，并重放请求体
+func (t *fallbackRoundTripper) rewrite(req *http.Request) (*http.Request, error) {
+	clone := req.Clone(req.Context())
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return nil, fmt.Errorf("请求体不可重放")
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		clone.Body = body
+	}
+
+	target := *req.URL
+	target.Scheme = t.fallback.Scheme
+	target.Host = t.fallback.Host
+	target.Path = t.fallback.Path + strings.TrimPrefix(req.URL.Path, t.primary.Path)
+	target.RawPath = ""
+	clone.URL = &target
+	clone.Host = ""
+	return clone, nil
+}
@@ -0,0 +1,118 @@
+package prometheus
+
+import (
+	"fmt"
+	"sort"
+
+	"mcp-server/internal/common"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/prometheus/common/model"
+)
+
+// 查询结果输出格式，与Superset工具使用相同的format参数
+const (
+	formatJSON     = "json"
+	formatMarkdown = common.FormatMarkdown
+)
+
+// validateFormat 校验输出格式，为空表示默认的JSON
+func validateFormat(format string) error {
+	switch format {
+	case "", formatJSON, formatMarkdown:
+		return nil
+	}
+	return fmt.Errorf("不支持的输出格式: %s，可选值为 json 或 markdown", format)
+}
+
+// renderQueryResult 按指定格式生成查询结果响应，raw为true时JSON输出保持Prometheus原始格式
+func renderQueryResult(value model.Value, format string, raw bool, opts *toolOptions) (*mcp.CallToolResultFor[any], error) {
+	if format == formatMarkdown {
+		columns, rows := tabulateValue(renameMetrics(value, opts.metricRename))
+		return common.CreateSimpleSuccessResponse(common.FormatMarkdownTable(columns, rows))
+	}
+	if raw {
+		return common.CreateSuccessResponse(rawResult(value, opts))
+	}
+	return common.CreateSuccessResponse(transformResult(value, opts))
+}
+
+// tabulateValue 将查询结果展开为表格，每个样本一行，列为所有标签、时间和值
+func tabulateValue(value model.Value) ([]string, [][]string) {
+	switch v := value.(type) {
+	case model.Vector:
+		metrics := make([]model.Metric, 0, len(v))
+		for _, sample := range v {
+			metrics = append(metrics, sample.Metric)
+		}
+		labels := labelColumns(metrics)
+
+		rows := make([][]string, 0, len(v))
+		for _, sample := range v {
+			rows = append(rows, sampleRow(labels, sample.Metric, sample.Timestamp, sampleText(sample.Value, sample.Histogram)))
+		}
+		return append(labels, "timestamp", "value"), rows
+	case model.Matrix:
+		metrics := make([]model.Metric, 0, len(v))
+		for _, stream := range v {
+			metrics = append(metrics, stream.Metric)
+		}
+		labels := labelColumns(metrics)
+
+		var rows [][]string
+		for _, stream := range v {
+			for _, pair := range stream.Values {
+				rows = append(rows, sampleRow(labels, stream.Metric, pair.Timestamp, pair.Value.String()))
+			}
+			for _, pair := range stream.Histograms {
+				rows = append(rows, sampleRow(labels, stream.Metric, pair.Timestamp, pair.Histogram.String()))
+			}
+		}
+		return append(labels, "timestamp", "value"), rows
+	case *model.Scalar:
+		return []string{"timestamp", "value"}, [][]string{{v.Timestamp.String(), v.Value.String()}}
+	case *model.String:
+		return []string{"timestamp", "value"}, [][]string{{v.Timestamp.String(), v.Value}}
+	default:
+		return []string{"value"}, [][]string{{fmt.Sprint(value)}}
+	}
+}
+
+// labelColumns 收集所有序列出现过的标签名，__name__在前，其余按字母排序
+func labelColumns(metrics []model.Metric) []string {
+	seen := make(map[model.LabelName]bool)
+	for _, metric := range metrics {
+		for name := range metric {
+			seen[name] = true
+		}
+	}
+
+	columns := make([]string, 0, len(seen))
+	for name := range seen {
+		if name != model.MetricNameLabel {
+			columns = append(columns, string(name))
+		}
+	}
+	sort.Strings(columns)
+	if seen[model.MetricNameLabel] {
+		columns = append([]string{model.MetricNameLabel}, columns...)
+	}
+	return columns
+}
+
+// sampleRow 构建单个样本的表格行，缺失的标签输出为空
+func sampleRow(labels []string, metric model.Metric, timestamp model.Time, value string) []string {
+	row := make([]string, 0, len(labels)+2)
+	for _, name := range labels {
+		row = append(row, string(metric[model.LabelName(name)]))
+	}
+	return append(row, timestamp.String(), value)
+}
+
+// sampleText 样本值的文本表示，原生直方图样本输出直方图摘要
+func sampleText(value model.SampleValue, histogram *model.SampleHistogram) string {
+	if histogram != nil {
+		return histogram.String()
+	}
+	return value.String()
+}
@@ -0,0 +1,68 @@
+package prometheus
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// gzipRoundTripper 显式协商gzip并自行解压响应
+// http.Transport只在自己添加Accept-Encoding时才自动解压，代理强制返回gzip或改写请求头时会绕过自动解压，
+// 导致prometheus客户端库解析JSON失败；由这一层统一处理，不依赖上游和传输层的行为
+type gzipRoundTripper struct {
+	next http.RoundTripper
+}
+
+// withGzip 包装gzipRoundTripper
+func withGzip(next http.RoundTripper) http.RoundTripper {
+	return &gzipRoundTripper{next: next}
+}
+
+// RoundTrip 实现http.RoundTripper接口
+func (t *gzipRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Accept-Encoding") == "" {
+		// RoundTripper不应修改原始请求
+		req = req.Clone(req.Context())
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(strings.TrimSpace(resp.Header.Get("Content-Encoding")), "gzip") {
+		return resp, nil
+	}
+
+	resp.Body = &gzipBody{body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+// gzipBody 首次读取时才创建gzip读取器，避免空响应体（如HEAD请求）在创建时报错
+type gzipBody struct {
+	body   io.ReadCloser
+	reader *gzip.Reader
+	err    error
+}
+
+// Read 实现io.Reader接口
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.reader == nil && b.err == nil {
+		b.reader, b.err = gzip.NewReader(b.body)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.reader.Read(p)
+}
+
+// Close 实现io.Closer接口
+func (b *gzipBody) Close() error {
+	return b.body.Close()
+}
@@ -2,30 +2,86 @@ package prometheus
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"mcp-server/internal/common"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
 )
 
-// 常量定义
+// 常量定义（配置未指定时的默认值）
 const (
-	defaultQueryTimeout = 10 * time.Second
-	rangeQueryTimeout   = 30 * time.Second
-	listMetricsTimeout  = 15 * time.Second
+	defaultQueryTimeout       = 10 * time.Second
+	defaultRangeQueryTimeout  = 30 * time.Second
+	defaultListMetricsTimeout = 15 * time.Second
+
+	// 自动计算step时的目标数据点数
+	autoStepTargetPoints = 250
+
+	// Prometheus范围查询单条序列允许的最大数据点数
+	maxRangePoints = 11000
+
+	// stepAuto 按时间范围自动计算step
+	stepAuto = "auto"
+
+	// 序列查询最多返回的序列数，避免响应过大
+	maxSeriesResults = 500
+
+	// exemplar查询最多返回的exemplar数，避免响应过大
+	maxExemplarResults = 500
+
+	// 未指定指标时默认返回的元数据条数
+	defaultMetadataLimit = 200
+
+	// 标签值默认最多返回的条数
+	defaultLabelValuesLimit = 1000
+
+	// 批量查询常用指标时的最大并发数
+	maxCommonMetricsConcurrency = 4
 )
 
+// toolOptions 工具处理器配置
+type toolOptions struct {
+	queryTimeout       time.Duration
+	rangeQueryTimeout  time.Duration
+	listMetricsTimeout time.Duration
+	metadataLimit      int
+	labelValuesLimit   int                 // 标签值最多返回的条数
+	highCardinality    map[string]struct{} // 必须提供match才能查询取值的高基数标签
+	maxRangeWindow     time.Duration       // 0表示不限制
+	enableRawAPI       bool
+	enableAdminAPI     bool
+	metricQueries      map[string]string // 生效的常用指标查询
+	diskMountpoint     string            // disk查询默认的挂载点
+	metricRename       map[string]string // 输出中__name__的展示名称映射
+	nanMode            string            // NaN/Inf的输出方式
+	labelLookback      time.Duration     // 指标名称和标签值列表的默认回溯窗口
+	defaultStep        time.Duration     // 范围查询未指定step时的步长，0表示自动计算
+}
+
 // 工具参数结构体
 type QueryParams struct {
-	Query string `json:"query" jsonschema:"PromQL查询语句"`
+	Query       string   `json:"query" jsonschema:"PromQL查询语句"`
+	GroupBy     []string `json:"group_by,omitempty" jsonschema:"按标签对结果进行客户端聚合，可选 (例如: [\"pod\"])"`
+	Aggregation string   `json:"aggregation,omitempty" jsonschema:"聚合函数 (sum, avg, max, min)，默认sum，仅在指定group_by时生效"`
+	Format      string   `json:"format,omitempty" jsonschema:"输出格式，json(默认) 或 markdown"`
+	ScalarOnly  bool     `json:"scalar_only,omitempty" jsonschema:"结果只有一个样本时只返回 {value, timestamp}，多条序列时报错，适用于count(up)这类查询"`
+	Raw         bool     `json:"raw,omitempty" jsonschema:"返回Prometheus原始JSON格式（时间戳为秒、值为字符串），默认返回规范化结构"`
 }
 
 type QueryRangeParams struct {
 	Query     string `json:"query" jsonschema:"PromQL查询语句"`
 	StartTime string `json:"start_time" jsonschema:"开始时间 (RFC3339格式, 例如: 2024-01-01T00:00:00Z)"`
 	EndTime   string `json:"end_time" jsonschema:"结束时间 (RFC3339格式, 例如: 2024-01-01T23:59:59Z)"`
-	Step      string `json:"step" jsonschema:"步长持续时间 (例如: 1m, 5m, 1h)"`
+	Step      string `json:"step,omitempty" jsonschema:"步长持续时间，可选，auto表示按时间范围自动计算（约250个点），为空时使用配置的default_step，未配置时自动计算 (例如: 1m, 5m, 1h, auto)"`
+	Format    string `json:"format,omitempty" jsonschema:"输出格式，json(默认) 或 markdown"`
+	Raw       bool   `json:"raw,omitempty" jsonschema:"返回Prometheus原始JSON格式（时间戳为秒、值为字符串），默认返回规范化结构"`
 }
 
 type TargetsParams struct{}
@@ -33,78 +89,278 @@ type TargetsParams struct{}
 type StatusParams struct{}
 
 type CommonMetricsParams struct {
-	MetricType string `json:"metric_type" jsonschema:"指标类型 (cpu, memory, disk, network, up)"`
+	MetricType string `json:"metric_type" jsonschema:"指标类型，内置cpu, memory, disk, network, up，以及配置的自定义类型，可通过prometheus_list_common_metrics查看"`
+	Mountpoint string `json:"mountpoint,omitempty" jsonschema:"磁盘挂载点，可选，仅disk类型生效，默认使用配置的disk_mountpoint (例如: /data)"`
+	Format     string `json:"format,omitempty" jsonschema:"输出格式，json(默认) 或 markdown"`
+	Raw        bool   `json:"raw,omitempty" jsonschema:"返回Prometheus原始JSON格式（时间戳为秒、值为字符串），默认返回规范化结构"`
+}
+
+type CommonMetricsRangeParams struct {
+	MetricType string `json:"metric_type" jsonschema:"指标类型，与prometheus_common_metrics相同，可通过prometheus_list_common_metrics查看"`
+	StartTime  string `json:"start_time" jsonschema:"开始时间 (RFC3339格式, 例如: 2024-01-01T00:00:00Z)"`
+	EndTime    string `json:"end_time" jsonschema:"结束时间 (RFC3339格式, 例如: 2024-01-01T23:59:59Z)"`
+	Step       string `json:"step,omitempty" jsonschema:"步长持续时间，可选，auto表示按时间范围自动计算（约250个点），为空时使用配置的default_step，未配置时自动计算 (例如: 1m, 5m, 1h, auto)"`
+	Mountpoint string `json:"mountpoint,omitempty" jsonschema:"磁盘挂载点，可选，仅disk类型生效，默认使用配置的disk_mountpoint (例如: /data)"`
+	Format     string `json:"format,omitempty" jsonschema:"输出格式，json(默认) 或 markdown"`
+	Raw        bool   `json:"raw,omitempty" jsonschema:"返回Prometheus原始JSON格式（时间戳为秒、值为字符串），默认返回规范化结构"`
+}
+
+type CommonMetricsAllParams struct {
+	Mountpoint string `json:"mountpoint,omitempty" jsonschema:"磁盘挂载点，可选，仅影响disk类型，默认使用配置的disk_mountpoint"`
+}
+
+type ListMetricsParams struct {
+	Prefix   string `json:"prefix,omitempty" jsonschema:"指标名称前缀，可选，只返回以此开头的指标 (例如: node_)"`
+	Limit    int    `json:"limit,omitempty" jsonschema:"本页最多返回的指标数，可选，0表示不限制"`
+	Offset   int    `json:"offset,omitempty" jsonschema:"跳过的指标数，可选，配合limit翻页"`
+	Lookback string `json:"lookback,omitempty" jsonschema:"回溯时间窗口，可选，只返回该窗口内出现过的指标，默认使用配置的label_lookback (例如: 24h, 7d)"`
 }
 
-type ListMetricsParams struct{}
+type SnapshotMetricsParams struct {
+	Name     string `json:"name" jsonschema:"快照名称，同名快照会被覆盖 (例如: before_deploy)"`
+	Lookback string `json:"lookback,omitempty" jsonschema:"回溯时间窗口，可选，默认使用配置的label_lookback (例如: 24h, 7d)"`
+}
+
+type DiffMetricsParams struct {
+	Name     string `json:"name" jsonschema:"要对比的快照名称"`
+	Lookback string `json:"lookback,omitempty" jsonschema:"回溯时间窗口，可选，应与创建快照时一致，默认使用配置的label_lookback"`
+}
+
+type ListCommonMetricsParams struct{}
+
+type TSDBStatsParams struct{}
+
+type ReloadParams struct{}
+
+type AlertsParams struct{}
+
+type LabelValuesParams struct {
+	Label    string `json:"label" jsonschema:"标签名称 (例如: instance, job)"`
+	Match    string `json:"match,omitempty" jsonschema:"序列选择器，可选 (例如: up{job=\"node\"})"`
+	Lookback string `json:"lookback,omitempty" jsonschema:"回溯时间窗口，可选，默认使用配置的label_lookback (例如: 24h, 7d)"`
+}
+
+type SeriesParams struct {
+	Match     string `json:"match,omitempty" jsonschema:"序列选择器 (例如: up{job=\"node\"})，使用cursor翻页时可省略"`
+	StartTime string `json:"start_time,omitempty" jsonschema:"开始时间 (RFC3339格式, 例如: 2024-01-01T00:00:00Z)"`
+	EndTime   string `json:"end_time,omitempty" jsonschema:"结束时间 (RFC3339格式, 例如: 2024-01-01T23:59:59Z)"`
+	Limit     int    `json:"limit,omitempty" jsonschema:"分页大小，可选，设置后返回第一页和next_cursor，最大500"`
+	Cursor    string `json:"cursor,omitempty" jsonschema:"上一页返回的next_cursor，设置后忽略其他参数"`
+}
+
+type QueryExemplarsParams struct {
+	Query     string `json:"query" jsonschema:"PromQL查询语句 (例如: http_request_duration_seconds_bucket{job=\"api\"})"`
+	StartTime string `json:"start_time" jsonschema:"开始时间 (RFC3339格式, 例如: 2024-01-01T00:00:00Z)"`
+	EndTime   string `json:"end_time" jsonschema:"结束时间 (RFC3339格式, 例如: 2024-01-01T23:59:59Z)"`
+}
+
+type MetricMetadataParams struct {
+	Metric string `json:"metric,omitempty" jsonschema:"指标名称，可选，为空时返回所有指标的元数据（有数量上限）"`
+}
+
+type RulesParams struct {
+	GroupName     string            `json:"group_name,omitempty" jsonschema:"规则组名称，可选，为空时返回所有规则组"`
+	LabelSelector map[string]string `json:"label_selector,omitempty" jsonschema:"告警规则标签过滤条件，可选，指定后只返回标签全部匹配的告警规则，不返回记录规则 (例如: {\"team\": \"payments\"})"`
+}
+
+type QueryWithModifiersParams struct {
+	Query  string `json:"query" jsonschema:"基础PromQL表达式"`
+	Offset string `json:"offset,omitempty" jsonschema:"偏移时长 (例如: 5m, 1h, 1d)，可选"`
+	At     string `json:"at,omitempty" jsonschema:"固定求值时间 (RFC3339格式或Unix时间戳)，可选"`
+}
+
+type HistogramQuantileParams struct {
+	Metric   string            `json:"metric" jsonschema:"直方图指标基础名称，不含_bucket后缀 (例如: http_request_duration_seconds)"`
+	Quantile float64           `json:"quantile" jsonschema:"分位数，0到1之间 (例如: 0.99)"`
+	Window   string            `json:"window,omitempty" jsonschema:"rate计算的时间窗口，默认5m"`
+	Filters  map[string]string `json:"filters,omitempty" jsonschema:"标签过滤条件，可选 (例如: {\"job\": \"api\"})"`
+}
+
+type CounterIncreaseParams struct {
+	Metric  string            `json:"metric" jsonschema:"计数器指标名称 (例如: http_requests_total)"`
+	Window  string            `json:"window,omitempty" jsonschema:"increase计算的时间窗口，默认1h"`
+	Filters map[string]string `json:"filters,omitempty" jsonschema:"标签过滤条件，可选 (例如: {\"job\": \"api\"})"`
+}
+
+type RawAPIParams struct {
+	Path   string            `json:"path" jsonschema:"API路径，必须以/api/v1/开头 (例如: /api/v1/status/config)"`
+	Params map[string]string `json:"params,omitempty" jsonschema:"查询参数，可选 (例如: {\"limit\": \"10\"})"`
+}
+
+// parseTimeRange 解析RFC3339格式的开始和结束时间
+func parseTimeRange(start, end string) (time.Time, time.Time, error) {
+	startTime, err := time.Parse(time.RFC3339, start)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("无效的开始时间格式: %v", err)
+	}
+
+	endTime, err := time.Parse(time.RFC3339, end)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("无效的结束时间格式: %v", err)
+	}
+
+	return startTime, endTime, nil
+}
+
+// parseRangeQueryParams 解析并校验范围查询的时间范围和步长，范围查询类工具共用以保持错误信息一致
+func parseRangeQueryParams(start, end, step string, opts *toolOptions) (time.Time, time.Time, time.Duration, error) {
+	startTime, endTime, err := parseTimeRange(start, end)
+	if err != nil {
+		return time.Time{}, time.Time{}, 0, err
+	}
+
+	if err := checkRangeWindow(startTime, endTime, opts.maxRangeWindow); err != nil {
+		return time.Time{}, time.Time{}, 0, err
+	}
+
+	if step == "" && opts.defaultStep > 0 {
+		return startTime, endTime, opts.defaultStep, nil
+	}
+	if step == "" || step == stepAuto {
+		stepDuration := autoStep(endTime.Sub(startTime))
+		if points := int64(endTime.Sub(startTime)/stepDuration) + 1; points > maxRangePoints {
+			return time.Time{}, time.Time{}, 0, fmt.Errorf("自动计算的步长 %v 会产生%d个数据点，超过Prometheus的上限%d，请指定更大的step", stepDuration, points, maxRangePoints)
+		}
+		return startTime, endTime, stepDuration, nil
+	}
+	stepDuration, err := time.ParseDuration(step)
+	if err != nil {
+		return time.Time{}, time.Time{}, 0, fmt.Errorf("无效的步长格式: %v", err)
+	}
+
+	return startTime, endTime, stepDuration, nil
+}
+
+// autoSteps 自动计算step时可选的步长，从小到大排列
+var autoSteps = []time.Duration{
+	time.Second, 5 * time.Second, 10 * time.Second, 15 * time.Second, 30 * time.Second,
+	time.Minute, 2 * time.Minute, 5 * time.Minute, 10 * time.Minute, 15 * time.Minute, 30 * time.Minute,
+	time.Hour, 2 * time.Hour, 3 * time.Hour, 6 * time.Hour, 12 * time.Hour, 24 * time.Hour,
+}
+
+// autoStep 按时间窗口计算约autoStepTargetPoints个数据点的步长，向上取整到常用步长，超过1天时取整到天
+func autoStep(window time.Duration) time.Duration {
+	target := window / autoStepTargetPoints
+	for _, step := range autoSteps {
+		if step >= target {
+			return step
+		}
+	}
+	day := 24 * time.Hour
+	return (target + day - 1) / day * day
+}
+
+// resolveLookback 解析工具参数中的回溯窗口（支持PromQL风格的 1d、7d），为空时使用配置的默认值
+func resolveLookback(value string, defaultLookback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultLookback, nil
+	}
+	lookback, err := parseOffset(value)
+	if err != nil || lookback <= 0 {
+		return 0, fmt.Errorf("无效的回溯窗口: %s", value)
+	}
+	return lookback, nil
+}
+
+// checkRangeWindow 检查范围查询的时间窗口是否超过上限
+func checkRangeWindow(startTime, endTime time.Time, maxWindow time.Duration) error {
+	window := endTime.Sub(startTime)
+	if window < 0 {
+		return fmt.Errorf("结束时间不能早于开始时间")
+	}
+	if maxWindow > 0 && window > maxWindow {
+		return fmt.Errorf("查询时间窗口 %v 超过上限 %v，请缩小时间范围，或使用更粗的步长分段查询", window, maxWindow)
+	}
+	return nil
+}
 
 // createQueryHandler 创建即时查询处理器
-func createQueryHandler(client *Client) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[QueryParams]) (*mcp.CallToolResultFor[any], error) {
+func createQueryHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[QueryParams]) (*mcp.CallToolResultFor[any], error) {
 	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[QueryParams]) (*mcp.CallToolResultFor[any], error) {
 		if client == nil {
 			return common.CreateErrorResponse("Prometheus客户端不可用")
 		}
 
-		queryCtx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+		if err := validateFormat(params.Arguments.Format); err != nil {
+			return common.CreateErrorResponse("%v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.queryTimeout)
 		defer cancel()
 
 		result, err := client.QueryInstant(queryCtx, params.Arguments.Query)
 		if err != nil {
-			return common.CreateErrorResponse("查询失败: %v", err)
+			return common.CreateErrorResponse("查询失败: %v", deadline.Explain(err))
 		}
 
-		return common.CreateSuccessResponse(result)
+		// 按标签进行客户端聚合
+		if len(params.Arguments.GroupBy) > 0 {
+			vector, ok := result.(model.Vector)
+			if !ok {
+				return common.CreateErrorResponse("分组聚合仅支持瞬时向量结果，当前结果类型: %s", result.Type())
+			}
+
+			aggregated, err := aggregateVector(vector, params.Arguments.GroupBy, params.Arguments.Aggregation)
+			if err != nil {
+				return common.CreateErrorResponse("聚合失败: %v", err)
+			}
+			result = aggregated
+		}
+
+		if params.Arguments.ScalarOnly {
+			value, err := singleValue(result, opts)
+			if err != nil {
+				return common.CreateErrorResponse("%v", err)
+			}
+			return common.CreateSuccessResponse(value)
+		}
+
+		return renderQueryResult(result, params.Arguments.Format, params.Arguments.Raw, opts)
 	}
 }
 
 // createQueryRangeHandler 创建范围查询处理器
-func createQueryRangeHandler(client *Client) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[QueryRangeParams]) (*mcp.CallToolResultFor[any], error) {
+func createQueryRangeHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[QueryRangeParams]) (*mcp.CallToolResultFor[any], error) {
 	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[QueryRangeParams]) (*mcp.CallToolResultFor[any], error) {
 		if client == nil {
 			return common.CreateErrorResponse("Prometheus客户端不可用")
 		}
 
 		// 验证时间参数
-		startTime, err := time.Parse(time.RFC3339, params.Arguments.StartTime)
+		startTime, endTime, step, err := parseRangeQueryParams(params.Arguments.StartTime, params.Arguments.EndTime, params.Arguments.Step, opts)
 		if err != nil {
-			return common.CreateErrorResponse("无效的开始时间格式: %v", err)
+			return common.CreateErrorResponse("%v", err)
 		}
 
-		endTime, err := time.Parse(time.RFC3339, params.Arguments.EndTime)
-		if err != nil {
-			return common.CreateErrorResponse("无效的结束时间格式: %v", err)
+		if err := validateFormat(params.Arguments.Format); err != nil {
+			return common.CreateErrorResponse("%v", err)
 		}
 
-		step, err := time.ParseDuration(params.Arguments.Step)
-		if err != nil {
-			return common.CreateErrorResponse("无效的步长格式: %v", err)
-		}
-
-		queryCtx, cancel := context.WithTimeout(ctx, rangeQueryTimeout)
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.rangeQueryTimeout)
 		defer cancel()
 
 		result, err := client.QueryRange(queryCtx, params.Arguments.Query, startTime, endTime, step)
 		if err != nil {
-			return common.CreateErrorResponse("范围查询失败: %v", err)
+			return common.CreateErrorResponse("范围查询失败: %v", deadline.Explain(err))
 		}
 
-		return common.CreateSuccessResponse(result)
+		return renderQueryResult(result, params.Arguments.Format, params.Arguments.Raw, opts)
 	}
 }
 
 // createTargetsHandler 创建目标获取处理器
-func createTargetsHandler(client *Client) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[TargetsParams]) (*mcp.CallToolResultFor[any], error) {
+func createTargetsHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[TargetsParams]) (*mcp.CallToolResultFor[any], error) {
 	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[TargetsParams]) (*mcp.CallToolResultFor[any], error) {
 		if client == nil {
 			return common.CreateErrorResponse("Prometheus客户端不可用")
 		}
 
-		queryCtx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.queryTimeout)
 		defer cancel()
 
 		targets, err := client.GetTargets(queryCtx)
 		if err != nil {
-			return common.CreateErrorResponse("获取目标失败: %v", err)
+			return common.CreateErrorResponse("获取目标失败: %v", deadline.Explain(err))
 		}
 
 		targetInfo := map[string]any{
@@ -119,18 +375,18 @@ func createTargetsHandler(client *Client) func(context.Context, *mcp.ServerSessi
 }
 
 // createStatusHandler 创建状态检查处理器
-func createStatusHandler(client *Client) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[StatusParams]) (*mcp.CallToolResultFor[any], error) {
+func createStatusHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[StatusParams]) (*mcp.CallToolResultFor[any], error) {
 	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[StatusParams]) (*mcp.CallToolResultFor[any], error) {
 		if client == nil {
 			return common.CreateErrorResponse("Prometheus客户端不可用")
 		}
 
-		queryCtx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.queryTimeout)
 		defer cancel()
 
 		// 测试连接
 		if err := client.TestConnection(queryCtx); err != nil {
-			return common.CreateErrorResponse("连接测试失败: %v", err)
+			return common.CreateErrorResponse("连接测试失败: %v", deadline.Explain(err))
 		}
 
 		// 功能测试
@@ -149,50 +405,1006 @@ func createStatusHandler(client *Client) func(context.Context, *mcp.ServerSessio
 	}
 }
 
+// resolveMetricQuery 查找常用指标类型对应的查询并替换挂载点，mountpoint为空时使用默认挂载点
+func resolveMetricQuery(opts *toolOptions, metricType, mountpoint string) (string, error) {
+	query, exists := opts.metricQueries[metricType]
+	if !exists {
+		return "", fmt.Errorf("不支持的指标类型: %s，可通过prometheus_list_common_metrics查看支持的类型", metricType)
+	}
+
+	if mountpoint == "" {
+		mountpoint = opts.diskMountpoint
+	} else if !hasMountpointPlaceholder(query) {
+		return "", fmt.Errorf("指标类型 %s 不支持mountpoint参数", metricType)
+	}
+
+	return renderMetricQuery(query, mountpoint), nil
+}
+
 // createCommonMetricsHandler 创建常用指标查询处理器
-func createCommonMetricsHandler(client *Client) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[CommonMetricsParams]) (*mcp.CallToolResultFor[any], error) {
+func createCommonMetricsHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[CommonMetricsParams]) (*mcp.CallToolResultFor[any], error) {
 	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[CommonMetricsParams]) (*mcp.CallToolResultFor[any], error) {
 		if client == nil {
 			return common.CreateErrorResponse("Prometheus客户端不可用")
 		}
 
-		query, exists := MetricQueries[params.Arguments.MetricType]
-		if !exists {
-			return common.CreateErrorResponse("不支持的指标类型")
+		query, err := resolveMetricQuery(opts, params.Arguments.MetricType, params.Arguments.Mountpoint)
+		if err != nil {
+			return common.CreateErrorResponse("%v", err)
+		}
+
+		if err := validateFormat(params.Arguments.Format); err != nil {
+			return common.CreateErrorResponse("%v", err)
 		}
 
-		queryCtx, cancel := context.WithTimeout(ctx, defaultQueryTimeout)
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.queryTimeout)
 		defer cancel()
 
 		result, err := client.QueryInstant(queryCtx, query)
 		if err != nil {
-			return common.CreateErrorResponse("查询失败: %v", err)
+			return common.CreateErrorResponse("查询失败: %v", deadline.Explain(err))
 		}
 
-		return common.CreateSuccessResponse(result)
+		return renderQueryResult(result, params.Arguments.Format, params.Arguments.Raw, opts)
+	}
+}
+
+// createCommonMetricsRangeHandler 创建常用指标范围查询处理器
+func createCommonMetricsRangeHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[CommonMetricsRangeParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[CommonMetricsRangeParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Prometheus客户端不可用")
+		}
+
+		query, err := resolveMetricQuery(opts, params.Arguments.MetricType, params.Arguments.Mountpoint)
+		if err != nil {
+			return common.CreateErrorResponse("%v", err)
+		}
+
+		// 验证时间参数
+		startTime, endTime, step, err := parseRangeQueryParams(params.Arguments.StartTime, params.Arguments.EndTime, params.Arguments.Step, opts)
+		if err != nil {
+			return common.CreateErrorResponse("%v", err)
+		}
+
+		if err := validateFormat(params.Arguments.Format); err != nil {
+			return common.CreateErrorResponse("%v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.rangeQueryTimeout)
+		defer cancel()
+
+		result, err := client.QueryRange(queryCtx, query, startTime, endTime, step)
+		if err != nil {
+			return common.CreateErrorResponse("范围查询失败: %v", deadline.Explain(err))
+		}
+
+		return renderQueryResult(result, params.Arguments.Format, params.Arguments.Raw, opts)
+	}
+}
+
+// commonMetricResult 单个常用指标类型的查询结果，失败时只包含错误信息
+type commonMetricResult struct {
+	Query  string `json:"query"`
+	Result any    `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// createCommonMetricsAllHandler 创建批量常用指标查询处理器
+// 所有类型共享一个查询超时并发执行，单个类型失败不影响其他类型
+func createCommonMetricsAllHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[CommonMetricsAllParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[CommonMetricsAllParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Prometheus客户端不可用")
+		}
+
+		mountpoint := opts.diskMountpoint
+		if params.Arguments.Mountpoint != "" {
+			mountpoint = params.Arguments.Mountpoint
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.queryTimeout)
+		defer cancel()
+
+		results := make(map[string]*commonMetricResult, len(opts.metricQueries))
+		var (
+			mu  sync.Mutex
+			wg  sync.WaitGroup
+			sem = make(chan struct{}, maxCommonMetricsConcurrency)
+		)
+		for metricType, query := range opts.metricQueries {
+			query = renderMetricQuery(query, mountpoint)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				entry := &commonMetricResult{Query: query}
+				if result, err := client.QueryInstant(queryCtx, query); err != nil {
+					entry.Error = deadline.Explain(err).Error()
+				} else {
+					entry.Result = transformResult(result, opts)
+				}
+
+				mu.Lock()
+				results[metricType] = entry
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+
+		return common.CreateSuccessResponse(results)
+	}
+}
+
+// commonMetricInfo 常用指标类型及其查询
+type commonMetricInfo struct {
+	MetricType string `json:"metric_type"`
+	Query      string `json:"query"`
+}
+
+// createListCommonMetricsHandler 创建常用指标类型列表处理器
+func createListCommonMetricsHandler(opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[ListCommonMetricsParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[ListCommonMetricsParams]) (*mcp.CallToolResultFor[any], error) {
+		metricTypes := make([]string, 0, len(opts.metricQueries))
+		for metricType := range opts.metricQueries {
+			metricTypes = append(metricTypes, metricType)
+		}
+		sort.Strings(metricTypes)
+
+		metrics := make([]commonMetricInfo, 0, len(metricTypes))
+		for _, metricType := range metricTypes {
+			metrics = append(metrics, commonMetricInfo{
+				MetricType: metricType,
+				Query:      renderMetricQuery(opts.metricQueries[metricType], opts.diskMountpoint),
+			})
+		}
+
+		return common.CreateSuccessResponse(map[string]any{
+			"count":   len(metrics),
+			"metrics": metrics,
+		})
 	}
 }
 
 // createListMetricsHandler 创建指标列表处理器
-func createListMetricsHandler(client *Client) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[ListMetricsParams]) (*mcp.CallToolResultFor[any], error) {
+func createListMetricsHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[ListMetricsParams]) (*mcp.CallToolResultFor[any], error) {
 	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[ListMetricsParams]) (*mcp.CallToolResultFor[any], error) {
 		if client == nil {
 			return common.CreateErrorResponse("Prometheus客户端不可用")
 		}
 
-		queryCtx, cancel := context.WithTimeout(ctx, listMetricsTimeout)
+		limit, offset := params.Arguments.Limit, params.Arguments.Offset
+		if limit < 0 || offset < 0 {
+			return common.CreateErrorResponse("limit和offset不能为负数")
+		}
+
+		lookback, err := resolveLookback(params.Arguments.Lookback, opts.labelLookback)
+		if err != nil {
+			return common.CreateErrorResponse("%v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.listMetricsTimeout)
 		defer cancel()
 
-		metricNames, err := client.GetMetricNames(queryCtx)
+		metricNames, err := client.GetMetricNames(queryCtx, lookback)
 		if err != nil {
-			return common.CreateErrorResponse("获取指标名称失败: %v", err)
+			return common.CreateErrorResponse("获取指标名称失败: %v", deadline.Explain(err))
 		}
 
+		// 先按前缀过滤，count为匹配前缀的总数，再截取当前页
+		if prefix := params.Arguments.Prefix; prefix != "" {
+			matched := make([]string, 0)
+			for _, name := range metricNames {
+				if strings.HasPrefix(name, prefix) {
+					matched = append(matched, name)
+				}
+			}
+			metricNames = matched
+		}
+
+		count := len(metricNames)
+		page := metricNames[min(offset, count):]
+		if limit > 0 && len(page) > limit {
+			page = page[:limit]
+		}
+
+		result := map[string]any{
+			"count":    count,
+			"offset":   offset,
+			"has_more": offset+len(page) < count,
+			"metrics":  page,
+		}
+
+		return common.CreateSuccessResponse(result)
+	}
+}
+
+// createSnapshotMetricsHandler 创建指标名称快照处理器
+func createSnapshotMetricsHandler(client *Client, opts *toolOptions, snapshots *metricSnapshotStore) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[SnapshotMetricsParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[SnapshotMetricsParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Prometheus客户端不可用")
+		}
+		if params.Arguments.Name == "" {
+			return common.CreateErrorResponse("快照名称不能为空")
+		}
+
+		lookback, err := resolveLookback(params.Arguments.Lookback, opts.labelLookback)
+		if err != nil {
+			return common.CreateErrorResponse("%v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.listMetricsTimeout)
+		defer cancel()
+
+		metricNames, err := client.GetMetricNames(queryCtx, lookback)
+		if err != nil {
+			return common.CreateErrorResponse("获取指标名称失败: %v", deadline.Explain(err))
+		}
+
+		now := time.Now().UTC()
+		snapshots.save(params.Arguments.Name, metricNames, now)
+
 		result := map[string]any{
-			"count":   len(metricNames),
-			"metrics": metricNames,
+			"name":       params.Arguments.Name,
+			"count":      len(metricNames),
+			"created_at": now,
 		}
 
 		return common.CreateSuccessResponse(result)
 	}
 }
+
+// createDiffMetricsHandler 创建指标名称快照对比处理器
+func createDiffMetricsHandler(client *Client, opts *toolOptions, snapshots *metricSnapshotStore) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[DiffMetricsParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[DiffMetricsParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Prometheus客户端不可用")
+		}
+		if params.Arguments.Name == "" {
+			return common.CreateErrorResponse("快照名称不能为空")
+		}
+
+		lookback, err := resolveLookback(params.Arguments.Lookback, opts.labelLookback)
+		if err != nil {
+			return common.CreateErrorResponse("%v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.listMetricsTimeout)
+		defer cancel()
+
+		metricNames, err := client.GetMetricNames(queryCtx, lookback)
+		if err != nil {
+			return common.CreateErrorResponse("获取指标名称失败: %v", deadline.Explain(err))
+		}
+
+		added, removed, createdAt, ok := snapshots.diff(params.Arguments.Name, metricNames)
+		if !ok {
+			return common.CreateErrorResponse("未找到快照: %s，请先使用prometheus_snapshot_metrics创建", params.Arguments.Name)
+		}
+
+		result := map[string]any{
+			"name":          params.Arguments.Name,
+			"snapshot_time": createdAt,
+			"added":         added,
+			"removed":       removed,
+			"added_count":   len(added),
+			"removed_count": len(removed),
+		}
+
+		return common.CreateSuccessResponse(result)
+	}
+}
+
+// alertInfo 告警信息
+type alertInfo struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	ActiveAt    time.Time         `json:"activeAt"`
+	Value       string            `json:"value"`
+}
+
+// tsdbHeadStats TSDB头块统计，时间范围转换为可读时间
+type tsdbHeadStats struct {
+	NumSeries     int       `json:"num_series"`
+	NumLabelPairs int       `json:"num_label_pairs"`
+	ChunkCount    int       `json:"chunk_count"`
+	MinTime       time.Time `json:"min_time"`
+	MaxTime       time.Time `json:"max_time"`
+}
+
+// createTSDBStatsHandler 创建TSDB统计查询处理器
+func createTSDBStatsHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[TSDBStatsParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[TSDBStatsParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Prometheus客户端不可用")
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.listMetricsTimeout)
+		defer cancel()
+
+		stats, err := client.GetTSDBStats(queryCtx)
+		if err != nil {
+			return common.CreateErrorResponse("获取TSDB统计失败: %v", deadline.Explain(err))
+		}
+
+		result := map[string]any{
+			"head_stats": tsdbHeadStats{
+				NumSeries:     stats.HeadStats.NumSeries,
+				NumLabelPairs: stats.HeadStats.NumLabelPairs,
+				ChunkCount:    stats.HeadStats.ChunkCount,
+				MinTime:       time.UnixMilli(int64(stats.HeadStats.MinTime)).UTC(),
+				MaxTime:       time.UnixMilli(int64(stats.HeadStats.MaxTime)).UTC(),
+			},
+			"series_count_by_metric_name":      stats.SeriesCountByMetricName,
+			"label_value_count_by_label_name":  stats.LabelValueCountByLabelName,
+			"series_count_by_label_value_pair": stats.SeriesCountByLabelValuePair,
+			"memory_in_bytes_by_label_name":    stats.MemoryInBytesByLabelName,
+		}
+
+		return common.CreateSuccessResponse(result)
+	}
+}
+
+// createAlertsHandler 创建告警查询处理器
+func createAlertsHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[AlertsParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[AlertsParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Prometheus客户端不可用")
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.queryTimeout)
+		defer cancel()
+
+		alerts, err := client.GetAlerts(queryCtx)
+		if err != nil {
+			return common.CreateErrorResponse("获取告警失败: %v", deadline.Explain(err))
+		}
+
+		// 按状态分组
+		firing := make([]alertInfo, 0)
+		pending := make([]alertInfo, 0)
+		for _, alert := range alerts.Alerts {
+			info := alertInfo{
+				Labels:      labelSetToMap(alert.Labels),
+				Annotations: labelSetToMap(alert.Annotations),
+				ActiveAt:    alert.ActiveAt,
+				Value:       alert.Value,
+			}
+			switch alert.State {
+			case v1.AlertStateFiring:
+				firing = append(firing, info)
+			case v1.AlertStatePending:
+				pending = append(pending, info)
+			}
+		}
+
+		alertsInfo := map[string]any{
+			"firing_count":  len(firing),
+			"pending_count": len(pending),
+			"firing":        firing,
+			"pending":       pending,
+		}
+
+		return common.CreateSuccessResponse(alertsInfo)
+	}
+}
+
+// createQueryWithModifiersHandler 创建带offset/@修饰符的查询处理器
+func createQueryWithModifiersHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[QueryWithModifiersParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[QueryWithModifiersParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Prometheus客户端不可用")
+		}
+
+		offset, err := parseOffset(params.Arguments.Offset)
+		if err != nil {
+			return common.CreateErrorResponse("无效的偏移格式: %v", err)
+		}
+
+		at, err := parseEvalTime(params.Arguments.At)
+		if err != nil {
+			return common.CreateErrorResponse("无效的求值时间格式: %v", err)
+		}
+
+		if offset == 0 && at == nil {
+			return common.CreateErrorResponse("offset和at至少需要指定一个")
+		}
+
+		query, err := applyModifiers(params.Arguments.Query, offset, at)
+		if err != nil {
+			return common.CreateErrorResponse("构建查询失败: %v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.queryTimeout)
+		defer cancel()
+
+		result, err := client.QueryInstant(queryCtx, query)
+		if err != nil {
+			return common.CreateErrorResponse("查询失败: %v", deadline.Explain(err))
+		}
+
+		return common.CreateSuccessResponse(map[string]any{
+			"query":  query,
+			"result": transformResult(result, opts),
+		})
+	}
+}
+
+// createHistogramQuantileHandler 创建直方图分位数查询处理器
+func createHistogramQuantileHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[HistogramQuantileParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[HistogramQuantileParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Prometheus客户端不可用")
+		}
+
+		args := params.Arguments
+		query, err := buildHistogramQuantile(args.Metric, args.Quantile, args.Window, args.Filters)
+		if err != nil {
+			return common.CreateErrorResponse("构建查询失败: %v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.queryTimeout)
+		defer cancel()
+
+		result, err := client.QueryInstant(queryCtx, query)
+		if err != nil {
+			return common.CreateErrorResponse("查询失败: %v", deadline.Explain(err))
+		}
+
+		return common.CreateSuccessResponse(map[string]any{
+			"query":  query,
+			"result": transformResult(result, opts),
+		})
+	}
+}
+
+// createCounterIncreaseHandler 创建计数器增量查询处理器
+func createCounterIncreaseHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[CounterIncreaseParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[CounterIncreaseParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Prometheus客户端不可用")
+		}
+
+		args := params.Arguments
+		query, err := buildCounterIncrease(args.Metric, args.Window, args.Filters)
+		if err != nil {
+			return common.CreateErrorResponse("构建查询失败: %v", err)
+		}
+
+		if metricType, ok := lookupMetricType(ctx, client, opts, args.Metric); ok && metricType != v1.MetricTypeCounter {
+			return common.CreateErrorResponse("指标 %s 的类型为%s，increase只适用于counter类型", args.Metric, metricType)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.queryTimeout)
+		defer cancel()
+
+		result, err := client.QueryInstant(queryCtx, query)
+		if err != nil {
+			return common.CreateErrorResponse("查询失败: %v", deadline.Explain(err))
+		}
+
+		return common.CreateSuccessResponse(map[string]any{
+			"query":  query,
+			"result": transformResult(result, opts),
+		})
+	}
+}
+
+// lookupMetricType 通过元数据获取指标类型，元数据缺失、类型不一致或查询失败时返回false，由调用方直接放行
+func lookupMetricType(ctx context.Context, client *Client, opts *toolOptions, metric string) (v1.MetricType, bool) {
+	queryCtx, cancel, _ := common.WithUpstreamTimeout(ctx, opts.listMetricsTimeout)
+	defer cancel()
+
+	metadata, err := client.GetMetricMetadata(queryCtx, metric)
+	if err != nil || len(metadata[metric]) == 0 {
+		return "", false
+	}
+
+	metricType := metadata[metric][0].Type
+	for _, item := range metadata[metric][1:] {
+		if item.Type != metricType {
+			return "", false
+		}
+	}
+	return metricType, metricType != v1.MetricTypeUnknown
+}
+
+// alertingRuleInfo 告警规则信息
+type alertingRuleInfo struct {
+	Name         string            `json:"name"`
+	Query        string            `json:"query"`
+	Duration     float64           `json:"duration"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	State        string            `json:"state"`
+	Health       v1.RuleHealth     `json:"health"`
+	LastError    string            `json:"last_error,omitempty"`
+	ActiveAlerts int               `json:"active_alerts"`
+}
+
+// recordingRuleInfo 记录规则信息
+type recordingRuleInfo struct {
+	Name      string            `json:"name"`
+	Query     string            `json:"query"`
+	Labels    map[string]string `json:"labels"`
+	Health    v1.RuleHealth     `json:"health"`
+	LastError string            `json:"last_error,omitempty"`
+}
+
+// ruleGroupInfo 规则组信息
+type ruleGroupInfo struct {
+	Name           string              `json:"name"`
+	File           string              `json:"file"`
+	Interval       float64             `json:"interval"`
+	AlertingRules  []alertingRuleInfo  `json:"alerting_rules"`
+	RecordingRules []recordingRuleInfo `json:"recording_rules"`
+}
+
+// matchLabelSelector 判断标签是否包含选择器中的全部键值对，选择器为空时总是匹配
+func matchLabelSelector(labels model.LabelSet, selector map[string]string) bool {
+	for name, value := range selector {
+		if string(labels[model.LabelName(name)]) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// createRulesHandler 创建规则查询处理器
+func createRulesHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[RulesParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[RulesParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Prometheus客户端不可用")
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.queryTimeout)
+		defer cancel()
+
+		rules, err := client.GetRules(queryCtx)
+		if err != nil {
+			return common.CreateErrorResponse("获取规则失败: %v", deadline.Explain(err))
+		}
+
+		groupName := params.Arguments.GroupName
+		selector := params.Arguments.LabelSelector
+		groups := make([]ruleGroupInfo, 0, len(rules.Groups))
+		alertingCount, recordingCount := 0, 0
+		groupFound := false
+
+		for _, group := range rules.Groups {
+			if groupName != "" && group.Name != groupName {
+				continue
+			}
+			groupFound = true
+
+			info := ruleGroupInfo{
+				Name:           group.Name,
+				File:           group.File,
+				Interval:       group.Interval,
+				AlertingRules:  make([]alertingRuleInfo, 0),
+				RecordingRules: make([]recordingRuleInfo, 0),
+			}
+
+			for _, rule := range group.Rules {
+				switch r := rule.(type) {
+				case v1.AlertingRule:
+					if !matchLabelSelector(r.Labels, selector) {
+						continue
+					}
+					info.AlertingRules = append(info.AlertingRules, alertingRuleInfo{
+						Name:         r.Name,
+						Query:        r.Query,
+						Duration:     r.Duration,
+						Labels:       labelSetToMap(r.Labels),
+						Annotations:  labelSetToMap(r.Annotations),
+						State:        r.State,
+						Health:       r.Health,
+						LastError:    r.LastError,
+						ActiveAlerts: len(r.Alerts),
+					})
+				case v1.RecordingRule:
+					if len(selector) > 0 {
+						continue
+					}
+					info.RecordingRules = append(info.RecordingRules, recordingRuleInfo{
+						Name:      r.Name,
+						Query:     r.Query,
+						Labels:    labelSetToMap(r.Labels),
+						Health:    r.Health,
+						LastError: r.LastError,
+					})
+				}
+			}
+
+			// 按标签过滤时省略没有匹配规则的规则组
+			if len(selector) > 0 && len(info.AlertingRules) == 0 {
+				continue
+			}
+
+			alertingCount += len(info.AlertingRules)
+			recordingCount += len(info.RecordingRules)
+			groups = append(groups, info)
+		}
+
+		if groupName != "" && !groupFound {
+			return common.CreateErrorResponse("未找到规则组: %s", groupName)
+		}
+
+		rulesInfo := map[string]any{
+			"group_count":     len(groups),
+			"alerting_count":  alertingCount,
+			"recording_count": recordingCount,
+			"groups":          groups,
+		}
+
+		return common.CreateSuccessResponse(rulesInfo)
+	}
+}
+
+// createLabelValuesHandler 创建标签值查询处理器
+func createLabelValuesHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[LabelValuesParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[LabelValuesParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Prometheus客户端不可用")
+		}
+
+		label := params.Arguments.Label
+		if label == "" {
+			return common.CreateErrorResponse("标签名称不能为空")
+		}
+
+		var matches []string
+		if params.Arguments.Match != "" {
+			matches = []string{params.Arguments.Match}
+		} else if _, ok := opts.highCardinality[label]; ok {
+			return common.CreateErrorResponse("标签 %s 基数较高，请通过match参数缩小范围 (例如: up{job=\"node\"})", label)
+		}
+
+		lookback, err := resolveLookback(params.Arguments.Lookback, opts.labelLookback)
+		if err != nil {
+			return common.CreateErrorResponse("%v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.listMetricsTimeout)
+		defer cancel()
+
+		values, err := client.GetLabelValues(queryCtx, label, matches, lookback)
+		if err != nil {
+			return common.CreateErrorResponse("获取标签值失败: %v", deadline.Explain(err))
+		}
+
+		sort.Strings(values)
+		total := len(values)
+		truncated := total > opts.labelValuesLimit
+		if truncated {
+			values = values[:opts.labelValuesLimit]
+		}
+
+		result := map[string]any{
+			"label":     label,
+			"count":     len(values),
+			"total":     total,
+			"truncated": truncated,
+			"values":    values,
+		}
+
+		return common.CreateSuccessResponse(result)
+	}
+}
+
+// createSeriesHandler 创建序列元数据查询处理器
+func createSeriesHandler(client *Client, opts *toolOptions, cache *seriesCache) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[SeriesParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[SeriesParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Prometheus客户端不可用")
+		}
+
+		// 续页直接从缓存读取，不再请求上游
+		if params.Arguments.Cursor != "" {
+			cursor, err := decodeSeriesCursor(params.Arguments.Cursor)
+			if err != nil {
+				return common.CreateErrorResponse("%v", err)
+			}
+			cached, ok := cache.get(cursor.ID, time.Now())
+			if !ok {
+				return common.CreateErrorResponse("游标已过期（有效期 %v），请重新查询", seriesCacheTTL)
+			}
+			return seriesPageResponse(cached, cursor)
+		}
+
+		limit := params.Arguments.Limit
+		if limit < 0 || limit > maxSeriesResults {
+			return common.CreateErrorResponse("limit应在0到%d之间", maxSeriesResults)
+		}
+
+		if params.Arguments.Match == "" {
+			return common.CreateErrorResponse("序列选择器不能为空")
+		}
+
+		// 验证时间参数
+		startTime, endTime, err := parseTimeRange(params.Arguments.StartTime, params.Arguments.EndTime)
+		if err != nil {
+			return common.CreateErrorResponse("%v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.listMetricsTimeout)
+		defer cancel()
+
+		series, err := client.GetSeries(queryCtx, []string{params.Arguments.Match}, startTime, endTime)
+		if err != nil {
+			return common.CreateErrorResponse("获取序列失败: %v", deadline.Explain(err))
+		}
+
+		if limit > 0 {
+			id, cached := cache.put(series, time.Now())
+			return seriesPageResponse(cached, seriesCursor{ID: id, Limit: limit})
+		}
+
+		total := len(series)
+		truncated := total > maxSeriesResults
+		if truncated {
+			series = series[:maxSeriesResults]
+		}
+
+		labelSets := make([]map[string]string, 0, len(series))
+		for _, labels := range series {
+			labelSets = append(labelSets, labelSetToMap(labels))
+		}
+
+		result := map[string]any{
+			"count":     len(labelSets),
+			"total":     total,
+			"truncated": truncated,
+			"series":    labelSets,
+		}
+
+		return common.CreateSuccessResponse(result)
+	}
+}
+
+// seriesPageResponse 生成序列分页响应，还有下一页时返回next_cursor
+func seriesPageResponse(cached cachedSeries, cursor seriesCursor) (*mcp.CallToolResultFor[any], error) {
+	count := len(cached.series)
+	page := cached.series[min(cursor.Offset, count):]
+	if len(page) > cursor.Limit {
+		page = page[:cursor.Limit]
+	}
+
+	labelSets := make([]map[string]string, 0, len(page))
+	for _, labels := range page {
+		labelSets = append(labelSets, labelSetToMap(labels))
+	}
+
+	nextOffset := cursor.Offset + len(page)
+	result := map[string]any{
+		"count":     len(labelSets),
+		"total":     cached.total,
+		"offset":    cursor.Offset,
+		"has_more":  nextOffset < count,
+		"truncated": cached.total > count,
+		"series":    labelSets,
+	}
+	if nextOffset < count {
+		next, err := seriesCursor{ID: cursor.ID, Offset: nextOffset, Limit: cursor.Limit}.encode()
+		if err != nil {
+			return common.CreateErrorResponse("%v", err)
+		}
+		result["next_cursor"] = next
+	}
+
+	return common.CreateSuccessResponse(result)
+}
+
+// exemplar标签中常见的链路追踪ID标签名，按优先级排列
+var traceIDLabels = []model.LabelName{"trace_id", "traceID", "traceId", "TraceID"}
+
+// exemplarInfo 单个exemplar信息，trace_id单独列出便于关联链路追踪
+type exemplarInfo struct {
+	TraceID   string            `json:"trace_id,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+	Value     float64           `json:"value"`
+	Labels    map[string]string `json:"labels"`
+}
+
+// exemplarSeriesInfo 一个序列的exemplar列表
+type exemplarSeriesInfo struct {
+	Series    map[string]string `json:"series"`
+	Exemplars []exemplarInfo    `json:"exemplars"`
+}
+
+// exemplarTraceID 从exemplar标签中提取链路追踪ID
+func exemplarTraceID(labels model.LabelSet) string {
+	for _, name := range traceIDLabels {
+		if value, ok := labels[name]; ok && value != "" {
+			return string(value)
+		}
+	}
+	return ""
+}
+
+// createQueryExemplarsHandler 创建exemplar查询处理器
+func createQueryExemplarsHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[QueryExemplarsParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[QueryExemplarsParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Prometheus客户端不可用")
+		}
+
+		if params.Arguments.Query == "" {
+			return common.CreateErrorResponse("查询语句不能为空")
+		}
+
+		// 验证时间参数
+		startTime, endTime, err := parseTimeRange(params.Arguments.StartTime, params.Arguments.EndTime)
+		if err != nil {
+			return common.CreateErrorResponse("%v", err)
+		}
+
+		if err := checkRangeWindow(startTime, endTime, opts.maxRangeWindow); err != nil {
+			return common.CreateErrorResponse("%v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.rangeQueryTimeout)
+		defer cancel()
+
+		results, err := client.QueryExemplars(queryCtx, params.Arguments.Query, startTime, endTime)
+		if err != nil {
+			return common.CreateErrorResponse("查询exemplar失败: %v", deadline.Explain(err))
+		}
+
+		// 按序列整理exemplar，同时汇总去重后的trace_id
+		var (
+			total    int
+			series   = make([]exemplarSeriesInfo, 0, len(results))
+			traceIDs = make([]string, 0)
+			seen     = make(map[string]bool)
+		)
+		for _, result := range results {
+			info := exemplarSeriesInfo{
+				Series:    labelSetToMap(result.SeriesLabels),
+				Exemplars: make([]exemplarInfo, 0, len(result.Exemplars)),
+			}
+			for _, exemplar := range result.Exemplars {
+				total++
+				if total > maxExemplarResults {
+					continue
+				}
+
+				traceID := exemplarTraceID(exemplar.Labels)
+				if traceID != "" && !seen[traceID] {
+					seen[traceID] = true
+					traceIDs = append(traceIDs, traceID)
+				}
+				info.Exemplars = append(info.Exemplars, exemplarInfo{
+					TraceID:   traceID,
+					Timestamp: exemplar.Timestamp.Time().UTC(),
+					Value:     float64(exemplar.Value),
+					Labels:    labelSetToMap(exemplar.Labels),
+				})
+			}
+			if len(info.Exemplars) > 0 {
+				series = append(series, info)
+			}
+		}
+
+		result := map[string]any{
+			"trace_ids": traceIDs,
+			"count":     min(total, maxExemplarResults),
+			"total":     total,
+			"truncated": total > maxExemplarResults,
+			"series":    series,
+		}
+
+		return common.CreateSuccessResponse(result)
+	}
+}
+
+// metricMetadataInfo 指标元数据信息
+type metricMetadataInfo struct {
+	Metric string        `json:"metric"`
+	Type   v1.MetricType `json:"type"`
+	Help   string        `json:"help"`
+	Unit   string        `json:"unit"`
+}
+
+// createMetricMetadataHandler 创建指标元数据查询处理器
+func createMetricMetadataHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[MetricMetadataParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[MetricMetadataParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Prometheus客户端不可用")
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.listMetricsTimeout)
+		defer cancel()
+
+		metadata, err := client.GetMetricMetadata(queryCtx, params.Arguments.Metric)
+		if err != nil {
+			return common.CreateErrorResponse("获取指标元数据失败: %v", deadline.Explain(err))
+		}
+
+		if params.Arguments.Metric != "" && len(metadata) == 0 {
+			return common.CreateErrorResponse("未找到指标的元数据: %s", params.Arguments.Metric)
+		}
+
+		// 按指标名称排序，保证截断结果稳定
+		names := make([]string, 0, len(metadata))
+		for name := range metadata {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		total := len(names)
+		truncated := params.Arguments.Metric == "" && total > opts.metadataLimit
+		if truncated {
+			names = names[:opts.metadataLimit]
+		}
+
+		entries := make([]metricMetadataInfo, 0, len(names))
+		for _, name := range names {
+			seen := make(map[v1.Metadata]bool)
+			for _, item := range metadata[name] {
+				if seen[item] {
+					continue
+				}
+				seen[item] = true
+				entries = append(entries, metricMetadataInfo{
+					Metric: name,
+					Type:   item.Type,
+					Help:   item.Help,
+					Unit:   item.Unit,
+				})
+			}
+		}
+
+		result := map[string]any{
+			"count":     len(names),
+			"total":     total,
+			"truncated": truncated,
+			"metadata":  entries,
+		}
+
+		return common.CreateSuccessResponse(result)
+	}
+}
+
+// createRawAPIHandler 创建原始API透传处理器
+func createRawAPIHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[RawAPIParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[RawAPIParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Prometheus客户端不可用")
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.queryTimeout)
+		defer cancel()
+
+		body, err := client.RawGet(queryCtx, params.Arguments.Path, params.Arguments.Params)
+		if err != nil {
+			return common.CreateErrorResponse("原始API请求失败: %v", deadline.Explain(err))
+		}
+
+		return common.CreateSimpleSuccessResponse(string(body))
+	}
+}
+
+// createReloadHandler 创建配置重载处理器
+func createReloadHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[ReloadParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[ReloadParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Prometheus客户端不可用")
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.queryTimeout)
+		defer cancel()
+
+		if err := client.ReloadConfig(queryCtx); err != nil {
+			return common.CreateErrorResponse("重载配置失败: %v", deadline.Explain(err))
+		}
+
+		return common.CreateSuccessResponse(map[string]any{
+			"status":  "success",
+			"message": "Prometheus配置已重新加载",
+		})
+	}
+}
@@ -0,0 +1,615 @@
+package prometheus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-server/config"
+	"mcp-server/internal/core"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// connectInMemory 通过内存传输连接MCP服务器，返回客户端会话
+func connectInMemory(t *testing.T, server *mcp.Server) *mcp.ClientSession {
+	t.Helper()
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport)
+	if err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	t.Cleanup(func() { serverSession.Close() })
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "test"}, nil)
+	session, err := client.Connect(ctx, clientTransport)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	t.Cleanup(func() { session.Close() })
+	return session
+}
+
+func TestToolsWithNilClient(t *testing.T) {
+	ctx := context.Background()
+	server := mcp.NewServer(&mcp.Implementation{Name: "prometheus", Version: "test"}, nil)
+	opts := newToolOptions(&config.PrometheusConfig{EnableRawAPI: true, EnableAdminAPI: true})
+	if _, err := registerTools(server, nil, opts); err != nil {
+		t.Fatalf("registerTools: %v", err)
+	}
+	session := connectInMemory(t, server)
+
+	tools, err := session.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatalf("list tools: %v", err)
+	}
+	for _, tool := range tools.Tools {
+		// 预定义查询列表不需要请求上游
+		if tool.Name == "prometheus_list_common_metrics" {
+			continue
+		}
+		t.Run(tool.Name, func(t *testing.T) {
+			result, err := session.CallTool(ctx, &mcp.CallToolParams{Name: tool.Name, Arguments: map[string]any{}})
+			if err != nil {
+				t.Fatalf("call tool: %v", err)
+			}
+			text := result.Content[0].(*mcp.TextContent).Text
+			if !result.IsError || !strings.Contains(text, "客户端不可用") {
+				t.Errorf("result = %q (IsError=%v), want service unavailable error", text, result.IsError)
+			}
+		})
+	}
+
+	service := &serviceImpl{server: server}
+	var unavailable *core.ServiceUnavailableError
+	if err := service.TestConnection(ctx); !errors.As(err, &unavailable) {
+		t.Errorf("TestConnection() = %v, want ServiceUnavailableError", err)
+	}
+}
+
+func TestOptionalToolsFollowConfig(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  config.PrometheusConfig
+		want bool
+	}{
+		{name: "disabled", cfg: config.PrometheusConfig{}, want: false},
+		{name: "enabled", cfg: config.PrometheusConfig{EnableRawAPI: true, EnableAdminAPI: true}, want: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := tc.cfg
+			cfg.URL = "http://prometheus.invalid"
+			service, err := CreateService(&cfg, time.Second)
+			if err != nil {
+				t.Fatalf("CreateService: %v", err)
+			}
+			names := service.(core.ToolLister).ToolNames()
+			for _, tool := range []string{"prometheus_raw_api", "prometheus_reload"} {
+				if got := slices.Contains(names, tool); got != tc.want {
+					t.Errorf("%s registered = %v, want %v", tool, got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryExplainsTightClientDeadline(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}), ClientOptions{})
+	opts := &toolOptions{queryTimeout: 30 * time.Second}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	result, err := createQueryHandler(client, opts)(ctx, nil, &mcp.CallToolParamsFor[QueryParams]{
+		Arguments: QueryParams{Query: "up"},
+	})
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	text := result.Content[0].(*mcp.TextContent).Text
+	if !result.IsError || !strings.Contains(text, "调用方") {
+		t.Errorf("result = %q (IsError=%v), want client deadline explanation", text, result.IsError)
+	}
+}
+
+// rangeQueryUpstream 返回空矩阵的范围查询上游，记录收到的step参数
+func rangeQueryUpstream(t *testing.T, steps *[]string) *Client {
+	t.Helper()
+	return newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		*steps = append(*steps, r.Form.Get("step"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"matrix","result":[]}}`))
+	}), ClientOptions{})
+}
+
+func TestQueryRangeWindowLimit(t *testing.T) {
+	var steps []string
+	client := rangeQueryUpstream(t, &steps)
+	handler := createQueryRangeHandler(client, &toolOptions{rangeQueryTimeout: 5 * time.Second, maxRangeWindow: 24 * time.Hour})
+
+	call := func(start, end string) *mcp.CallToolResultFor[any] {
+		t.Helper()
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[QueryRangeParams]{
+			Arguments: QueryRangeParams{Query: "up", StartTime: start, EndTime: end, Step: "5m"},
+		})
+		if err != nil {
+			t.Fatalf("handler: %v", err)
+		}
+		return result
+	}
+
+	if result := call("2024-01-01T00:00:00Z", "2024-01-02T00:00:00Z"); result.IsError {
+		t.Errorf("window equal to the limit rejected: %s", result.Content[0].(*mcp.TextContent).Text)
+	}
+	if len(steps) != 1 {
+		t.Fatalf("upstream requests = %d, want 1", len(steps))
+	}
+
+	result := call("2024-01-01T00:00:00Z", "2024-01-03T00:00:00Z")
+	if text := result.Content[0].(*mcp.TextContent).Text; !result.IsError || !strings.Contains(text, "超过上限") {
+		t.Errorf("over-limit window: result = %q (IsError=%v), want limit error", text, result.IsError)
+	}
+	if len(steps) != 1 {
+		t.Errorf("over-limit window reached upstream")
+	}
+}
+
+func TestListCommonMetricsReturnsAllQueries(t *testing.T) {
+	opts := newToolOptions(&config.PrometheusConfig{CommonMetrics: map[string]string{
+		"cpu":    "sum(rate(custom_cpu_seconds_total[5m]))",
+		"custom": "sum(up)",
+	}})
+
+	result, err := createListCommonMetricsHandler(opts)(context.Background(), nil, &mcp.CallToolParamsFor[ListCommonMetricsParams]{})
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	var listed struct {
+		Count   int                `json:"count"`
+		Metrics []commonMetricInfo `json:"metrics"`
+	}
+	if err := json.Unmarshal([]byte(result.Content[0].(*mcp.TextContent).Text), &listed); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+
+	want := make(map[string]string, len(MetricQueries)+1)
+	for metricType, query := range MetricQueries {
+		want[metricType] = renderMetricQuery(query, defaultDiskMountpoint)
+	}
+	want["cpu"] = "sum(rate(custom_cpu_seconds_total[5m]))"
+	want["custom"] = "sum(up)"
+
+	if listed.Count != len(want) || len(listed.Metrics) != len(want) {
+		t.Fatalf("count = %d, metrics = %d, want %d", listed.Count, len(listed.Metrics), len(want))
+	}
+	for i, metric := range listed.Metrics {
+		if query, ok := want[metric.MetricType]; !ok || query != metric.Query {
+			t.Errorf("metric %s = %q, want %q", metric.MetricType, metric.Query, query)
+		}
+		if i > 0 && listed.Metrics[i-1].MetricType >= metric.MetricType {
+			t.Errorf("metrics not sorted: %s before %s", listed.Metrics[i-1].MetricType, metric.MetricType)
+		}
+	}
+}
+
+func TestCommonMetricsDiskMountpoint(t *testing.T) {
+	var queries []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		queries = append(queries, r.Form.Get("query"))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}), ClientOptions{})
+	handler := createCommonMetricsHandler(client, newToolOptions(&config.PrometheusConfig{DiskMountpoint: "/var"}))
+
+	call := func(params CommonMetricsParams) *mcp.CallToolResultFor[any] {
+		t.Helper()
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[CommonMetricsParams]{Arguments: params})
+		if err != nil {
+			t.Fatalf("handler: %v", err)
+		}
+		return result
+	}
+
+	call(CommonMetricsParams{MetricType: "disk", Mountpoint: "/data"})
+	call(CommonMetricsParams{MetricType: "disk"})
+	if len(queries) != 2 {
+		t.Fatalf("upstream queries = %v, want 2", queries)
+	}
+	if want := `node_filesystem_avail_bytes{mountpoint="/data"}`; !strings.Contains(queries[0], want) || strings.Contains(queries[0], mountpointPlaceholder) {
+		t.Errorf("query = %q, want custom mountpoint %s", queries[0], want)
+	}
+	if want := `mountpoint="/var"`; !strings.Contains(queries[1], want) {
+		t.Errorf("query = %q, want configured default %s", queries[1], want)
+	}
+
+	// 不含挂载点占位符的类型拒绝mountpoint参数
+	if result := call(CommonMetricsParams{MetricType: "cpu", Mountpoint: "/data"}); !result.IsError {
+		t.Error("cpu with mountpoint succeeded, want error")
+	}
+	if len(queries) != 2 {
+		t.Errorf("rejected request reached upstream")
+	}
+}
+
+func TestCommonMetricsAllReportsPerTypeErrors(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.Form.Get("query") == "broken_metric" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"status":"error","errorType":"bad_data","error":"unknown metric"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"node"},"value":[1700000000,"1"]}]}}`))
+	}), ClientOptions{})
+	opts := &toolOptions{
+		queryTimeout:  5 * time.Second,
+		nanMode:       nanModeString,
+		metricQueries: map[string]string{"up": "up", "broken": "broken_metric"},
+	}
+
+	result, err := createCommonMetricsAllHandler(client, opts)(context.Background(), nil, &mcp.CallToolParamsFor[CommonMetricsAllParams]{})
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	text := result.Content[0].(*mcp.TextContent).Text
+	if result.IsError {
+		t.Fatalf("handler error: %s", text)
+	}
+
+	var results map[string]struct {
+		Query  string            `json:"query"`
+		Result []normalizedPoint `json:"result"`
+		Error  string            `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(text), &results); err != nil {
+		t.Fatalf("unmarshal %s: %v", text, err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("results = %v, want both types", results)
+	}
+	if up := results["up"]; up.Error != "" || len(up.Result) != 1 || up.Query != "up" {
+		t.Errorf("up = %+v, want one sample and no error", up)
+	}
+	if broken := results["broken"]; !strings.Contains(broken.Error, "unknown metric") || broken.Result != nil {
+		t.Errorf("broken = %+v, want the upstream error", broken)
+	}
+}
+
+func TestRulesFilteredByTeamLabel(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"groups":[
+			{"name":"payments","file":"payments.yml","interval":30,"rules":[
+				{"type":"alerting","name":"PaymentsDown","query":"up{job=\"payments\"} == 0","duration":60,"labels":{"team":"payments","severity":"page"},"annotations":{},"alerts":[],"health":"ok","state":"inactive"},
+				{"type":"recording","name":"job:payments:rate5m","query":"rate(payments_total[5m])","labels":{"team":"payments"},"health":"ok"}
+			]},
+			{"name":"search","file":"search.yml","interval":30,"rules":[
+				{"type":"alerting","name":"SearchDown","query":"up{job=\"search\"} == 0","duration":60,"labels":{"team":"search"},"annotations":{},"alerts":[],"health":"ok","state":"inactive"}
+			]}
+		]}}`))
+	}), ClientOptions{})
+	handler := createRulesHandler(client, &toolOptions{queryTimeout: 5 * time.Second})
+
+	result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[RulesParams]{
+		Arguments: RulesParams{LabelSelector: map[string]string{"team": "payments"}},
+	})
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	text := result.Content[0].(*mcp.TextContent).Text
+	if result.IsError {
+		t.Fatalf("handler error: %s", text)
+	}
+
+	var rules struct {
+		GroupCount     int             `json:"group_count"`
+		AlertingCount  int             `json:"alerting_count"`
+		RecordingCount int             `json:"recording_count"`
+		Groups         []ruleGroupInfo `json:"groups"`
+	}
+	if err := json.Unmarshal([]byte(text), &rules); err != nil {
+		t.Fatalf("unmarshal %s: %v", text, err)
+	}
+	// 只保留payments团队的告警规则，记录规则和无匹配规则的规则组被省略
+	if rules.GroupCount != 1 || rules.AlertingCount != 1 || rules.RecordingCount != 0 {
+		t.Fatalf("counts = %d groups, %d alerting, %d recording; want 1, 1, 0", rules.GroupCount, rules.AlertingCount, rules.RecordingCount)
+	}
+	group := rules.Groups[0]
+	if group.Name != "payments" || len(group.AlertingRules) != 1 || group.AlertingRules[0].Name != "PaymentsDown" {
+		t.Errorf("group = %+v, want PaymentsDown only", group)
+	}
+
+	// 没有规则匹配时返回空列表而不是错误
+	result, err = handler(context.Background(), nil, &mcp.CallToolParamsFor[RulesParams]{
+		Arguments: RulesParams{LabelSelector: map[string]string{"team": "billing"}},
+	})
+	if err != nil || result.IsError {
+		t.Fatalf("unmatched selector: err = %v, result = %+v", err, result)
+	}
+	if text := result.Content[0].(*mcp.TextContent).Text; !strings.Contains(text, `"group_count":0`) {
+		t.Errorf("unmatched selector: %s, want no groups", text)
+	}
+}
+
+func TestQueryRangeDefaultStep(t *testing.T) {
+	var steps []string
+	client := rangeQueryUpstream(t, &steps)
+	opts := newToolOptions(&config.PrometheusConfig{DefaultStep: 2 * time.Minute})
+	handler := createQueryRangeHandler(client, opts)
+
+	// 未指定step时使用default_step，显式指定时覆盖默认值
+	for _, step := range []string{"", "30s"} {
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[QueryRangeParams]{
+			Arguments: QueryRangeParams{Query: "up", StartTime: "2024-01-01T00:00:00Z", EndTime: "2024-01-01T01:00:00Z", Step: step},
+		})
+		if err != nil {
+			t.Fatalf("step %q: handler: %v", step, err)
+		}
+		if result.IsError {
+			t.Fatalf("step %q: %s", step, result.Content[0].(*mcp.TextContent).Text)
+		}
+	}
+	if want := []string{"120", "30"}; !slices.Equal(steps, want) {
+		t.Errorf("upstream steps = %v, want %v", steps, want)
+	}
+}
+
+func TestSeriesCursorPaging(t *testing.T) {
+	const total = 1200
+	var upstreamCalls int
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamCalls++
+		series := make([]map[string]string, 0, total)
+		for i := range total {
+			series = append(series, map[string]string{"__name__": "up", "instance": fmt.Sprintf("host-%04d", i)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]any{"status": "success", "data": series}); err != nil {
+			t.Errorf("encode: %v", err)
+		}
+	}), ClientOptions{})
+	handler := createSeriesHandler(client, &toolOptions{listMetricsTimeout: 5 * time.Second}, newSeriesCache())
+
+	type seriesPage struct {
+		Count      int                 `json:"count"`
+		Total      int                 `json:"total"`
+		Offset     int                 `json:"offset"`
+		HasMore    bool                `json:"has_more"`
+		Truncated  bool                `json:"truncated"`
+		NextCursor string              `json:"next_cursor"`
+		Series     []map[string]string `json:"series"`
+	}
+	call := func(args SeriesParams) seriesPage {
+		t.Helper()
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[SeriesParams]{Arguments: args})
+		if err != nil {
+			t.Fatalf("handler: %v", err)
+		}
+		text := result.Content[0].(*mcp.TextContent).Text
+		if result.IsError {
+			t.Fatalf("handler error: %s", text)
+		}
+		var page seriesPage
+		if err := json.Unmarshal([]byte(text), &page); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		return page
+	}
+
+	page := call(SeriesParams{Match: "up", StartTime: "2024-01-01T00:00:00Z", EndTime: "2024-01-01T01:00:00Z", Limit: maxSeriesResults})
+	var seen []string
+	var counts []int
+	for {
+		counts = append(counts, page.Count)
+		for _, labels := range page.Series {
+			seen = append(seen, labels["instance"])
+		}
+		if page.Total != total || page.Truncated || page.HasMore != (page.NextCursor != "") {
+			t.Fatalf("page = total %d, truncated %v, has_more %v, next_cursor %q", page.Total, page.Truncated, page.HasMore, page.NextCursor)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		page = call(SeriesParams{Cursor: page.NextCursor})
+	}
+
+	// 续页从缓存读取，所有序列按顺序各返回一次
+	if want := []int{500, 500, 200}; !slices.Equal(counts, want) {
+		t.Errorf("page sizes = %v, want %v", counts, want)
+	}
+	if len(seen) != total || seen[0] != "host-0000" || seen[total-1] != fmt.Sprintf("host-%04d", total-1) {
+		t.Errorf("paged %d series from %q to %q, want all %d in order", len(seen), seen[0], seen[len(seen)-1], total)
+	}
+	if upstreamCalls != 1 {
+		t.Errorf("upstream calls = %d, want 1", upstreamCalls)
+	}
+
+	// 无效游标返回错误
+	result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[SeriesParams]{Arguments: SeriesParams{Cursor: "not-a-cursor"}})
+	if err != nil || !result.IsError {
+		t.Errorf("invalid cursor: result = %+v, err = %v, want error result", result, err)
+	}
+}
+
+func TestCounterIncreaseExecutesQuery(t *testing.T) {
+	var queries []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/metadata":
+			metricType := "counter"
+			if r.Form.Get("metric") == "node_load1" {
+				metricType = "gauge"
+			}
+			_, _ = fmt.Fprintf(w, `{"status":"success","data":{%q:[{"type":%q,"help":"","unit":""}]}}`, r.Form.Get("metric"), metricType)
+		case "/api/v1/query":
+			queries = append(queries, r.Form.Get("query"))
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{"job":"api"},"value":[1700000000,"42"]}]}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}), ClientOptions{})
+	handler := createCounterIncreaseHandler(client, &toolOptions{queryTimeout: 5 * time.Second, listMetricsTimeout: 5 * time.Second})
+
+	result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[CounterIncreaseParams]{
+		Arguments: CounterIncreaseParams{Metric: "http_requests_total", Window: "30m", Filters: map[string]string{"job": "api"}},
+	})
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	text := result.Content[0].(*mcp.TextContent).Text
+	if result.IsError {
+		t.Fatalf("handler error: %s", text)
+	}
+	want := `increase(http_requests_total{job="api"}[30m])`
+	if !slices.Equal(queries, []string{want}) {
+		t.Errorf("upstream queries = %v, want [%s]", queries, want)
+	}
+	if !strings.Contains(text, `"value":42`) {
+		t.Errorf("result = %s, want the increase value", text)
+	}
+
+	// 非counter类型的指标不执行查询
+	result, err = handler(context.Background(), nil, &mcp.CallToolParamsFor[CounterIncreaseParams]{
+		Arguments: CounterIncreaseParams{Metric: "node_load1"},
+	})
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	if text := result.Content[0].(*mcp.TextContent).Text; !result.IsError || !strings.Contains(text, "gauge") {
+		t.Errorf("gauge metric: result = %q (IsError=%v), want type error", text, result.IsError)
+	}
+	if len(queries) != 1 {
+		t.Errorf("gauge metric reached the query API")
+	}
+}
+
+func TestQueryScalarOnly(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.Form.Get("query") == "count(up)" {
+			_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"3"]}]}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[
+			{"metric":{"__name__":"up","instance":"a"},"value":[1700000000,"1"]},
+			{"metric":{"__name__":"up","instance":"b"},"value":[1700000000,"0"]}
+		]}}`))
+	}), ClientOptions{})
+	handler := createQueryHandler(client, &toolOptions{queryTimeout: 5 * time.Second})
+	call := func(query string) (string, bool) {
+		t.Helper()
+		result, err := handler(context.Background(), nil, &mcp.CallToolParamsFor[QueryParams]{
+			Arguments: QueryParams{Query: query, ScalarOnly: true},
+		})
+		if err != nil {
+			t.Fatalf("handler: %v", err)
+		}
+		return result.Content[0].(*mcp.TextContent).Text, result.IsError
+	}
+
+	// 单一结果只返回 {value, timestamp}
+	text, isError := call("count(up)")
+	if isError {
+		t.Fatalf("single result: %s", text)
+	}
+	var point map[string]any
+	if err := json.Unmarshal([]byte(text), &point); err != nil {
+		t.Fatalf("unmarshal %s: %v", text, err)
+	}
+	if len(point) != 2 || point["value"] != float64(3) || point["timestamp"] == "" {
+		t.Errorf("single result = %s, want only value 3 and a timestamp", text)
+	}
+
+	// 多条序列时报错并提示聚合
+	text, isError = call("up")
+	if !isError || !strings.Contains(text, "2条序列") {
+		t.Errorf("multiple results: %q (IsError=%v), want series count error", text, isError)
+	}
+}
+
+func TestLabelValuesCapAndTruncatedFlag(t *testing.T) {
+	const total = 1500
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 上游按逆序返回，处理器排序后再截断
+		values := make([]string, 0, total)
+		for i := total - 1; i >= 0; i-- {
+			values = append(values, fmt.Sprintf("pod-%04d", i))
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(map[string]any{"status": "success", "data": values}); err != nil {
+			t.Errorf("encode: %v", err)
+		}
+	}), ClientOptions{})
+
+	for _, tc := range []struct {
+		name      string
+		limit     int
+		wantCount int
+		truncated bool
+	}{
+		{"default limit", 0, defaultLabelValuesLimit, true},
+		{"configured limit", 200, 200, true},
+		{"limit above total", 2000, total, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := newToolOptions(&config.PrometheusConfig{LabelValuesLimit: tc.limit})
+			result, err := createLabelValuesHandler(client, opts)(context.Background(), nil, &mcp.CallToolParamsFor[LabelValuesParams]{
+				Arguments: LabelValuesParams{Label: "pod", Match: `up{job="k8s"}`},
+			})
+			if err != nil {
+				t.Fatalf("handler: %v", err)
+			}
+			text := result.Content[0].(*mcp.TextContent).Text
+			if result.IsError {
+				t.Fatalf("handler error: %s", text)
+			}
+
+			var labelValues struct {
+				Count     int      `json:"count"`
+				Total     int      `json:"total"`
+				Truncated bool     `json:"truncated"`
+				Values    []string `json:"values"`
+			}
+			if err := json.Unmarshal([]byte(text), &labelValues); err != nil {
+				t.Fatalf("unmarshal: %v", err)
+			}
+			if labelValues.Count != tc.wantCount || len(labelValues.Values) != tc.wantCount || labelValues.Total != total || labelValues.Truncated != tc.truncated {
+				t.Errorf("count = %d (%d values), total = %d, truncated = %v; want %d, %d, %v",
+					labelValues.Count, len(labelValues.Values), labelValues.Total, labelValues.Truncated, tc.wantCount, total, tc.truncated)
+			}
+			if len(labelValues.Values) > 0 && labelValues.Values[0] != "pod-0000" {
+				t.Errorf("first value = %q, want pod-0000 after sorting", labelValues.Values[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package prometheus
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+)
+
+// 常用指标查询模板中的挂载点占位符，替换为带引号的PromQL字符串
+const (
+	mountpointPlaceholder = "$mountpoint"
+	defaultDiskMountpoint = "/"
+)
+
+// renderMetricQuery 将查询模板中的挂载点占位符替换为指定挂载点
+func renderMetricQuery(query, mountpoint string) string {
+	return strings.ReplaceAll(query, mountpointPlaceholder, strconv.Quote(mountpoint))
+}
+
+// hasMountpointPlaceholder 判断查询模板是否支持挂载点参数
+func hasMountpointPlaceholder(query string) bool {
+	return strings.Contains(query, mountpointPlaceholder)
+}
+
+// modifierVisitor 为表达式中的选择器和子查询添加offset/@修饰符
+type modifierVisitor struct {
+	offset time.Duration
+	at     *int64 // 毫秒时间戳
+}
+
+// Visit 实现parser.Visitor接口
+func (v *modifierVisitor) Visit(node parser.Node, _ []parser.Node) (parser.Visitor, error) {
+	switch n := node.(type) {
+	case *parser.VectorSelector:
+		v.apply(&n.OriginalOffset, &n.Timestamp, n.StartOrEnd)
+	case *parser.SubqueryExpr:
+		// 子查询整体应用修饰符，避免内部选择器重复偏移
+		v.apply(&n.OriginalOffset, &n.Timestamp, n.StartOrEnd)
+		return nil, nil
+	}
+	return v, nil
+}
+
+// apply 仅在未显式指定时设置修饰符，不覆盖用户原有的设置
+func (v *modifierVisitor) apply(offset *time.Duration, timestamp **int64, startOrEnd parser.ItemType) {
+	if v.offset != 0 && *offset == 0 {
+		*offset = v.offset
+	}
+	if v.at != nil && *timestamp == nil && startOrEnd == 0 {
+		at := *v.at
+		*timestamp = &at
+	}
+}
+
+// applyModifiers 为PromQL表达式添加offset和@修饰符，并通过解析器校验结果
+func applyModifiers(query string, offset time.Duration, at *time.Time) (string, error) {
+	expr, err := parser.ParseExpr(query)
+	if err != nil {
+		return "", fmt.Errorf("PromQL解析失败: %w", err)
+	}
+
+	visitor := &modifierVisitor{offset: offset}
+	if at != nil {
+		ms := at.UnixMilli()
+		visitor.at = &ms
+	}
+
+	if err := parser.Walk(visitor, expr, nil); err != nil {
+		return "", fmt.Errorf("添加修饰符失败: %w", err)
+	}
+
+	modified := expr.String()
+	if _, err := parser.ParseExpr(modified); err != nil {
+		return "", fmt.Errorf("生成的PromQL无效: %w", err)
+	}
+
+	return modified, nil
+}
+
+// newDefaultMatchers 将默认标签匹配配置按标签名排序后转换为等值匹配器
+func newDefaultMatchers(matchers map[string]string) ([]*labels.Matcher, error) {
+	names := make([]string, 0, len(matchers))
+	for name := range matchers {
+		if !model.LabelName(name).IsValidLegacy() || name == model.MetricNameLabel {
+			return nil, fmt.Errorf("无效的默认匹配标签: %s", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]*labels.Matcher, 0, len(names))
+	for _, name := range names {
+		matcher, err := labels.NewMatcher(labels.MatchEqual, name, matchers[name])
+		if err != nil {
+			return nil, fmt.Errorf("无效的默认匹配器 %s: %w", name, err)
+		}
+		result = append(result, matcher)
+	}
+	return result, nil
+}
+
+// matcherVisitor 为表达式中的每个选择器注入默认匹配器
+type matcherVisitor struct {
+	matchers []*labels.Matcher
+}
+
+// Visit 实现parser.Visitor接口
+func (v *matcherVisitor) Visit(node parser.Node, _ []parser.Node) (parser.Visitor, error) {
+	if selector, ok := node.(*parser.VectorSelector); ok {
+		for _, matcher := range v.matchers {
+			// 用户已对该标签设置匹配条件时保留原条件
+			if !slices.ContainsFunc(selector.LabelMatchers, func(m *labels.Matcher) bool { return m.Name == matcher.Name }) {
+				selector.LabelMatchers = append(selector.LabelMatchers, matcher)
+			}
+		}
+	}
+	return v, nil
+}
+
+// matchersSelector 生成仅包含给定匹配器的序列选择器，例如 {cluster="prod"}
+func matchersSelector(matchers []*labels.Matcher) string {
+	selector := &parser.VectorSelector{LabelMatchers: matchers}
+	return selector.String()
+}
+
+// injectMatchers 解析PromQL并为其中所有选择器注入默认匹配器，matchers为空时原样返回
+func injectMatchers(query string, matchers []*labels.Matcher) (string, error) {
+	if len(matchers) == 0 {
+		return query, nil
+	}
+
+	expr, err := parser.ParseExpr(query)
+	if err != nil {
+		return "", fmt.Errorf("PromQL解析失败: %w", err)
+	}
+	if err := parser.Walk(&matcherVisitor{matchers: matchers}, expr, nil); err != nil {
+		return "", fmt.Errorf("注入默认匹配器失败: %w", err)
+	}
+	return expr.String(), nil
+}
+
+// 直方图分位数查询相关常量
+const (
+	bucketSuffix                = "_bucket"
+	defaultHistogramRangeWindow = "5m"
+)
+
+// buildHistogramQuantile 构建 histogram_quantile(q, sum by (le) (rate(<metric>_bucket{filters}[window])))
+// metric可以是基础名称或带_bucket后缀的名称，结果经解析器校验
+func buildHistogramQuantile(metric string, quantile float64, window string, filters map[string]string) (string, error) {
+	if quantile < 0 || quantile > 1 {
+		return "", fmt.Errorf("分位数应在0到1之间: %v", quantile)
+	}
+
+	metric = strings.TrimSuffix(metric, bucketSuffix)
+	if !model.IsValidLegacyMetricName(metric) {
+		return "", fmt.Errorf("无效的指标名称: %s", metric)
+	}
+
+	if window == "" {
+		window = defaultHistogramRangeWindow
+	}
+	if _, err := model.ParseDuration(window); err != nil {
+		return "", fmt.Errorf("无效的时间窗口: %s", window)
+	}
+
+	matchers, err := buildMatchers(filters, model.BucketLabel)
+	if err != nil {
+		return "", err
+	}
+
+	query := fmt.Sprintf("histogram_quantile(%s, sum by (le) (rate(%s%s{%s}[%s])))",
+		strconv.FormatFloat(quantile, 'g', -1, 64), metric, bucketSuffix, matchers, window)
+
+	expr, err := parser.ParseExpr(query)
+	if err != nil {
+		return "", fmt.Errorf("生成的PromQL无效: %w", err)
+	}
+	return expr.String(), nil
+}
+
+// defaultIncreaseWindow 计数器增量查询的默认时间窗口
+const defaultIncreaseWindow = "1h"
+
+// buildCounterIncrease 构建 increase(<metric>{filters}[window])，结果经解析器校验
+func buildCounterIncrease(metric, window string, filters map[string]string) (string, error) {
+	if !model.IsValidLegacyMetricName(metric) {
+		return "", fmt.Errorf("无效的指标名称: %s", metric)
+	}
+
+	if window == "" {
+		window = defaultIncreaseWindow
+	}
+	if _, err := model.ParseDuration(window); err != nil {
+		return "", fmt.Errorf("无效的时间窗口: %s", window)
+	}
+
+	matchers, err := buildMatchers(filters)
+	if err != nil {
+		return "", err
+	}
+
+	query := fmt.Sprintf("increase(%s{%s}[%s])", metric, matchers, window)
+
+	expr, err := parser.ParseExpr(query)
+	if err != nil {
+		return "", fmt.Errorf("生成的PromQL无效: %w", err)
+	}
+	return expr.String(), nil
+}
+
+// buildMatchers 将标签过滤条件按标签名排序后拼接为选择器内的匹配器，__name__和reserved中的标签不允许过滤
+func buildMatchers(filters map[string]string, reserved ...string) (string, error) {
+	names := make([]string, 0, len(filters))
+	for name := range filters {
+		if !model.LabelName(name).IsValidLegacy() || name == model.MetricNameLabel || slices.Contains(reserved, name) {
+			return "", fmt.Errorf("无效的过滤标签: %s", name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	matchers := make([]string, 0, len(names))
+	for _, name := range names {
+		matchers = append(matchers, name+"="+strconv.Quote(filters[name]))
+	}
+	return strings.Join(matchers, ","), nil
+}
+
+// parseOffset 解析PromQL风格的偏移时长 (例如: 5m, 1h, 1d)
+func parseOffset(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	d, err := model.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(d), nil
+}
+
+// parseEvalTime 解析RFC3339时间或Unix时间戳(秒)
+func parseEvalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("应为RFC3339格式或Unix时间戳: %s", value)
+	}
+	t := time.UnixMilli(int64(seconds * 1000)).UTC()
+	return &t, nil
+}
@@ -0,0 +1,182 @@
+package prometheus
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInjectMatchers(t *testing.T) {
+	matchers, err := newDefaultMatchers(map[string]string{"cluster": "prod"})
+	if err != nil {
+		t.Fatalf("newDefaultMatchers: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "simple",
+			query: "up",
+			want:  `up{cluster="prod"}`,
+		},
+		{
+			name:  "aggregated",
+			query: `sum by (job) (rate(http_requests_total{code="500"}[5m])) / sum by (job) (rate(http_requests_total[5m]))`,
+			want:  `sum by (job) (rate(http_requests_total{cluster="prod",code="500"}[5m])) / sum by (job) (rate(http_requests_total{cluster="prod"}[5m]))`,
+		},
+		{
+			name:  "label already specified",
+			query: `up{cluster="staging"}`,
+			want:  `up{cluster="staging"}`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := injectMatchers(tc.query, matchers)
+			if err != nil {
+				t.Fatalf("injectMatchers(%q): %v", tc.query, err)
+			}
+			if got != tc.want {
+				t.Errorf("injectMatchers(%q) = %q, want %q", tc.query, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestApplyModifiers(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+
+	for _, tc := range []struct {
+		name   string
+		query  string
+		offset time.Duration
+		at     *time.Time
+		want   string
+	}{
+		{
+			name:   "offset only",
+			query:  `rate(http_requests_total[5m])`,
+			offset: time.Hour,
+			want:   `rate(http_requests_total[5m] offset 1h)`,
+		},
+		{
+			name:  "at only",
+			query: `up`,
+			at:    &at,
+			want:  `up @ 1700000000.000`,
+		},
+		{
+			name:   "offset and at",
+			query:  `sum(up)`,
+			offset: 30 * time.Minute,
+			at:     &at,
+			want:   `sum(up @ 1700000000.000 offset 30m)`,
+		},
+		{
+			name:   "explicit offset kept",
+			query:  `up offset 5m`,
+			offset: time.Hour,
+			want:   `up offset 5m`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := applyModifiers(tc.query, tc.offset, tc.at)
+			if err != nil {
+				t.Fatalf("applyModifiers(%q): %v", tc.query, err)
+			}
+			if got != tc.want {
+				t.Errorf("applyModifiers(%q) = %q, want %q", tc.query, got, tc.want)
+			}
+		})
+	}
+
+	if _, err := applyModifiers(`up{`, time.Hour, nil); err == nil {
+		t.Error("applyModifiers accepted an invalid expression")
+	}
+}
+
+func TestBuildHistogramQuantile(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		metric  string
+		q       float64
+		window  string
+		filters map[string]string
+		want    string
+	}{
+		{
+			name:   "base name with filters",
+			metric: "http_request_duration_seconds",
+			q:      0.99,
+			window: "10m",
+			filters: map[string]string{
+				"service": "api",
+				"code":    "200",
+			},
+			want: `histogram_quantile(0.99, sum by (le) (rate(http_request_duration_seconds_bucket{code="200",service="api"}[10m])))`,
+		},
+		{
+			name:   "bucket suffix and default window",
+			metric: "http_request_duration_seconds_bucket",
+			q:      0.5,
+			want:   `histogram_quantile(0.5, sum by (le) (rate(http_request_duration_seconds_bucket[5m])))`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := buildHistogramQuantile(tc.metric, tc.q, tc.window, tc.filters)
+			if err != nil {
+				t.Fatalf("buildHistogramQuantile: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("got  %s\nwant %s", got, tc.want)
+			}
+		})
+	}
+
+	for _, tc := range []struct {
+		name    string
+		metric  string
+		q       float64
+		window  string
+		filters map[string]string
+	}{
+		{name: "quantile out of range", metric: "latency", q: 1.5},
+		{name: "invalid metric", metric: "bad-name", q: 0.9},
+		{name: "invalid window", metric: "latency", q: 0.9, window: "five minutes"},
+		{name: "le filter", metric: "latency", q: 0.9, filters: map[string]string{"le": "0.1"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, err := buildHistogramQuantile(tc.metric, tc.q, tc.window, tc.filters); err == nil {
+				t.Errorf("buildHistogramQuantile = %q, want error", got)
+			}
+		})
+	}
+}
+
+func TestBuildCounterIncrease(t *testing.T) {
+	got, err := buildCounterIncrease("http_requests_total", "", map[string]string{"job": "api", "code": "500"})
+	if err != nil {
+		t.Fatalf("buildCounterIncrease: %v", err)
+	}
+	if want := `increase(http_requests_total{code="500",job="api"}[1h])`; got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		metric  string
+		window  string
+		filters map[string]string
+	}{
+		{name: "invalid metric", metric: "bad-name"},
+		{name: "invalid window", metric: "http_requests_total", window: "an hour"},
+		{name: "name filter", metric: "http_requests_total", filters: map[string]string{"__name__": "other"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, err := buildCounterIncrease(tc.metric, tc.window, tc.filters); err == nil {
+				t.Errorf("buildCounterIncrease = %q, want error", got)
+			}
+		})
+	}
+}
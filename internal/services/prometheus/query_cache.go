@@ -0,0 +1,129 @@
+package prometheus
+
+import (
+	"container/list"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// maxQueryCacheEntries 即时查询结果缓存的最大条目数，超过后淘汰最久未使用的条目
+const maxQueryCacheEntries = 256
+
+// queryCache 即时查询结果的LRU缓存，按查询语句和时间桶缓存
+// 时间桶按TTL对齐，桶切换后旧条目不再命中，缓存结果的存活时间不会超过TTL
+// 只缓存以当前时间求值的即时查询；范围查询的结果随结束时间变化，不经过缓存
+type queryCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	order   *list.List // 队首为最近使用的条目
+	entries map[string]*list.Element
+}
+
+// queryCacheEntry 缓存条目
+type queryCacheEntry struct {
+	key       string
+	value     model.Value
+	expiresAt time.Time
+}
+
+// newQueryCache 创建查询缓存，ttl不大于0时返回nil表示不启用
+func newQueryCache(ttl time.Duration) *queryCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &queryCache{
+		ttl:     ttl,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// key 生成缓存键，同一时间桶内的相同查询共享结果
+func (c *queryCache) key(query string, now time.Time) string {
+	return strconv.FormatInt(now.Truncate(c.ttl).UnixNano(), 10) + "\x00" + query
+}
+
+// get 获取未过期的缓存结果
+func (c *queryCache) get(query string, now time.Time) (model.Value, bool) {
+	key := c.key(query, now)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*queryCacheEntry)
+	if !now.Before(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	c.order.MoveToFront(element)
+	return copyValue(entry.value), true
+}
+
+// put 缓存查询结果，超出容量时淘汰最久未使用的条目
+func (c *queryCache) put(query string, now time.Time, value model.Value) {
+	key := c.key(query, now)
+	entry := &queryCacheEntry{
+		key:       key,
+		value:     copyValue(value),
+		expiresAt: now.Truncate(c.ttl).Add(c.ttl),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > maxQueryCacheEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*queryCacheEntry).key)
+	}
+}
+
+// copyValue 深拷贝查询结果的样本、标签和数据点，调用方（如指标重命名）修改结果不影响缓存
+// 原生直方图样本只读，不复制
+func copyValue(value model.Value) model.Value {
+	switch v := value.(type) {
+	case model.Vector:
+		copied := make(model.Vector, 0, len(v))
+		for _, sample := range v {
+			s := *sample
+			s.Metric = sample.Metric.Clone()
+			copied = append(copied, &s)
+		}
+		return copied
+	case model.Matrix:
+		copied := make(model.Matrix, 0, len(v))
+		for _, stream := range v {
+			s := *stream
+			s.Metric = stream.Metric.Clone()
+			s.Values = slices.Clone(stream.Values)
+			s.Histograms = slices.Clone(stream.Histograms)
+			copied = append(copied, &s)
+		}
+		return copied
+	case *model.Scalar:
+		s := *v
+		return &s
+	case *model.String:
+		s := *v
+		return &s
+	}
+	return value
+}
@@ -0,0 +1,73 @@
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+)
+
+// 重试默认配置
+const (
+	defaultRetryAttempts = 3
+	defaultRetryBackoff  = 500 * time.Millisecond
+
+	logPrefixRetry = "Prometheus查询重试 [query=%s]: 第%d/%d次失败: %v，%v后重试"
+)
+
+// withRetry 执行查询，遇到5xx或连接错误时按指数退避重试
+// PromQL解析错误(400)等客户端错误不重试，上下文到期后立即停止
+func (c *Client) withRetry(ctx context.Context, query string, fn func() error) error {
+	attempts := c.retryAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := c.retryBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= attempts || !isRetryable(ctx, err) {
+			return err
+		}
+
+		if !waitBackoff(ctx, backoff) {
+			return err
+		}
+		log.Printf(logPrefixRetry, query, attempt, attempts, err, backoff)
+		backoff *= 2
+	}
+}
+
+// isRetryable 判断错误是否可重试
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *v1.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Type == v1.ErrServer
+	}
+
+	// 非API错误视为连接错误
+	return true
+}
+
+// waitBackoff 等待退避时间，上下文剩余期限不足或已取消时返回false
+func waitBackoff(ctx context.Context, backoff time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+		return false
+	}
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
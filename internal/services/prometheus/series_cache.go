@@ -0,0 +1,123 @@
+package prometheus
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// 序列分页相关常量
+const (
+	// seriesCacheTTL 序列结果的缓存时间，游标在此时间内有效
+	seriesCacheTTL = 5 * time.Minute
+	// maxSeriesCacheEntries 最多缓存的查询结果数，超出时淘汰最早创建的结果
+	maxSeriesCacheEntries = 16
+	// maxCachedSeries 单次查询最多缓存的序列数
+	maxCachedSeries = 50000
+)
+
+// seriesCursor 序列分页游标
+type seriesCursor struct {
+	ID     string `json:"id"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+}
+
+// encode 编码为URL安全的base64字符串
+func (c seriesCursor) encode() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("编码游标失败: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeSeriesCursor 解析序列分页游标
+func decodeSeriesCursor(cursor string) (seriesCursor, error) {
+	var c seriesCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(cursor))
+	if err != nil {
+		return c, fmt.Errorf("无效的游标: %w", err)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("无效的游标: %w", err)
+	}
+	if c.ID == "" || c.Limit <= 0 || c.Offset < 0 {
+		return c, fmt.Errorf("无效的游标: 缺少必要字段")
+	}
+	return c, nil
+}
+
+// cachedSeries 缓存的序列查询结果
+type cachedSeries struct {
+	series    []model.LabelSet
+	total     int // 上游返回的序列总数，超过maxCachedSeries时大于len(series)
+	createdAt time.Time
+}
+
+// seriesCache 短时缓存完整的序列查询结果，支撑客户端游标分页
+// Prometheus序列API本身不支持分页，续页从缓存读取，保证翻页期间结果一致
+type seriesCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedSeries
+}
+
+// newSeriesCache 创建序列缓存
+func newSeriesCache() *seriesCache {
+	return &seriesCache{entries: make(map[string]cachedSeries)}
+}
+
+// put 缓存查询结果，返回缓存ID和缓存的结果
+func (c *seriesCache) put(series []model.LabelSet, now time.Time) (string, cachedSeries) {
+	entry := cachedSeries{series: series, total: len(series), createdAt: now}
+	if len(series) > maxCachedSeries {
+		entry.series = series[:maxCachedSeries]
+	}
+
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	id := hex.EncodeToString(buf)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictLocked(now)
+	for len(c.entries) >= maxSeriesCacheEntries {
+		oldestID := ""
+		for entryID, cached := range c.entries {
+			if oldestID == "" || cached.createdAt.Before(c.entries[oldestID].createdAt) {
+				oldestID = entryID
+			}
+		}
+		delete(c.entries, oldestID)
+	}
+	c.entries[id] = entry
+	return id, entry
+}
+
+// get 获取未过期的缓存结果
+func (c *seriesCache) get(id string, now time.Time) (cachedSeries, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.evictLocked(now)
+	entry, ok := c.entries[id]
+	return entry, ok
+}
+
+// evictLocked 清除过期的缓存结果，调用方需持有锁
+func (c *seriesCache) evictLocked(now time.Time) {
+	for id, entry := range c.entries {
+		if now.Sub(entry.createdAt) >= seriesCacheTTL {
+			delete(c.entries, id)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"mcp-server/config"
+	"mcp-server/internal/common"
 	"mcp-server/internal/core"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -16,6 +17,11 @@ type serviceImpl struct {
 	client   *Client
 	server   *mcp.Server
 	endpoint string
+	port     string // 独立监听端口，为空表示使用共享端口
+
+	toolNames     []string // 已注册的工具名称
+	readinessMode core.ReadinessMode
+	labelLookback time.Duration // 完整功能验证查询指标名称时的回溯窗口
 }
 
 // CreateService 创建Prometheus服务实例（工厂函数）
@@ -26,7 +32,23 @@ func CreateService(serviceConfig core.ServiceConfig, timeout time.Duration) (cor
 	}
 
 	// 创建客户端
-	client, err := NewClient(promConfig.URL)
+	client, err := NewClient(promConfig.URL, ClientOptions{
+		MaxConnsPerHost: promConfig.MaxConnsPerHost,
+		RetryAttempts:   promConfig.RetryAttempts,
+		RetryBackoff:    promConfig.RetryBackoff,
+		CacheTTL:        promConfig.CacheTTL,
+		DefaultMatchers: promConfig.DefaultMatchers,
+		Username:        promConfig.Username,
+		Password:        promConfig.Password,
+		BearerToken:     promConfig.BearerToken,
+		FallbackURL:     promConfig.FallbackURL,
+		TLS: common.TLSOptions{
+			CAFile:             promConfig.CAFile,
+			ClientCertFile:     promConfig.ClientCertFile,
+			ClientKeyFile:      promConfig.ClientKeyFile,
+			InsecureSkipVerify: promConfig.InsecureSkipVerify,
+		},
+	})
 	if err != nil {
 		return nil, core.NewServiceCreationError(core.ServiceTypePrometheus, err)
 	}
@@ -37,18 +59,88 @@ func CreateService(serviceConfig core.ServiceConfig, timeout time.Duration) (cor
 		Version: "1.0.0",
 	}, nil)
 
+	opts := newToolOptions(promConfig)
 	service := &serviceImpl{
 		client:   client,
 		server:   server,
 		endpoint: promConfig.GetEndpoint(),
+		port:     promConfig.GetPort(),
+
+		readinessMode: promConfig.GetReadinessMode(),
+		labelLookback: opts.labelLookback,
 	}
 
 	// 注册工具
-	registerTools(server, client)
+	toolNames, err := registerTools(server, client, opts)
+	if err != nil {
+		return nil, core.NewServiceCreationError(core.ServiceTypePrometheus, err)
+	}
+	service.toolNames = toolNames
 
 	return service, nil
 }
 
+// newToolOptions 根据服务配置创建工具处理器配置，未配置的项使用默认值
+func newToolOptions(promConfig *config.PrometheusConfig) *toolOptions {
+	opts := &toolOptions{
+		queryTimeout:       defaultQueryTimeout,
+		rangeQueryTimeout:  defaultRangeQueryTimeout,
+		listMetricsTimeout: defaultListMetricsTimeout,
+		metadataLimit:      defaultMetadataLimit,
+		labelValuesLimit:   defaultLabelValuesLimit,
+		highCardinality:    make(map[string]struct{}, len(promConfig.HighCardinality)),
+		nanMode:            nanModeString,
+		diskMountpoint:     defaultDiskMountpoint,
+		labelLookback:      defaultLabelLookback,
+		metricQueries:      make(map[string]string, len(MetricQueries)),
+	}
+
+	// 复制预定义查询，避免各服务实例共享同一个map；配置的自定义查询同名时覆盖预定义查询
+	for metricType, query := range MetricQueries {
+		opts.metricQueries[metricType] = query
+	}
+	for metricType, query := range promConfig.CommonMetrics {
+		opts.metricQueries[metricType] = query
+	}
+
+	if promConfig.QueryTimeout > 0 {
+		opts.queryTimeout = promConfig.QueryTimeout
+	}
+	if promConfig.RangeQueryTimeout > 0 {
+		opts.rangeQueryTimeout = promConfig.RangeQueryTimeout
+	}
+	if promConfig.ListMetricsTimeout > 0 {
+		opts.listMetricsTimeout = promConfig.ListMetricsTimeout
+	}
+	if promConfig.MetadataLimit > 0 {
+		opts.metadataLimit = promConfig.MetadataLimit
+	}
+	if promConfig.LabelValuesLimit > 0 {
+		opts.labelValuesLimit = promConfig.LabelValuesLimit
+	}
+	for _, label := range promConfig.HighCardinality {
+		opts.highCardinality[label] = struct{}{}
+	}
+	opts.maxRangeWindow = promConfig.MaxRangeWindow
+	opts.enableRawAPI = promConfig.EnableRawAPI
+	opts.enableAdminAPI = promConfig.EnableAdminAPI
+	opts.metricRename = promConfig.MetricRename
+	if promConfig.DiskMountpoint != "" {
+		opts.diskMountpoint = promConfig.DiskMountpoint
+	}
+	if promConfig.NaNHandling != "" {
+		opts.nanMode = promConfig.NaNHandling
+	}
+	if promConfig.LabelLookback > 0 {
+		opts.labelLookback = promConfig.LabelLookback
+	}
+	if promConfig.DefaultStep > 0 {
+		opts.defaultStep = promConfig.DefaultStep
+	}
+
+	return opts
+}
+
 // GetServer 实现Service接口
 func (s *serviceImpl) GetServer() *mcp.Server {
 	return s.server
@@ -57,17 +149,36 @@ func (s *serviceImpl) GetServer() *mcp.Server {
 // TestConnection 实现Service接口
 func (s *serviceImpl) TestConnection(ctx context.Context) error {
 	if s.client == nil {
-		return fmt.Errorf("客户端未初始化")
+		return core.NewServiceUnavailableError(core.ServiceTypePrometheus)
 	}
 	return s.client.TestConnection(ctx)
 }
 
+// ReadinessMode 实现core.FunctionalChecker接口
+func (s *serviceImpl) ReadinessMode() core.ReadinessMode {
+	return s.readinessMode
+}
+
+// CheckFunctionality 实现core.FunctionalChecker接口，在连接测试基础上查询指标名称列表
+func (s *serviceImpl) CheckFunctionality(ctx context.Context) error {
+	if err := s.TestConnection(ctx); err != nil {
+		return err
+	}
+	_, err := s.client.GetMetricNames(ctx, s.labelLookback)
+	return err
+}
+
 // Close 实现Service接口
 func (s *serviceImpl) Close() error {
 	// Prometheus客户端无需特殊清理
 	return nil
 }
 
+// ToolNames 实现core.ToolLister接口
+func (s *serviceImpl) ToolNames() []string {
+	return s.toolNames
+}
+
 // GetType 实现Service接口
 func (s *serviceImpl) GetType() core.ServiceType {
 	return core.ServiceTypePrometheus
@@ -78,41 +189,158 @@ func (s *serviceImpl) GetEndpoint() string {
 	return s.endpoint
 }
 
+// GetPort 实现core.PortProvider接口
+func (s *serviceImpl) GetPort() string {
+	return s.port
+}
+
 // registerTools 注册所有Prometheus工具
-func registerTools(server *mcp.Server, client *Client) {
+func registerTools(server *mcp.Server, client *Client, opts *toolOptions) ([]string, error) {
+	registrar := core.NewToolRegistrar(server)
+	snapshots := newMetricSnapshotStore()
+	seriesPages := newSeriesCache()
+
 	// 注册即时查询工具
-	mcp.AddTool(server, &mcp.Tool{
+	core.AddTool(registrar, &mcp.Tool{
 		Name:        "prometheus_query",
 		Description: "执行Prometheus即时查询",
-	}, createQueryHandler(client))
+	}, createQueryHandler(client, opts))
 
 	// 注册范围查询工具
-	mcp.AddTool(server, &mcp.Tool{
+	core.AddTool(registrar, &mcp.Tool{
 		Name:        "prometheus_query_range",
 		Description: "执行Prometheus范围查询",
-	}, createQueryRangeHandler(client))
+	}, createQueryRangeHandler(client, opts))
 
 	// 注册目标获取工具
-	mcp.AddTool(server, &mcp.Tool{
+	core.AddTool(registrar, &mcp.Tool{
 		Name:        "prometheus_targets",
 		Description: "获取Prometheus监控目标",
-	}, createTargetsHandler(client))
+	}, createTargetsHandler(client, opts))
 
 	// 注册状态检查工具
-	mcp.AddTool(server, &mcp.Tool{
+	core.AddTool(registrar, &mcp.Tool{
 		Name:        "prometheus_status",
 		Description: "检查Prometheus服务状态和连接",
-	}, createStatusHandler(client))
+	}, createStatusHandler(client, opts))
 
 	// 注册常用指标查询工具
-	mcp.AddTool(server, &mcp.Tool{
+	core.AddTool(registrar, &mcp.Tool{
 		Name:        "prometheus_common_metrics",
 		Description: "查询常用Prometheus指标",
-	}, createCommonMetricsHandler(client))
+	}, createCommonMetricsHandler(client, opts))
+
+	// 注册常用指标范围查询工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "prometheus_common_metrics_range",
+		Description: "按指标类型执行常用指标的范围查询，获取历史趋势 (cpu, memory, disk, network, up 及配置的自定义类型)",
+	}, createCommonMetricsRangeHandler(client, opts))
+
+	// 注册批量常用指标查询工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "prometheus_common_metrics_all",
+		Description: "并发查询所有常用指标类型，返回类型到结果的映射（单个类型失败时返回其错误），用于一次性了解节点健康状况",
+	}, createCommonMetricsAllHandler(client, opts))
+
+	// 注册常用指标类型列表工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "prometheus_list_common_metrics",
+		Description: "列出prometheus_common_metrics支持的metric_type及其对应的PromQL",
+	}, createListCommonMetricsHandler(opts))
 
 	// 注册指标列表工具
-	mcp.AddTool(server, &mcp.Tool{
+	core.AddTool(registrar, &mcp.Tool{
 		Name:        "prometheus_list_metrics",
-		Description: "获取所有可用的指标名称",
-	}, createListMetricsHandler(client))
+		Description: "获取可用的指标名称，可按前缀过滤并通过limit/offset分页",
+	}, createListMetricsHandler(client, opts))
+
+	// 注册指标快照工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "prometheus_snapshot_metrics",
+		Description: "将当前的指标名称列表保存为命名快照（仅保存在内存中），配合prometheus_diff_metrics检测新增或消失的指标",
+	}, createSnapshotMetricsHandler(client, opts, snapshots))
+
+	// 注册指标快照对比工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "prometheus_diff_metrics",
+		Description: "对比当前指标名称与命名快照，返回新增和消失的指标，用于发现部署变更或exporter故障",
+	}, createDiffMetricsHandler(client, opts, snapshots))
+
+	// 注册告警查询工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "prometheus_alerts",
+		Description: "获取当前触发(firing)和待定(pending)的告警",
+	}, createAlertsHandler(client, opts))
+
+	// 注册标签值查询工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "prometheus_label_values",
+		Description: "获取指定标签的取值（排序后按上限截断），可通过序列选择器过滤，高基数标签必须提供match",
+	}, createLabelValuesHandler(client, opts))
+
+	// 注册序列查询工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "prometheus_series",
+		Description: "获取匹配选择器的序列标签集合，设置limit后分页返回，通过next_cursor获取后续页",
+	}, createSeriesHandler(client, opts, seriesPages))
+
+	// 注册exemplar查询工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "prometheus_query_exemplars",
+		Description: "查询时间范围内的exemplar，返回其中的trace_id，便于从指标跳转到链路追踪",
+	}, createQueryExemplarsHandler(client, opts))
+
+	// 注册TSDB统计工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "prometheus_tsdb_stats",
+		Description: "获取TSDB头块统计（序列数、chunk数）及各指标的序列数、标签基数排行，用于排查基数膨胀",
+	}, createTSDBStatsHandler(client, opts))
+
+	// 注册指标元数据工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "prometheus_metric_metadata",
+		Description: "获取指标的类型(counter/gauge/histogram等)、帮助信息和单位",
+	}, createMetricMetadataHandler(client, opts))
+
+	// 注册规则查询工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "prometheus_rules",
+		Description: "获取告警规则和记录规则及其健康状态，可按规则组和告警规则标签（如team、service）过滤",
+	}, createRulesHandler(client, opts))
+
+	// 注册带修饰符的查询工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "prometheus_query_with_modifiers",
+		Description: "为PromQL表达式自动添加offset和@修饰符后执行即时查询",
+	}, createQueryWithModifiersHandler(client, opts))
+
+	// 注册直方图分位数查询工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "prometheus_histogram_quantile",
+		Description: "根据直方图指标的_bucket序列计算分位数（例如P99延迟），自动构建histogram_quantile查询",
+	}, createHistogramQuantileHandler(client, opts))
+
+	// 注册计数器增量查询工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "prometheus_counter_increase",
+		Description: "计算计数器指标在时间窗口内的总增量，自动构建increase查询；按元数据识别到非counter类型时拒绝执行",
+	}, createCounterIncreaseHandler(client, opts))
+
+	// 注册原始API透传工具（需显式开启）
+	if opts.enableRawAPI {
+		core.AddTool(registrar, &mcp.Tool{
+			Name:        "prometheus_raw_api",
+			Description: "以GET方式透传请求到Prometheus /api/v1/ 下的任意接口并返回原始JSON，供高级用户使用",
+		}, createRawAPIHandler(client, opts))
+	}
+
+	// 注册配置重载工具（需显式开启管理接口）
+	if opts.enableAdminAPI {
+		core.AddTool(registrar, &mcp.Tool{
+			Name:        "prometheus_reload",
+			Description: "触发Prometheus重新加载配置（需Prometheus以--web.enable-lifecycle启动）",
+		}, createReloadHandler(client, opts))
+	}
+
+	return registrar.Names(), registrar.Err()
 }
@@ -0,0 +1,66 @@
+package prometheus
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// metricSnapshot 某一时刻的指标名称快照
+type metricSnapshot struct {
+	names     map[string]struct{}
+	createdAt time.Time
+}
+
+// metricSnapshotStore 按名称保存指标名称快照，仅保存在内存中，服务重建后清空
+type metricSnapshotStore struct {
+	mu        sync.Mutex
+	snapshots map[string]metricSnapshot
+}
+
+// newMetricSnapshotStore 创建快照存储
+func newMetricSnapshotStore() *metricSnapshotStore {
+	return &metricSnapshotStore{snapshots: make(map[string]metricSnapshot)}
+}
+
+// save 保存快照，同名快照被覆盖
+func (s *metricSnapshotStore) save(name string, metricNames []string, now time.Time) {
+	names := make(map[string]struct{}, len(metricNames))
+	for _, metricName := range metricNames {
+		names[metricName] = struct{}{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots[name] = metricSnapshot{names: names, createdAt: now}
+}
+
+// diff 对比快照和当前指标名称，返回新增和消失的指标（均已排序）
+func (s *metricSnapshotStore) diff(name string, metricNames []string) (added, removed []string, createdAt time.Time, ok bool) {
+	s.mu.Lock()
+	snapshot, ok := s.snapshots[name]
+	s.mu.Unlock()
+	if !ok {
+		return nil, nil, time.Time{}, false
+	}
+
+	current := make(map[string]struct{}, len(metricNames))
+	added = make([]string, 0)
+	for _, metricName := range metricNames {
+		current[metricName] = struct{}{}
+		if _, exists := snapshot.names[metricName]; !exists {
+			added = append(added, metricName)
+		}
+	}
+
+	removed = make([]string, 0)
+	for metricName := range snapshot.names {
+		if _, exists := current[metricName]; !exists {
+			removed = append(removed, metricName)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed, snapshot.createdAt, true
+}
@@ -0,0 +1,44 @@
+package prometheus
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMetricSnapshotDiff(t *testing.T) {
+	store := newMetricSnapshotStore()
+	if _, _, _, ok := store.diff("baseline", []string{"up"}); ok {
+		t.Fatal("diff of a missing snapshot reported ok")
+	}
+
+	createdAt := time.Date(2026, 10, 14, 8, 0, 0, 0, time.UTC)
+	store.save("baseline", []string{"up", "node_load1", "http_requests_total"}, createdAt)
+
+	added, removed, gotCreatedAt, ok := store.diff("baseline", []string{"up", "zeta_total", "http_requests_total", "alpha_total"})
+	if !ok {
+		t.Fatal("diff of a saved snapshot not ok")
+	}
+	if want := []string{"alpha_total", "zeta_total"}; !slices.Equal(added, want) {
+		t.Errorf("added = %v, want %v", added, want)
+	}
+	if want := []string{"node_load1"}; !slices.Equal(removed, want) {
+		t.Errorf("removed = %v, want %v", removed, want)
+	}
+	if !gotCreatedAt.Equal(createdAt) {
+		t.Errorf("createdAt = %v, want %v", gotCreatedAt, createdAt)
+	}
+
+	// 没有变化时返回空切片而不是nil，便于序列化为[]
+	added, removed, _, _ = store.diff("baseline", []string{"http_requests_total", "node_load1", "up"})
+	if added == nil || removed == nil || len(added) != 0 || len(removed) != 0 {
+		t.Errorf("unchanged: added = %#v, removed = %#v, want empty slices", added, removed)
+	}
+
+	// 同名快照被覆盖
+	store.save("baseline", []string{"up"}, createdAt.Add(time.Hour))
+	added, removed, gotCreatedAt, _ = store.diff("baseline", []string{"up"})
+	if len(added) != 0 || len(removed) != 0 || !gotCreatedAt.Equal(createdAt.Add(time.Hour)) {
+		t.Errorf("after overwrite: added = %v, removed = %v, createdAt = %v", added, removed, gotCreatedAt)
+	}
+}
@@ -0,0 +1,336 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+// NaN/Inf处理方式
+const (
+	nanModeString = "string" // 保持Prometheus的字符串表示 ("NaN", "+Inf", "-Inf")
+	nanModeNull   = "null"   // 转换为JSON null
+)
+
+// 聚合函数
+const (
+	aggregationSum = "sum"
+	aggregationAvg = "avg"
+	aggregationMax = "max"
+	aggregationMin = "min"
+)
+
+// aggregationGroup 聚合分组中间状态
+type aggregationGroup struct {
+	labels    model.Metric
+	sum       float64
+	max       float64
+	min       float64
+	count     int
+	timestamp model.Time
+}
+
+// aggregateVector 按指定标签对瞬时向量进行客户端聚合
+func aggregateVector(vector model.Vector, groupBy []string, fn string) (model.Vector, error) {
+	if fn == "" {
+		fn = aggregationSum
+	}
+	switch fn {
+	case aggregationSum, aggregationAvg, aggregationMax, aggregationMin:
+	default:
+		return nil, fmt.Errorf("不支持的聚合函数: %s (可选: sum, avg, max, min)", fn)
+	}
+
+	groups := make(map[model.Fingerprint]*aggregationGroup)
+	order := make([]model.Fingerprint, 0)
+
+	for _, sample := range vector {
+		labels := make(model.Metric, len(groupBy))
+		for _, name := range groupBy {
+			if value, ok := sample.Metric[model.LabelName(name)]; ok {
+				labels[model.LabelName(name)] = value
+			}
+		}
+
+		fp := labels.Fingerprint()
+		group, exists := groups[fp]
+		if !exists {
+			group = &aggregationGroup{
+				labels: labels,
+				max:    math.Inf(-1),
+				min:    math.Inf(1),
+			}
+			groups[fp] = group
+			order = append(order, fp)
+		}
+
+		value := float64(sample.Value)
+		group.sum += value
+		group.max = math.Max(group.max, value)
+		group.min = math.Min(group.min, value)
+		group.count++
+		if sample.Timestamp > group.timestamp {
+			group.timestamp = sample.Timestamp
+		}
+	}
+
+	result := make(model.Vector, 0, len(groups))
+	for _, fp := range order {
+		group := groups[fp]
+
+		var value float64
+		switch fn {
+		case aggregationSum:
+			value = group.sum
+		case aggregationAvg:
+			value = group.sum / float64(group.count)
+		case aggregationMax:
+			value = group.max
+		case aggregationMin:
+			value = group.min
+		}
+
+		result = append(result, &model.Sample{
+			Metric:    group.labels,
+			Value:     model.SampleValue(value),
+			Timestamp: group.timestamp,
+		})
+	}
+
+	// 按标签排序，保证输出稳定
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Metric.String() < result[j].Metric.String()
+	})
+
+	return result, nil
+}
+
+// renameMetrics 按映射替换结果中__name__标签的值，仅影响展示，不影响查询
+func renameMetrics(value model.Value, rename map[string]string) model.Value {
+	if len(rename) == 0 {
+		return value
+	}
+
+	switch v := value.(type) {
+	case model.Vector:
+		for _, sample := range v {
+			sample.Metric = renameMetric(sample.Metric, rename)
+		}
+	case model.Matrix:
+		for _, stream := range v {
+			stream.Metric = renameMetric(stream.Metric, rename)
+		}
+	}
+	return value
+}
+
+// renameMetric 复制标签集并替换指标名称，避免修改共享的标签集
+func renameMetric(metric model.Metric, rename map[string]string) model.Metric {
+	name, ok := metric[model.MetricNameLabel]
+	if !ok {
+		return metric
+	}
+	display, ok := rename[string(name)]
+	if !ok {
+		return metric
+	}
+
+	renamed := metric.Clone()
+	renamed[model.MetricNameLabel] = model.LabelValue(display)
+	return renamed
+}
+
+// transformResult 对查询结果应用指标重命名，并转换为规范化结构
+func transformResult(value model.Value, opts *toolOptions) any {
+	return normalizeValue(renameMetrics(value, opts.metricRename), opts.nanMode)
+}
+
+// rawResult 对查询结果应用指标重命名和NaN/Inf处理，其余保持Prometheus的JSON格式
+func rawResult(value model.Value, opts *toolOptions) any {
+	value = renameMetrics(value, opts.metricRename)
+	if opts.nanMode == nanModeNull {
+		return nullifyNonFinite(value)
+	}
+	return value
+}
+
+// normalizedPoint 规范化的数据点，值为数字，时间戳为RFC3339格式
+type normalizedPoint struct {
+	Value     any    `json:"value"`
+	Timestamp string `json:"timestamp"`
+}
+
+// normalizedHistogramPoint 规范化的原生直方图数据点
+type normalizedHistogramPoint struct {
+	Histogram *model.SampleHistogram `json:"histogram"`
+	Timestamp string                 `json:"timestamp"`
+}
+
+// normalizedSample 规范化的瞬时向量样本
+type normalizedSample struct {
+	Metric model.Metric `json:"metric"`
+	normalizedPoint
+}
+
+// normalizedHistogramSample 规范化的原生直方图样本
+type normalizedHistogramSample struct {
+	Metric model.Metric `json:"metric"`
+	normalizedHistogramPoint
+}
+
+// normalizedSeries 规范化的范围向量序列
+type normalizedSeries struct {
+	Metric     model.Metric               `json:"metric"`
+	Values     []normalizedPoint          `json:"values"`
+	Histograms []normalizedHistogramPoint `json:"histograms,omitempty"`
+}
+
+// normalizeValue 将四种PromQL结果类型转换为规范化结构
+// vector为样本数组，matrix为序列数组，scalar和string为单个数据点
+func normalizeValue(value model.Value, nanMode string) any {
+	switch v := value.(type) {
+	case model.Vector:
+		samples := make([]any, 0, len(v))
+		for _, sample := range v {
+			if sample.Histogram != nil {
+				samples = append(samples, normalizedHistogramSample{
+					Metric:                   sample.Metric,
+					normalizedHistogramPoint: normalizedHistogramPoint{Histogram: sample.Histogram, Timestamp: formatTimestamp(sample.Timestamp)},
+				})
+				continue
+			}
+			samples = append(samples, normalizedSample{
+				Metric:          sample.Metric,
+				normalizedPoint: newNormalizedPoint(sample.Timestamp, sample.Value, nanMode),
+			})
+		}
+		return samples
+	case model.Matrix:
+		series := make([]normalizedSeries, 0, len(v))
+		for _, stream := range v {
+			entry := normalizedSeries{
+				Metric: stream.Metric,
+				Values: make([]normalizedPoint, 0, len(stream.Values)),
+			}
+			for _, pair := range stream.Values {
+				entry.Values = append(entry.Values, newNormalizedPoint(pair.Timestamp, pair.Value, nanMode))
+			}
+			for _, pair := range stream.Histograms {
+				entry.Histograms = append(entry.Histograms, normalizedHistogramPoint{Histogram: pair.Histogram, Timestamp: formatTimestamp(pair.Timestamp)})
+			}
+			series = append(series, entry)
+		}
+		return series
+	case *model.Scalar:
+		return newNormalizedPoint(v.Timestamp, v.Value, nanMode)
+	case *model.String:
+		return normalizedPoint{Value: v.Value, Timestamp: formatTimestamp(v.Timestamp)}
+	default:
+		return value
+	}
+}
+
+// newNormalizedPoint 构建数据点，有限值输出为数字，NaN/Inf按nan_handling处理
+func newNormalizedPoint(timestamp model.Time, value model.SampleValue, nanMode string) normalizedPoint {
+	point := normalizedPoint{Value: float64(value), Timestamp: formatTimestamp(timestamp)}
+	if f := float64(value); math.IsNaN(f) || math.IsInf(f, 0) {
+		point.Value = nil
+		if nanMode != nanModeNull {
+			point.Value = value.String()
+		}
+	}
+	return point
+}
+
+// formatTimestamp 将Prometheus毫秒时间戳格式化为UTC的RFC3339时间
+func formatTimestamp(timestamp model.Time) string {
+	return timestamp.Time().UTC().Format(time.RFC3339Nano)
+}
+
+// jsonSample 瞬时向量样本的JSON表示
+type jsonSample struct {
+	Metric model.Metric `json:"metric"`
+	Value  [2]any       `json:"value"`
+}
+
+// jsonSeries 范围向量序列的JSON表示
+type jsonSeries struct {
+	Metric model.Metric `json:"metric"`
+	Values [][2]any     `json:"values"`
+}
+
+// nullifyNonFinite 将结果中的NaN/Inf转换为null，其余值与Prometheus的JSON格式保持一致
+func nullifyNonFinite(value model.Value) any {
+	switch v := value.(type) {
+	case model.Vector:
+		samples := make([]any, 0, len(v))
+		for _, sample := range v {
+			// 原生直方图样本没有浮点值，保持原样
+			if sample.Histogram != nil {
+				samples = append(samples, sample)
+				continue
+			}
+			samples = append(samples, jsonSample{
+				Metric: sample.Metric,
+				Value:  samplePair(sample.Timestamp, sample.Value),
+			})
+		}
+		return samples
+	case model.Matrix:
+		series := make([]any, 0, len(v))
+		for _, stream := range v {
+			if len(stream.Histograms) > 0 {
+				series = append(series, stream)
+				continue
+			}
+			values := make([][2]any, 0, len(stream.Values))
+			for _, pair := range stream.Values {
+				values = append(values, samplePair(pair.Timestamp, pair.Value))
+			}
+			series = append(series, jsonSeries{Metric: stream.Metric, Values: values})
+		}
+		return series
+	case *model.Scalar:
+		return samplePair(v.Timestamp, v.Value)
+	default:
+		return value
+	}
+}
+
+// singleValue 从只有一个样本的结果中取出 {value, timestamp}
+func singleValue(value model.Value, opts *toolOptions) (normalizedPoint, error) {
+	var timestamp model.Time
+	var sampleValue model.SampleValue
+
+	switch v := value.(type) {
+	case *model.Scalar:
+		timestamp, sampleValue = v.Timestamp, v.Value
+	case model.Vector:
+		switch {
+		case len(v) == 0:
+			return normalizedPoint{}, fmt.Errorf("查询结果为空，没有可返回的值")
+		case len(v) > 1:
+			return normalizedPoint{}, fmt.Errorf("查询返回了%d条序列，scalar_only只适用于单一结果，请使用聚合（如sum、count）合并后重试", len(v))
+		case v[0].Histogram != nil:
+			return normalizedPoint{}, fmt.Errorf("结果为原生直方图样本，没有单一数值")
+		}
+		timestamp, sampleValue = v[0].Timestamp, v[0].Value
+	default:
+		return normalizedPoint{}, fmt.Errorf("scalar_only仅支持瞬时向量或标量结果，当前结果类型: %s", value.Type())
+	}
+
+	return newNormalizedPoint(timestamp, sampleValue, opts.nanMode), nil
+}
+
+// samplePair 构建 [时间戳, 值] 对，非有限值转换为nil
+func samplePair(timestamp model.Time, value model.SampleValue) [2]any {
+	f := float64(value)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return [2]any{json.Number(timestamp.String()), nil}
+	}
+	return [2]any{json.Number(timestamp.String()), value.String()}
+}
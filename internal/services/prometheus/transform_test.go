@@ -0,0 +1,139 @@
+package prometheus
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/prometheus/common/model"
+)
+
+// sampleVector 测试用瞬时向量
+func sampleVector() model.Vector {
+	return model.Vector{
+		{Metric: model.Metric{"__name__": "cpu", "pod": "a", "container": "app"}, Value: 1, Timestamp: 1000},
+		{Metric: model.Metric{"__name__": "cpu", "pod": "a", "container": "sidecar"}, Value: 3, Timestamp: 2000},
+		{Metric: model.Metric{"__name__": "cpu", "pod": "b", "container": "app"}, Value: 5, Timestamp: 1000},
+	}
+}
+
+func TestAggregateVectorByLabel(t *testing.T) {
+	for _, tc := range []struct {
+		fn   string
+		want map[model.LabelValue]model.SampleValue
+	}{
+		{fn: "", want: map[model.LabelValue]model.SampleValue{"a": 4, "b": 5}},
+		{fn: aggregationAvg, want: map[model.LabelValue]model.SampleValue{"a": 2, "b": 5}},
+		{fn: aggregationMax, want: map[model.LabelValue]model.SampleValue{"a": 3, "b": 5}},
+		{fn: aggregationMin, want: map[model.LabelValue]model.SampleValue{"a": 1, "b": 5}},
+	} {
+		t.Run("fn="+tc.fn, func(t *testing.T) {
+			result, err := aggregateVector(sampleVector(), []string{"pod"}, tc.fn)
+			if err != nil {
+				t.Fatalf("aggregateVector: %v", err)
+			}
+			if len(result) != len(tc.want) {
+				t.Fatalf("result = %v, want %d groups", result, len(tc.want))
+			}
+			for _, sample := range result {
+				// 结果只保留分组标签
+				if len(sample.Metric) != 1 {
+					t.Errorf("metric = %v, want only the pod label", sample.Metric)
+				}
+				if want := tc.want[sample.Metric["pod"]]; sample.Value != want {
+					t.Errorf("pod %s = %v, want %v", sample.Metric["pod"], sample.Value, want)
+				}
+			}
+			// 分组取最新的时间戳
+			if result[0].Metric["pod"] != "a" || result[0].Timestamp != 2000 {
+				t.Errorf("first group = %v, want pod a at timestamp 2000", result[0])
+			}
+		})
+	}
+
+	if _, err := aggregateVector(sampleVector(), []string{"pod"}, "median"); err == nil {
+		t.Error("aggregateVector accepted an unsupported function")
+	}
+}
+
+func TestTransformResultRenamesMetrics(t *testing.T) {
+	opts := &toolOptions{metricRename: map[string]string{"node_cpu_seconds_total": "CPU Seconds"}}
+	shared := model.Metric{"__name__": "node_cpu_seconds_total", "cpu": "0"}
+	vector := model.Vector{
+		{Metric: shared, Value: 1, Timestamp: 1000},
+		{Metric: model.Metric{"__name__": "up", "job": "node"}, Value: 1, Timestamp: 1000},
+	}
+
+	samples, ok := transformResult(vector, opts).([]any)
+	if !ok || len(samples) != 2 {
+		t.Fatalf("transformResult = %#v, want 2 samples", samples)
+	}
+	if got := samples[0].(normalizedSample).Metric; got["__name__"] != "CPU Seconds" || got["cpu"] != "0" {
+		t.Errorf("renamed metric = %v, want __name__ CPU Seconds with other labels kept", got)
+	}
+	if got := samples[1].(normalizedSample).Metric["__name__"]; got != "up" {
+		t.Errorf("unmapped metric name = %q, want up", got)
+	}
+	// 重命名只影响输出，不修改共享的标签集
+	if shared["__name__"] != "node_cpu_seconds_total" {
+		t.Errorf("shared metric modified: %v", shared)
+	}
+
+	matrix := model.Matrix{{Metric: model.Metric{"__name__": "node_cpu_seconds_total"}, Values: []model.SamplePair{{Timestamp: 1000, Value: 1}}}}
+	series := transformResult(matrix, opts).([]normalizedSeries)
+	if got := series[0].Metric["__name__"]; got != "CPU Seconds" {
+		t.Errorf("renamed series name = %q, want CPU Seconds", got)
+	}
+}
+
+func TestTransformResultNaNAndInf(t *testing.T) {
+	vector := func() model.Vector {
+		return model.Vector{
+			{Metric: model.Metric{"case": "nan"}, Value: model.SampleValue(math.NaN()), Timestamp: 1000},
+			{Metric: model.Metric{"case": "inf"}, Value: model.SampleValue(math.Inf(1)), Timestamp: 1000},
+			{Metric: model.Metric{"case": "-inf"}, Value: model.SampleValue(math.Inf(-1)), Timestamp: 1000},
+			{Metric: model.Metric{"case": "finite"}, Value: 1.5, Timestamp: 1000},
+		}
+	}
+
+	for _, tc := range []struct {
+		mode string
+		want []any
+	}{
+		{mode: nanModeString, want: []any{"NaN", "+Inf", "-Inf", 1.5}},
+		{mode: nanModeNull, want: []any{nil, nil, nil, 1.5}},
+	} {
+		t.Run(tc.mode, func(t *testing.T) {
+			data, err := json.Marshal(transformResult(vector(), &toolOptions{nanMode: tc.mode}))
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+			var samples []struct {
+				Value any `json:"value"`
+			}
+			if err := json.Unmarshal(data, &samples); err != nil {
+				t.Fatalf("unmarshal %s: %v", data, err)
+			}
+			for i, sample := range samples {
+				if sample.Value != tc.want[i] {
+					t.Errorf("sample %d value = %#v, want %#v", i, sample.Value, tc.want[i])
+				}
+			}
+		})
+	}
+
+	// 原始格式同样可以序列化，null模式下非有限值为null
+	data, err := json.Marshal(rawResult(vector(), &toolOptions{nanMode: nanModeNull}))
+	if err != nil {
+		t.Fatalf("marshal raw: %v", err)
+	}
+	var raw []struct {
+		Value [2]any `json:"value"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		t.Fatalf("unmarshal raw %s: %v", data, err)
+	}
+	if raw[0].Value[1] != nil || raw[3].Value[1] != "1.5" {
+		t.Errorf("raw values = %v, want null for NaN and \"1.5\" for finite", raw)
+	}
+}
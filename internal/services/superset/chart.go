@@ -0,0 +1,155 @@
+package superset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// 图表相关端点
+const (
+	chartEndpoint = "/api/v1/chart/"
+	chartDataPath = "/data/"
+)
+
+// Chart 图表信息
+type Chart struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	VizType        string `json:"viz_type"`
+	Datasource     string `json:"datasource"`
+	DatasourceID   int    `json:"datasource_id"`
+	DatasourceType string `json:"datasource_type"`
+}
+
+// ChartQueryResult 图表中单个查询的结果，结构与SQLResult一致
+type ChartQueryResult struct {
+	Columns  []string `json:"columns"`
+	Data     [][]any  `json:"data"`
+	Query    string   `json:"query"`
+	Status   string   `json:"status"`
+	RowCount int      `json:"row_count"`
+}
+
+// ChartData 图表数据，多数图表只有一个查询，混合图表等可能包含多个
+type ChartData struct {
+	ChartID int                `json:"chart_id"`
+	Results []ChartQueryResult `json:"results"`
+}
+
+// GetCharts 获取图表列表，nameFilter非空时按标题模糊匹配
+func (c *Client) GetCharts(ctx context.Context, nameFilter string) ([]Chart, error) {
+	query := risonQuery{
+		OrderColumn:    "changed_on_delta_humanized",
+		OrderDirection: "desc",
+		PageSize:       listPageSize,
+	}
+	if nameFilter != "" {
+		query.Filters = []risonFilter{{Col: "slice_name", Opr: "ct", Value: risonString(nameFilter)}}
+	}
+
+	charts := make([]Chart, 0)
+	for page := 0; page < maxListPages; page++ {
+		query.Page = page
+
+		var response struct {
+			Count  int `json:"count"`
+			Result []struct {
+				ID                 int    `json:"id"`
+				SliceName          string `json:"slice_name"`
+				VizType            string `json:"viz_type"`
+				DatasourceNameText string `json:"datasource_name_text"`
+				DatasourceID       int    `json:"datasource_id"`
+				DatasourceType     string `json:"datasource_type"`
+			} `json:"result"`
+		}
+		if err := c.getJSON(ctx, query.endpoint(chartEndpoint), &response); err != nil {
+			return nil, fmt.Errorf("获取图表列表失败: %w", err)
+		}
+
+		for _, item := range response.Result {
+			charts = append(charts, Chart{
+				ID:             item.ID,
+				Name:           item.SliceName,
+				VizType:        item.VizType,
+				Datasource:     item.DatasourceNameText,
+				DatasourceID:   item.DatasourceID,
+				DatasourceType: item.DatasourceType,
+			})
+		}
+
+		if len(response.Result) == 0 || len(charts) >= response.Count {
+			break
+		}
+	}
+
+	return charts, nil
+}
+
+// GetChartData 使用图表保存的查询上下文获取图表数据
+// 依赖图表保存的query_context，较早创建且未重新保存过的图表没有该字段，会返回明确的错误
+func (c *Client) GetChartData(ctx context.Context, chartID int) (*ChartData, error) {
+	var response struct {
+		Result []struct {
+			Colnames []string         `json:"colnames"`
+			Data     []map[string]any `json:"data"`
+			Query    string           `json:"query"`
+			Status   string           `json:"status"`
+			Error    *string          `json:"error"`
+			RowCount int              `json:"rowcount"`
+		} `json:"result"`
+	}
+
+	endpoint := chartEndpoint + strconv.Itoa(chartID) + chartDataPath
+	if err := c.getJSON(ctx, endpoint, &response); err != nil {
+		return nil, chartDataError(chartID, err)
+	}
+
+	chartData := &ChartData{
+		ChartID: chartID,
+		Results: make([]ChartQueryResult, 0, len(response.Result)),
+	}
+	for i, item := range response.Result {
+		if item.Error != nil && *item.Error != "" {
+			return nil, fmt.Errorf("图表 %d 的第%d个查询失败: %s", chartID, i+1, *item.Error)
+		}
+
+		data := make([][]any, 0, len(item.Data))
+		for _, row := range item.Data {
+			rowData := make([]any, 0, len(item.Colnames))
+			for _, column := range item.Colnames {
+				rowData = append(rowData, row[column])
+			}
+			data = append(data, rowData)
+		}
+
+		chartData.Results = append(chartData.Results, ChartQueryResult{
+			Columns:  item.Colnames,
+			Data:     data,
+			Query:    item.Query,
+			Status:   item.Status,
+			RowCount: item.RowCount,
+		})
+	}
+
+	return chartData, nil
+}
+
+// chartDataError 将常见的图表数据失败原因转换为可操作的错误信息
+func chartDataError(chartID int, err error) error {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusBadRequest && strings.Contains(strings.ToLower(apiErr.Body), "query context"):
+			return fmt.Errorf("图表 %d 未保存查询上下文，需要传入form-data参数才能查询；请在Superset中打开该图表并重新保存后重试: %w", chartID, err)
+		case apiErr.StatusCode == http.StatusAccepted:
+			return fmt.Errorf("图表 %d 使用异步查询（GLOBAL_ASYNC_QUERIES），暂不支持: %w", chartID, err)
+		case apiErr.StatusCode == http.StatusNotFound:
+			return fmt.Errorf("图表 %d 不存在或当前账号无权访问: %w", chartID, err)
+		}
+	}
+	return fmt.Errorf("获取图表数据失败: %w", err)
+}
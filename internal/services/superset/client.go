@@ -4,15 +4,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"net/http/cookiejar"
 	"net/url"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"mcp-server/internal/common"
+	"mcp-server/internal/core"
 )
 
 // 常量定义
@@ -23,6 +29,21 @@ const (
 	healthEndpoint     = "/health"
 	databaseEndpoint   = "/api/v1/database/"
 	sqlExecuteEndpoint = "/api/v1/sqllab/execute/"
+	queryEndpoint      = "/api/v1/query/"
+	meEndpoint         = "/api/v1/me/"
+	csvExportEndpoint  = "/api/v1/sqllab/export/"
+	rolesEndpoint      = "/api/v1/security/roles/"
+
+	// 数据库访问相关权限名称
+	permDatabaseAccess    = "database_access"
+	permAllDatabaseAccess = "all_database_access"
+
+	// 角色列表单页最大数量
+	maxRolesPageSize = 100
+	// maxRoles 数据库权限查询最多检查的角色数，超出时在结果中标记truncated
+	maxRoles = 1000
+	// maxRolePermissionConcurrency 同时查询角色权限的请求数上限
+	maxRolePermissionConcurrency = 8
 
 	// HTTP头常量
 	contentTypeJSON = "application/json"
@@ -32,20 +53,36 @@ const (
 	headerReferer   = "Referer"
 
 	// CSRF令牌缓存时间
-	csrfTokenCacheDuration = 5 * time.Minute
+	// defaultCSRFTokenTTL CSRF令牌缓存的默认有效期
+	defaultCSRFTokenTTL = 5 * time.Minute
+	// defaultDatabaseCacheTTL 数据库列表缓存的默认有效期
+	defaultDatabaseCacheTTL = 60 * time.Second
 
 	// HTTP传输层配置
-	maxIdleConns          = 100
-	maxIdleConnsPerHost   = 10
-	maxConnsPerHost       = 50
-	idleConnTimeout       = 90 * time.Second
-	tlsHandshakeTimeout   = 10 * time.Second
-	responseHeaderTimeout = 30 * time.Second
+	maxIdleConns           = 100
+	maxIdleConnsPerHost    = 10
+	defaultMaxConnsPerHost = 50
+	idleConnTimeout        = 90 * time.Second
+	tlsHandshakeTimeout    = 10 * time.Second
+	responseHeaderTimeout  = 30 * time.Second
+
+	// 结果列名的来源字段
+	ColumnNameSourceName       = "name"        // 列的展示名称（含别名），默认
+	ColumnNameSourceColumnName = "column_name" // 列的原始名称
 )
 
 // CSRF令牌正则表达式 - 预编译提升性能
 var csrfTokenRegex = regexp.MustCompile(`name="csrf_token"[^>]*value="([^"]*)"`)
 
+// 登录失败的原因
+var (
+	errInvalidCredentials      = errors.New("用户名或密码错误")
+	errUnexpectedLoginResponse = errors.New("登录响应异常")
+
+	// errMeUnavailable Superset不提供/api/v1/me/，无法通过API确认登录结果
+	errMeUnavailable = errors.New("当前用户接口不可用")
+)
+
 // Database 数据库结构
 type Database struct {
 	ID            int    `json:"id"`
@@ -58,10 +95,122 @@ type Database struct {
 
 // SQLResult SQL执行结果
 type SQLResult struct {
-	Columns []string `json:"columns"`
-	Data    [][]any  `json:"data"`
-	Query   string   `json:"query"`
-	Status  string   `json:"status"`
+	QueryID    int      `json:"query_id"`
+	Columns    []string `json:"columns"`
+	Data       [][]any  `json:"data"`
+	Query      string   `json:"query"`
+	Status     string   `json:"status"`
+	RowCount   int      `json:"row_count"`
+	DurationMS int64    `json:"duration_ms"`
+}
+
+// QueryRecord SQL Lab查询记录
+type QueryRecord struct {
+	ID           int         `json:"id"`
+	ClientID     string      `json:"client_id"`
+	Status       string      `json:"status"`
+	Rows         int         `json:"rows"`
+	SQL          string      `json:"sql"`
+	ExecutedSQL  string      `json:"executed_sql"`
+	Schema       string      `json:"schema"`
+	ErrorMessage string      `json:"error_message"`
+	TrackingURL  string      `json:"tracking_url"`
+	StartTime    epochMillis `json:"start_time"`
+	EndTime      epochMillis `json:"end_time"`
+	Database     struct {
+		DatabaseName string `json:"database_name"`
+	} `json:"database"`
+}
+
+// epochMillis Superset返回的毫秒时间戳，兼容数字和字符串两种格式
+type epochMillis float64
+
+// UnmarshalJSON 实现json.Unmarshaler接口
+func (e *epochMillis) UnmarshalJSON(data []byte) error {
+	text := strings.Trim(string(data), `"`)
+	if text == "" || text == "null" {
+		*e = 0
+		return nil
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return fmt.Errorf("无效的时间戳: %s", text)
+	}
+	*e = epochMillis(value)
+	return nil
+}
+
+// CurrentUser 当前登录用户
+type CurrentUser struct {
+	ID        int    `json:"id"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+}
+
+// CSVExport CSV导出链接信息
+type CSVExport struct {
+	QueryID      int    `json:"query_id"`
+	ClientID     string `json:"client_id"`
+	URL          string `json:"url"`
+	Status       string `json:"status"`
+	Rows         int    `json:"rows"`
+	SQL          string `json:"sql"`
+	AuthRequired bool   `json:"auth_required"`
+	AuthNote     string `json:"auth_note"`
+}
+
+// QueryError 查询失败详情
+type QueryError struct {
+	QueryID      int    `json:"query_id"`
+	Status       string `json:"status"`
+	Failed       bool   `json:"failed"`
+	ErrorMessage string `json:"error_message,omitempty"`
+	TrackingURL  string `json:"tracking_url,omitempty"`
+	SQL          string `json:"sql"`
+	ExecutedSQL  string `json:"executed_sql,omitempty"`
+	Database     string `json:"database"`
+	Schema       string `json:"schema,omitempty"`
+}
+
+// RoleAccess 可访问数据库的角色
+type RoleAccess struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Permission string `json:"permission"` // 授予访问的权限名称
+}
+
+// DatabasePermissions 数据库访问权限信息
+type DatabasePermissions struct {
+	DatabaseID     int          `json:"database_id"`
+	DatabaseName   string       `json:"database_name"`
+	PermissionView string       `json:"permission_view"` // 数据库访问权限对应的视图名称
+	Visible        bool         `json:"visible"`         // 当前账号是否有权查看角色权限
+	Roles          []RoleAccess `json:"roles"`
+	Truncated      bool         `json:"truncated,omitempty"` // 角色数量超过maxRoles，只检查了部分角色
+	Note           string       `json:"note,omitempty"`
+}
+
+// roleSummary 角色列表项
+type roleSummary struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// rolePermission 角色权限项
+type rolePermission struct {
+	PermissionName string `json:"permission_name"`
+	ViewMenuName   string `json:"view_menu_name"`
+}
+
+// apiError Superset API非200响应错误
+type apiError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("API请求失败，状态码: %d, 响应: %s", e.StatusCode, e.Body)
 }
 
 // csrfTokenCache CSRF令牌缓存
@@ -70,70 +219,180 @@ type csrfTokenCache struct {
 	expiresAt time.Time
 }
 
+// databaseCache 数据库列表缓存
+type databaseCache struct {
+	databases []Database
+	expiresAt time.Time
+}
+
 // Client Superset客户端
 type Client struct {
 	baseURL    string
 	username   string
 	password   string
 	httpClient *http.Client
+	jar        *resettableJar
 	loggedIn   bool
+	sessionGen uint64 // 每次登录成功递增，用于判断会话失效是否已被其他请求处理
 	mu         sync.RWMutex
 	timeout    time.Duration
 	csrfCache  csrfTokenCache
 	sqlLabURL  string // 缓存的sqllab URL
+
+	// CSRF令牌缓存有效期
+	csrfTokenTTL time.Duration
+
+	// 连接测试使用的健康检查路径
+	healthPath string
+
+	// 数据库列表缓存，由mu保护
+	dbCache    databaseCache
+	dbCacheTTL time.Duration
+
+	// 重试配置
+	retryAttempts int
+	retryBackoff  time.Duration
+
+	// 进行中的SQL执行，关闭时等待其完成
+	inflight inflightTracker
+
+	// 只读模式，仅允许执行SELECT/WITH/EXPLAIN语句
+	readOnly bool
+
+	// 结果列名及行数据取值使用的字段，name或column_name
+	columnNameSource string
+
+	// 合并并发的相同SQL执行，未启用时为nil
+	coalescer *sqlCoalescer
+}
+
+// ClientOptions Superset客户端可选配置
+type ClientOptions struct {
+	MaxConnsPerHost  int           // 到Superset的最大并发连接数，0使用默认值
+	RetryAttempts    int           // 请求最大尝试次数，0使用默认值，1表示不重试
+	RetryBackoff     time.Duration // 重试初始退避时间，每次翻倍，0使用默认值
+	ReadOnly         bool          // 只读模式，拒绝执行非SELECT/WITH/EXPLAIN语句
+	ColumnNameSource string        // 结果列名来源，name(默认)或column_name
+	DatabaseCacheTTL time.Duration // 数据库列表缓存有效期，0使用默认值
+	CSRFTokenTTL     time.Duration // CSRF令牌缓存有效期，0使用默认值
+	HealthPath       string        // 连接测试使用的健康检查路径，为空使用/health
+	CoalesceQueries  bool          // 合并并发的相同SQL执行，共享同一次上游调用的结果
+	TLS              common.TLSOptions
 }
 
 // NewClient 创建新的Superset客户端
-func NewClient(baseURL, username, password string, timeout time.Duration) (*Client, error) {
-	jar, err := cookiejar.New(nil)
+func NewClient(baseURL, username, password string, timeout time.Duration, opts ClientOptions) (*Client, error) {
+	// 去除末尾斜杠，避免拼接出 //api/v1 形式的路径
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+
+	if opts.MaxConnsPerHost <= 0 {
+		opts.MaxConnsPerHost = defaultMaxConnsPerHost
+	}
+	if opts.RetryAttempts <= 0 {
+		opts.RetryAttempts = defaultRetryAttempts
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaultRetryBackoff
+	}
+	if opts.ColumnNameSource == "" {
+		opts.ColumnNameSource = ColumnNameSourceName
+	}
+	if opts.DatabaseCacheTTL <= 0 {
+		opts.DatabaseCacheTTL = defaultDatabaseCacheTTL
+	}
+	if opts.CSRFTokenTTL <= 0 {
+		opts.CSRFTokenTTL = defaultCSRFTokenTTL
+	}
+	if opts.HealthPath == "" {
+		opts.HealthPath = healthEndpoint
+	}
+
+	jar, err := newResettableJar()
 	if err != nil {
 		return nil, fmt.Errorf("创建cookie jar失败: %w", err)
 	}
 
+	tlsConfig, err := common.BuildTLSConfig("Superset", opts.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("创建TLS配置失败: %w", err)
+	}
+
 	// 创建优化的HTTP传输层
 	transport := &http.Transport{
 		MaxIdleConns:          maxIdleConns,
 		MaxIdleConnsPerHost:   maxIdleConnsPerHost,
 		IdleConnTimeout:       idleConnTimeout,
 		TLSHandshakeTimeout:   tlsHandshakeTimeout,
+		TLSClientConfig:       tlsConfig,
 		DisableCompression:    false,
 		ForceAttemptHTTP2:     true,
-		MaxConnsPerHost:       maxConnsPerHost,
+		MaxConnsPerHost:       opts.MaxConnsPerHost,
 		ResponseHeaderTimeout: responseHeaderTimeout,
 	}
 
+	var coalescer *sqlCoalescer
+	if opts.CoalesceQueries {
+		coalescer = newSQLCoalescer()
+	}
+
 	return &Client{
 		baseURL:   baseURL,
 		username:  username,
 		password:  password,
 		sqlLabURL: baseURL + "/superset/sqllab",
+		jar:       jar,
 		httpClient: &http.Client{
 			Timeout:   timeout,
 			Jar:       jar,
-			Transport: transport,
+			Transport: common.InstrumentTransport(string(core.ServiceTypeSuperset), transport),
 		},
-		timeout: timeout,
+		timeout:          timeout,
+		retryAttempts:    opts.RetryAttempts,
+		retryBackoff:     opts.RetryBackoff,
+		readOnly:         opts.ReadOnly,
+		columnNameSource: opts.ColumnNameSource,
+		dbCacheTTL:       opts.DatabaseCacheTTL,
+		csrfTokenTTL:     opts.CSRFTokenTTL,
+		healthPath:       opts.HealthPath,
+		coalescer:        coalescer,
 	}, nil
 }
 
-// TestConnection 测试连接
+// TestConnection 测试连接，健康检查路径不可用（被禁用或需要认证）时改为检查登录页面
 func (c *Client) TestConnection(ctx context.Context) error {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+healthEndpoint, nil)
+	healthStatus, err := c.probe(ctx, c.healthPath)
 	if err != nil {
-		return fmt.Errorf("创建请求失败: %w", err)
+		return err
+	}
+	if healthStatus == http.StatusOK {
+		return nil
 	}
 
-	resp, err := c.httpClient.Do(req)
+	loginStatus, err := c.probe(ctx, loginEndpoint)
 	if err != nil {
-		return fmt.Errorf("连接失败: %w", err)
+		return err
 	}
-	defer resp.Body.Close()
+	if loginStatus != http.StatusOK {
+		return fmt.Errorf("服务器响应异常，%s 状态码: %d，%s 状态码: %d", c.healthPath, healthStatus, loginEndpoint, loginStatus)
+	}
+	return nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("服务器响应异常，状态码: %d", resp.StatusCode)
+// probe 发送不带认证的GET请求，返回响应状态码
+func (c *Client) probe(ctx context.Context, path string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return 0, fmt.Errorf("创建请求失败: %w", err)
 	}
 
-	return nil
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("连接失败: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode, nil
 }
 
 // getCSRFToken 获取CSRF令牌（带缓存）
@@ -161,7 +420,7 @@ func (c *Client) getCSRFToken(ctx context.Context) (string, error) {
 		return "", fmt.Errorf("创建请求失败: %w", err)
 	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.do(req)
 	if err != nil {
 		return "", fmt.Errorf("获取登录页面失败: %w", err)
 	}
@@ -181,7 +440,7 @@ func (c *Client) getCSRFToken(ctx context.Context) (string, error) {
 	token := matches[1]
 	c.csrfCache = csrfTokenCache{
 		token:     token,
-		expiresAt: time.Now().Add(csrfTokenCacheDuration),
+		expiresAt: time.Now().Add(c.csrfTokenTTL),
 	}
 
 	return token, nil
@@ -222,32 +481,92 @@ func (c *Client) Login(ctx context.Context) error {
 	}
 	defer resp.Body.Close()
 
-	// 检查登录成功
-	if resp.StatusCode == http.StatusFound || resp.StatusCode == http.StatusSeeOther {
-		location := resp.Header.Get("Location")
-		if c.isSuccessfulRedirect(location) {
-			c.loggedIn = true
-			return nil
-		}
+	body, err := common.ReadAllLimited(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取登录响应失败: %w", err)
 	}
 
-	if resp.StatusCode == http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		bodyStr := string(body)
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return errInvalidCredentials
+	case resp.StatusCode >= http.StatusBadRequest:
+		return fmt.Errorf("%w: 登录请求返回状态码 %d", errUnexpectedLoginResponse, resp.StatusCode)
+	}
 
-		if c.isLoginError(bodyStr) {
-			return fmt.Errorf("用户名或密码错误")
-		}
+	// 以已认证的API调用确认登录结果，不依赖登录页面的文案
+	authenticated, err := c.verifyLogin(ctx)
+	switch {
+	case errors.Is(err, errMeUnavailable):
+		// 较早的Superset没有/api/v1/me/，退回到检查登录响应
+		authenticated, err = c.loginResponseSucceeded(resp, string(body))
+	case err != nil:
+		return fmt.Errorf("%w: %w", errUnexpectedLoginResponse, err)
+	}
+	if err != nil {
+		return err
+	}
+	if !authenticated {
+		return errInvalidCredentials
+	}
 
-		if c.isLoginSuccess(bodyStr) {
-			c.loggedIn = true
-			return nil
-		}
+	c.loggedIn = true
+	c.sessionGen++
+	return nil
+}
 
-		return fmt.Errorf("登录失败")
+// verifyLogin 请求/api/v1/me/确认当前会话已认证，未认证（401/403）时返回false
+// 不经过doAuthenticated，也不访问登录状态，登录时（已持有mu）和判断403是否为会话过期时调用
+func (c *Client) verifyLogin(ctx context.Context) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+meEndpoint, nil)
+	if err != nil {
+		return false, fmt.Errorf("创建请求失败: %w", err)
 	}
+	req.Header.Set(headerAccept, contentTypeJSON)
+
+	resp, err := c.do(req)
+	if err != nil {
+		return false, fmt.Errorf("请求%s失败: %w", meEndpoint, err)
+	}
+	defer resp.Body.Close()
 
-	return fmt.Errorf("登录失败，状态码: %d", resp.StatusCode)
+	body, err := common.ReadAllLimited(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return false, nil
+	case http.StatusNotFound:
+		return false, errMeUnavailable
+	default:
+		return false, fmt.Errorf("%s 返回状态码 %d", meEndpoint, resp.StatusCode)
+	}
+
+	var result struct {
+		Result *struct {
+			Username string `json:"username"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.Result == nil {
+		return false, fmt.Errorf("%s 返回了无法识别的响应", meEndpoint)
+	}
+	return true, nil
+}
+
+// loginResponseSucceeded 根据登录响应判断登录结果，仅在无法调用/api/v1/me/时使用
+func (c *Client) loginResponseSucceeded(resp *http.Response, body string) (bool, error) {
+	if resp.StatusCode == http.StatusFound || resp.StatusCode == http.StatusSeeOther {
+		return c.isSuccessfulRedirect(resp.Header.Get("Location")), nil
+	}
+	if c.isLoginError(body) {
+		return false, nil
+	}
+	if c.isLoginSuccess(body) {
+		return true, nil
+	}
+	return false, fmt.Errorf("%w: 无法从登录页面判断登录结果", errUnexpectedLoginResponse)
 }
 
 // getCSRFTokenForLogin 为登录专门获取CSRF令牌（不使用缓存）
@@ -307,8 +626,38 @@ func (c *Client) ensureLoggedIn(ctx context.Context) error {
 	return c.Login(ctx)
 }
 
-// GetDatabases 获取数据库列表
+// GetDatabases 获取数据库列表，优先返回缓存，缓存过期后重新获取
 func (c *Client) GetDatabases(ctx context.Context) ([]Database, error) {
+	c.mu.RLock()
+	if time.Now().Before(c.dbCache.expiresAt) {
+		databases := slices.Clone(c.dbCache.databases)
+		c.mu.RUnlock()
+		return databases, nil
+	}
+	c.mu.RUnlock()
+
+	return c.RefreshDatabases(ctx)
+}
+
+// RefreshDatabases 跳过缓存从Superset获取数据库列表并更新缓存
+func (c *Client) RefreshDatabases(ctx context.Context) ([]Database, error) {
+	databases, err := c.fetchDatabases(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.dbCache = databaseCache{
+		databases: slices.Clone(databases),
+		expiresAt: time.Now().Add(c.dbCacheTTL),
+	}
+	c.mu.Unlock()
+
+	return databases, nil
+}
+
+// fetchDatabases 请求Superset获取数据库列表
+func (c *Client) fetchDatabases(ctx context.Context) ([]Database, error) {
 	if err := c.ensureLoggedIn(ctx); err != nil {
 		return nil, fmt.Errorf("登录失败: %w", err)
 	}
@@ -327,13 +676,13 @@ func (c *Client) GetDatabases(ctx context.Context) ([]Database, error) {
 	req.Header.Set(headerCSRF, csrfToken)
 	req.Header.Set(headerReferer, c.sqlLabURL)
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.doAuthenticated(req)
 	if err != nil {
 		return nil, fmt.Errorf("获取数据库列表失败: %w", err)
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := common.ReadAllLimited(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("读取响应失败: %w", err)
 	}
@@ -368,18 +717,43 @@ func (c *Client) ExecuteSQLWithSchema(ctx context.Context, sql string, databaseI
 	return c.executeSQLInternal(ctx, sql, databaseID, schema)
 }
 
-// executeSQLInternal 内部SQL执行方法
+// executeSQLInternal 内部SQL执行方法，开启合并时并发的相同查询共享一次执行
 func (c *Client) executeSQLInternal(ctx context.Context, sql string, databaseID int, schema string) (*SQLResult, error) {
+	// 只读检查在发送请求之前进行，分页查询包装后的SQL同样会被检查
+	if c.readOnly {
+		if err := checkReadOnlySQL(sql); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.coalescer != nil {
+		return c.coalescer.do(ctx, coalesceKey(databaseID, schema, sql), func(ctx context.Context) (*SQLResult, error) {
+			return c.executeSQLOnce(ctx, sql, databaseID, schema)
+		})
+	}
+	return c.executeSQLOnce(ctx, sql, databaseID, schema)
+}
+
+// executeSQLOnce 向Superset发送一次SQL执行请求
+func (c *Client) executeSQLOnce(ctx context.Context, sql string, databaseID int, schema string) (*SQLResult, error) {
+	if !c.inflight.begin() {
+		return nil, errClientClosing
+	}
+	defer c.inflight.end()
+
 	if err := c.ensureLoggedIn(ctx); err != nil {
-		return nil, fmt.Errorf("登录失败: %w", err)
+		return nil, timedOutBeforeSubmit(ctx, fmt.Errorf("登录失败: %w", err))
 	}
 
 	csrfToken, err := c.getCSRFToken(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("获取CSRF令牌失败: %w", err)
+		return nil, timedOutBeforeSubmit(ctx, fmt.Errorf("获取CSRF令牌失败: %w", err))
 	}
 
+	// 指定client_id，超时或取消时据此停止远端查询
+	clientID := newClientID()
 	payload := map[string]any{
+		"client_id":   clientID,
 		"database_id": databaseID,
 		"sql":         sql,
 		"schema":      schema,
@@ -400,17 +774,22 @@ func (c *Client) executeSQLInternal(ctx context.Context, sql string, databaseID
 	req.Header.Set(headerCSRF, csrfToken)
 	req.Header.Set(headerReferer, c.sqlLabURL)
 
-	resp, err := c.httpClient.Do(req)
+	// 统计HTTP往返耗时
+	startTime := time.Now()
+
+	resp, err := c.doAuthenticated(req)
 	if err != nil {
-		return nil, fmt.Errorf("执行SQL失败: %w", err)
+		return nil, c.interrupted(ctx, clientID, fmt.Errorf("执行SQL失败: %w", err))
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := common.ReadAllLimited(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
+		return nil, c.interrupted(ctx, clientID, fmt.Errorf("读取响应失败: %w", err))
 	}
 
+	duration := time.Since(startTime)
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("API请求失败，状态码: %d, 响应: %s", resp.StatusCode, string(body))
 	}
@@ -425,7 +804,8 @@ func (c *Client) executeSQLInternal(ctx context.Context, sql string, databaseID
 			Type       string `json:"type"`
 		} `json:"columns"`
 		Query struct {
-			SQL string `json:"sql"`
+			SQL  string `json:"sql"`
+			Rows *int   `json:"rows"`
 		} `json:"query"`
 	}
 
@@ -433,25 +813,375 @@ func (c *Client) executeSQLInternal(ctx context.Context, sql string, databaseID
 		return nil, fmt.Errorf("解析响应失败: %w, 响应体: %s", err, string(body))
 	}
 
-	// 预分配切片容量以提升性能
+	// 预分配切片容量以提升性能，列名和行数据使用同一字段作为键
 	columns := make([]string, 0, len(supersetResponse.Columns))
 	for _, col := range supersetResponse.Columns {
-		columns = append(columns, col.Name)
+		key := col.Name
+		if c.columnNameSource == ColumnNameSourceColumnName {
+			key = col.ColumnName
+		}
+		columns = append(columns, key)
+	}
+	// DDL或部分空结果的响应不带columns字段，此时从首行的键推导列名
+	if len(columns) == 0 && len(supersetResponse.Data) > 0 {
+		columns = columnsFromRow(supersetResponse.Data[0])
 	}
 
 	data := make([][]any, 0, len(supersetResponse.Data))
 	for _, row := range supersetResponse.Data {
-		rowData := make([]any, 0, len(supersetResponse.Columns))
-		for _, col := range supersetResponse.Columns {
-			rowData = append(rowData, row[col.Name])
+		rowData := make([]any, 0, len(columns))
+		for _, key := range columns {
+			rowData = append(rowData, row[key])
 		}
 		data = append(data, rowData)
 	}
 
+	// 优先使用Superset返回的行数元数据
+	rowCount := len(data)
+	if supersetResponse.Query.Rows != nil {
+		rowCount = *supersetResponse.Query.Rows
+	}
+
 	return &SQLResult{
-		Columns: columns,
-		Data:    data,
-		Query:   supersetResponse.Query.SQL,
-		Status:  supersetResponse.Status,
+		QueryID:    supersetResponse.QueryID,
+		Columns:    columns,
+		Data:       data,
+		Query:      supersetResponse.Query.SQL,
+		Status:     supersetResponse.Status,
+		RowCount:   rowCount,
+		DurationMS: duration.Milliseconds(),
+	}, nil
+}
+
+// getJSON 发送已认证的GET请求并解析JSON响应
+func (c *Client) getJSON(ctx context.Context, endpoint string, out any) error {
+	if err := c.ensureLoggedIn(ctx); err != nil {
+		return fmt.Errorf("登录失败: %w", err)
+	}
+
+	csrfToken, err := c.getCSRFToken(ctx)
+	if err != nil {
+		return fmt.Errorf("获取CSRF令牌失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	req.Header.Set(headerAccept, contentTypeJSON)
+	req.Header.Set(headerCSRF, csrfToken)
+	req.Header.Set(headerReferer, c.sqlLabURL)
+
+	resp, err := c.doAuthenticated(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := common.ReadAllLimited(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return &apiError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("解析响应失败: %w, 响应体: %s", err, string(body))
+	}
+
+	return nil
+}
+
+// postJSON 发送已认证的JSON POST请求并解析JSON响应
+func (c *Client) postJSON(ctx context.Context, endpoint string, payload, out any) error {
+	if err := c.ensureLoggedIn(ctx); err != nil {
+		return fmt.Errorf("登录失败: %w", err)
+	}
+
+	csrfToken, err := c.getCSRFToken(ctx)
+	if err != nil {
+		return fmt.Errorf("获取CSRF令牌失败: %w", err)
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+endpoint, bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentTypeJSON)
+	req.Header.Set(headerAccept, contentTypeJSON)
+	req.Header.Set(headerCSRF, csrfToken)
+	req.Header.Set(headerReferer, c.sqlLabURL)
+
+	resp, err := c.doAuthenticated(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := common.ReadAllLimited(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return &apiError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("解析响应失败: %w, 响应体: %s", err, string(body))
+	}
+
+	return nil
+}
+
+// GetQuery 获取SQL Lab查询记录
+func (c *Client) GetQuery(ctx context.Context, queryID int) (*QueryRecord, error) {
+	var result struct {
+		Result QueryRecord `json:"result"`
+	}
+
+	if err := c.getJSON(ctx, queryEndpoint+strconv.Itoa(queryID), &result); err != nil {
+		return nil, fmt.Errorf("获取查询记录失败: %w", err)
+	}
+
+	return &result.Result, nil
+}
+
+// GetResultsCSVURL 获取查询结果的CSV导出链接
+func (c *Client) GetResultsCSVURL(ctx context.Context, queryID int) (*CSVExport, error) {
+	query, err := c.GetQuery(ctx, queryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if query.ClientID == "" {
+		return nil, fmt.Errorf("查询 %d 缺少client_id，无法导出", queryID)
+	}
+
+	return &CSVExport{
+		QueryID:      queryID,
+		ClientID:     query.ClientID,
+		URL:          c.baseURL + csvExportEndpoint + url.PathEscape(query.ClientID) + "/",
+		Status:       query.Status,
+		Rows:         query.Rows,
+		SQL:          query.SQL,
+		AuthRequired: true,
+		AuthNote:     "该链接需要Superset登录会话，请使用对该数据库有访问权限的账号登录后在浏览器中打开",
+	}, nil
+}
+
+// GetQueryError 获取查询记录中保存的失败详情（错误信息和追踪链接）
+func (c *Client) GetQueryError(ctx context.Context, queryID int) (*QueryError, error) {
+	query, err := c.GetQuery(ctx, queryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueryError{
+		QueryID:      queryID,
+		Status:       query.Status,
+		Failed:       query.Status == "failed" || query.ErrorMessage != "",
+		ErrorMessage: query.ErrorMessage,
+		TrackingURL:  query.TrackingURL,
+		SQL:          query.SQL,
+		ExecutedSQL:  query.ExecutedSQL,
+		Database:     query.Database.DatabaseName,
+		Schema:       query.Schema,
 	}, nil
 }
+
+// GetCurrentUser 获取当前登录用户信息
+func (c *Client) GetCurrentUser(ctx context.Context) (*CurrentUser, error) {
+	var result struct {
+		Result CurrentUser `json:"result"`
+	}
+
+	if err := c.getJSON(ctx, meEndpoint, &result); err != nil {
+		return nil, fmt.Errorf("获取当前用户失败: %w", err)
+	}
+
+	return &result.Result, nil
+}
+
+// GetRecentQueries 获取当前用户最近的SQL Lab查询历史
+func (c *Client) GetRecentQueries(ctx context.Context, limit int) ([]QueryRecord, error) {
+	user, err := c.GetCurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	query := risonQuery{
+		Filters:        []risonFilter{{Col: "user", Opr: "rel_o_m", Value: risonInt(user.ID)}},
+		OrderColumn:    "start_time",
+		OrderDirection: "desc",
+		PageSize:       limit,
+	}
+
+	var result struct {
+		Result []QueryRecord `json:"result"`
+	}
+
+	if err := c.getJSON(ctx, query.endpoint(queryEndpoint), &result); err != nil {
+		return nil, fmt.Errorf("获取查询历史失败: %w", err)
+	}
+
+	return result.Result, nil
+}
+
+// GetDatabasePermissions 获取可访问指定数据库的角色列表
+// 当前账号无权访问安全相关接口时不返回错误，而是在结果中说明
+func (c *Client) GetDatabasePermissions(ctx context.Context, databaseID int) (*DatabasePermissions, error) {
+	var database struct {
+		Result struct {
+			DatabaseName string `json:"database_name"`
+		} `json:"result"`
+	}
+	if err := c.getJSON(ctx, databaseEndpoint+strconv.Itoa(databaseID), &database); err != nil {
+		return nil, fmt.Errorf("获取数据库信息失败: %w", err)
+	}
+
+	permissions := &DatabasePermissions{
+		DatabaseID:     databaseID,
+		DatabaseName:   database.Result.DatabaseName,
+		PermissionView: fmt.Sprintf("[%s].(id:%d)", database.Result.DatabaseName, databaseID),
+		Visible:        true,
+		Roles:          []RoleAccess{},
+	}
+
+	roles, truncated, err := c.listRoles(ctx)
+	if err != nil {
+		if isPermissionDenied(err) {
+			return permissionsHidden(permissions), nil
+		}
+		return nil, fmt.Errorf("获取角色列表失败: %w", err)
+	}
+	if truncated {
+		permissions.Truncated = true
+		permissions.Note = fmt.Sprintf("角色数量超过%d个，只检查了前%d个角色，结果可能不完整", maxRoles, maxRoles)
+	}
+
+	rolePerms, err := c.getRolePermissions(ctx, roles)
+	if err != nil {
+		if isPermissionDenied(err) {
+			return permissionsHidden(permissions), nil
+		}
+		return nil, err
+	}
+
+	for i, role := range roles {
+		if perm, ok := matchDatabaseAccess(rolePerms[i], permissions.PermissionView); ok {
+			permissions.Roles = append(permissions.Roles, RoleAccess{ID: role.ID, Name: role.Name, Permission: perm})
+		}
+	}
+
+	return permissions, nil
+}
+
+// listRoles 分页获取角色列表，最多返回maxRoles个角色，超出时truncated为true
+func (c *Client) listRoles(ctx context.Context) (roles []roleSummary, truncated bool, err error) {
+	for page := 0; ; page++ {
+		var result struct {
+			Count  int           `json:"count"`
+			Result []roleSummary `json:"result"`
+		}
+		query := risonQuery{Page: page, PageSize: maxRolesPageSize}
+		if err := c.getJSON(ctx, query.endpoint(rolesEndpoint), &result); err != nil {
+			return nil, false, err
+		}
+		roles = append(roles, result.Result...)
+
+		// 未返回count时以是否取满一页判断是否还有下一页
+		more := len(result.Result) == maxRolesPageSize && (result.Count == 0 || len(roles) < result.Count)
+		if !more {
+			return roles, false, nil
+		}
+		if len(roles) >= maxRoles {
+			return roles[:maxRoles], true, nil
+		}
+	}
+}
+
+// getRolePermissions 以有限并发获取每个角色的权限列表，结果与roles按下标对应
+// Superset没有按数据库反查角色的接口，只能逐个角色查询
+func (c *Client) getRolePermissions(ctx context.Context, roles []roleSummary) ([][]rolePermission, error) {
+	results := make([][]rolePermission, len(roles))
+	errs := make([]error, len(roles))
+	sem := make(chan struct{}, maxRolePermissionConcurrency)
+	var wg sync.WaitGroup
+
+	for i, role := range roles {
+		wg.Add(1)
+		go func(i int, role roleSummary) {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var rolePerms struct {
+				Result []rolePermission `json:"result"`
+			}
+			if err := c.getJSON(ctx, rolesEndpoint+strconv.Itoa(role.ID)+"/permissions/", &rolePerms); err != nil {
+				errs[i] = fmt.Errorf("获取角色 %s 的权限失败: %w", role.Name, err)
+				return
+			}
+			results[i] = rolePerms.Result
+		}(i, role)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+// matchDatabaseAccess 检查权限列表中是否包含对指定数据库的访问权限
+func matchDatabaseAccess(perms []rolePermission, permissionView string) (string, bool) {
+	for _, perm := range perms {
+		switch {
+		case perm.PermissionName == permAllDatabaseAccess:
+			return permAllDatabaseAccess, true
+		case perm.PermissionName == permDatabaseAccess && perm.ViewMenuName == permissionView:
+			return permDatabaseAccess, true
+		}
+	}
+	return "", false
+}
+
+// isPermissionDenied 判断是否为无权限访问错误
+func isPermissionDenied(err error) bool {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden
+}
+
+// permissionsHidden 标记当前账号无权查看角色权限
+func permissionsHidden(permissions *DatabasePermissions) *DatabasePermissions {
+	permissions.Visible = false
+	permissions.Roles = nil
+	permissions.Note = "当前账号无权访问Superset安全接口，无法列出角色权限；需要管理员权限，或在Superset中开启FAB_ADD_SECURITY_API"
+	return permissions
+}
+
+// columnsFromRow 按字典序返回行数据的键，保证列顺序稳定
+func columnsFromRow(row map[string]any) []string {
+	columns := make([]string, 0, len(row))
+	for key := range row {
+		columns = append(columns, key)
+	}
+	sort.Strings(columns)
+	return columns
+}
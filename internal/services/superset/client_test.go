@@ -0,0 +1,120 @@
+package superset
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBaseURLTrailingSlash(t *testing.T) {
+	f := newFakeSuperset(t)
+	f.handle(meEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"result": map[string]any{"id": 1, "username": "admin"}})
+	})
+
+	for _, baseURL := range []string{f.URL, f.URL + "/", f.URL + "//"} {
+		client, err := NewClient(baseURL, "admin", "admin", 5*time.Second, ClientOptions{})
+		if err != nil {
+			t.Fatalf("NewClient(%q): %v", baseURL, err)
+		}
+		t.Cleanup(func() { client.Close(context.Background()) })
+
+		if client.baseURL != f.URL {
+			t.Errorf("baseURL = %q, want %q", client.baseURL, f.URL)
+		}
+		if _, err := client.GetCurrentUser(context.Background()); err != nil {
+			t.Fatalf("GetCurrentUser via %q: %v", baseURL, err)
+		}
+	}
+
+	// 三个客户端的请求路径完全相同，没有 //api/v1 形式的路径
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for path, count := range f.requests {
+		if strings.HasPrefix(path, "//") {
+			t.Errorf("request path %q (%d requests) has a doubled slash", path, count)
+		}
+	}
+	if len(f.requests) != 2 || f.requests[loginEndpoint]%3 != 0 || f.requests[meEndpoint]%3 != 0 {
+		t.Errorf("requests = %v, want the same requests from each client", f.requests)
+	}
+}
+
+func TestExecuteSQLColumnNameSource(t *testing.T) {
+	// 列的展示名称（别名）与原始列名不同
+	f := newFakeSuperset(t)
+	f.handle(sqlExecuteEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"query_id": 7,
+			"status":   "success",
+			"columns": []map[string]any{
+				{"name": "User Count", "column_name": "user_count", "type": "BIGINT"},
+			},
+			"data": []map[string]any{{"User Count": 42, "user_count": 41}},
+		})
+	})
+
+	for _, tc := range []struct {
+		source string
+		column string
+		value  any
+	}{
+		{"", "User Count", float64(42)},
+		{ColumnNameSourceName, "User Count", float64(42)},
+		{ColumnNameSourceColumnName, "user_count", float64(41)},
+	} {
+		client := f.newClient(t, ClientOptions{ColumnNameSource: tc.source})
+		result, err := client.ExecuteSQL(context.Background(), "SELECT count(*) AS \"User Count\" FROM users", 1)
+		if err != nil {
+			t.Fatalf("source %q: ExecuteSQL: %v", tc.source, err)
+		}
+		// 列名和行数据使用同一字段作为键
+		if len(result.Columns) != 1 || result.Columns[0] != tc.column {
+			t.Errorf("source %q: columns = %v, want [%s]", tc.source, result.Columns, tc.column)
+		}
+		if len(result.Data) != 1 || len(result.Data[0]) != 1 || result.Data[0][0] != tc.value {
+			t.Errorf("source %q: data = %v, want [[%v]]", tc.source, result.Data, tc.value)
+		}
+	}
+}
+
+func TestExecuteSQLWithoutColumns(t *testing.T) {
+	// 响应不带columns字段时从首行的键推导列名，按字典序排列
+	f := newFakeSuperset(t)
+	f.handle(sqlExecuteEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"query_id": 8,
+			"status":   "success",
+			"data": []map[string]any{
+				{"name": "alice", "id": 1, "active": true},
+				{"name": "bob", "id": 2, "active": false},
+			},
+		})
+	})
+	client := f.newClient(t, ClientOptions{})
+
+	result, err := client.ExecuteSQL(context.Background(), "SELECT * FROM users", 1)
+	if err != nil {
+		t.Fatalf("ExecuteSQL: %v", err)
+	}
+	if want := []string{"active", "id", "name"}; !slices.Equal(result.Columns, want) {
+		t.Errorf("columns = %v, want %v", result.Columns, want)
+	}
+	want := [][]any{{true, float64(1), "alice"}, {false, float64(2), "bob"}}
+	if len(result.Data) != len(want) {
+		t.Fatalf("data = %v, want %v", result.Data, want)
+	}
+	for i, row := range want {
+		if !slices.Equal(result.Data[i], row) {
+			t.Errorf("row %d = %v, want %v", i, result.Data[i], row)
+		}
+	}
+
+	// 空结果没有列
+	if columns := columnsFromRow(map[string]any{}); len(columns) != 0 {
+		t.Errorf("columnsFromRow(empty) = %v, want none", columns)
+	}
+}
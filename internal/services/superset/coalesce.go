@@ -0,0 +1,74 @@
+package superset
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// sqlCoalescer 合并并发的相同SQL执行，同一时刻只向Superset发送一次请求
+type sqlCoalescer struct {
+	mu    sync.Mutex
+	calls map[string]*sqlCall
+}
+
+// sqlCall 进行中的SQL执行
+type sqlCall struct {
+	done   chan struct{} // 执行结束后关闭
+	result *SQLResult
+	err    error
+
+	// leaderDone 发起执行的请求因自身上下文结束而失败，等待者不应沿用该错误
+	leaderDone bool
+}
+
+// newSQLCoalescer 创建SQL执行合并器
+func newSQLCoalescer() *sqlCoalescer {
+	return &sqlCoalescer{calls: make(map[string]*sqlCall)}
+}
+
+// coalesceKey 生成合并键，数据库、schema和SQL全部相同才视为相同查询
+func coalesceKey(databaseID int, schema, sql string) string {
+	return strconv.Itoa(databaseID) + "\x00" + schema + "\x00" + sql
+}
+
+// do 执行fn，已有相同key的执行进行中时等待其结果
+// 发起执行的请求被取消或超时时，仍在等待的请求改为自行重新执行
+func (g *sqlCoalescer) do(ctx context.Context, key string, fn func(context.Context) (*SQLResult, error)) (*SQLResult, error) {
+	for {
+		g.mu.Lock()
+		if call, ok := g.calls[key]; ok {
+			g.mu.Unlock()
+
+			select {
+			case <-call.done:
+				if call.leaderDone && ctx.Err() == nil {
+					continue
+				}
+				if call.err != nil {
+					return nil, call.err
+				}
+				// 返回副本，调用方修改结果字段不影响其他等待者
+				result := *call.result
+				return &result, nil
+			case <-ctx.Done():
+				return nil, fmt.Errorf("等待相同SQL查询的结果时请求结束: %w", ctx.Err())
+			}
+		}
+
+		call := &sqlCall{done: make(chan struct{})}
+		g.calls[key] = call
+		g.mu.Unlock()
+
+		call.result, call.err = fn(ctx)
+		call.leaderDone = call.err != nil && ctx.Err() != nil
+
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(call.done)
+
+		return call.result, call.err
+	}
+}
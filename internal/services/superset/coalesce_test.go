@@ -0,0 +1,72 @@
+package superset
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCoalesceIdenticalConcurrentQueries(t *testing.T) {
+	f := newFakeSuperset(t)
+	entered := make(chan struct{}, 4)
+	release := make(chan struct{})
+	f.handle(sqlExecuteEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		writeJSON(w, map[string]any{
+			"query_id": 9,
+			"status":   "success",
+			"columns":  []map[string]any{{"name": "n", "column_name": "n"}},
+			"data":     []map[string]any{{"n": 1}},
+		})
+	})
+	client := f.newClient(t, ClientOptions{CoalesceQueries: true})
+
+	const callers = 2
+	results := make([]*SQLResult, callers)
+	errs := make([]error, callers)
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = client.ExecuteSQL(context.Background(), "SELECT 1 AS n", 1)
+		}()
+	}
+
+	// 第一个请求到达上游后保持进行中，留出时间让第二个请求加入等待
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("query did not reach upstream")
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	for i := range callers {
+		if errs[i] != nil {
+			t.Fatalf("caller %d: ExecuteSQL: %v", i, errs[i])
+		}
+		if results[i].QueryID != 9 || len(results[i].Data) != 1 {
+			t.Errorf("caller %d: result = %+v, want the shared upstream result", i, results[i])
+		}
+	}
+	if n := f.count(sqlExecuteEndpoint); n != 1 {
+		t.Errorf("upstream executions = %d, want 1", n)
+	}
+	// 每个调用方拿到独立的结果副本
+	if results[0] == results[1] {
+		t.Error("callers share the same *SQLResult")
+	}
+
+	// 合并结束后相同SQL重新执行
+	if _, err := client.ExecuteSQL(context.Background(), "SELECT 1 AS n", 1); err != nil {
+		t.Fatalf("ExecuteSQL after coalescing: %v", err)
+	}
+	if n := f.count(sqlExecuteEndpoint); n != 2 {
+		t.Errorf("upstream executions after a later call = %d, want 2", n)
+	}
+}
@@ -0,0 +1,68 @@
+package superset
+
+import (
+	"context"
+	"fmt"
+)
+
+// dashboardEndpoint 看板列表端点
+const dashboardEndpoint = "/api/v1/dashboard/"
+
+// Dashboard 看板信息
+type Dashboard struct {
+	ID        int    `json:"id"`
+	Title     string `json:"title"`
+	Slug      string `json:"slug"`
+	Published bool   `json:"published"`
+	URL       string `json:"url"`
+}
+
+// GetDashboards 获取看板列表，titleFilter非空时按标题模糊匹配
+func (c *Client) GetDashboards(ctx context.Context, titleFilter string) ([]Dashboard, error) {
+	query := risonQuery{
+		OrderColumn:    "changed_on_delta_humanized",
+		OrderDirection: "desc",
+		PageSize:       listPageSize,
+	}
+	if titleFilter != "" {
+		query.Filters = []risonFilter{{Col: "dashboard_title", Opr: "ct", Value: risonString(titleFilter)}}
+	}
+
+	dashboards := make([]Dashboard, 0)
+	for page := 0; page < maxListPages; page++ {
+		query.Page = page
+
+		var response struct {
+			Count  int `json:"count"`
+			Result []struct {
+				ID             int     `json:"id"`
+				DashboardTitle string  `json:"dashboard_title"`
+				Slug           *string `json:"slug"`
+				Published      bool    `json:"published"`
+				URL            string  `json:"url"`
+			} `json:"result"`
+		}
+		if err := c.getJSON(ctx, query.endpoint(dashboardEndpoint), &response); err != nil {
+			return nil, fmt.Errorf("获取看板列表失败: %w", err)
+		}
+
+		for _, item := range response.Result {
+			dashboard := Dashboard{
+				ID:        item.ID,
+				Title:     item.DashboardTitle,
+				Published: item.Published,
+				URL:       item.URL,
+			}
+			if item.Slug != nil {
+				dashboard.Slug = *item.Slug
+			}
+			dashboards = append(dashboards, dashboard)
+		}
+
+		if len(response.Result) == 0 || len(dashboards) >= response.Count {
+			break
+		}
+	}
+
+	return dashboards, nil
+}
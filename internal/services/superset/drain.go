@@ -0,0 +1,77 @@
+package superset
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// defaultDrainTimeout 未提供截止时间的关闭（如配置重载替换服务）等待进行中查询的最长时间
+const defaultDrainTimeout = 30 * time.Second
+
+// errClientClosing 客户端关闭后拒绝新的SQL执行
+var errClientClosing = fmt.Errorf("服务正在关闭，不再接受新的SQL查询")
+
+// inflightTracker 跟踪进行中的SQL执行，关闭时等待其完成
+type inflightTracker struct {
+	mu      sync.Mutex
+	active  int
+	closing bool
+	done    chan struct{} // 关闭后所有进行中的执行结束时关闭
+}
+
+// begin 登记一次SQL执行，客户端关闭后返回false
+func (t *inflightTracker) begin() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closing {
+		return false
+	}
+	t.active++
+	return true
+}
+
+// end 结束一次SQL执行
+func (t *inflightTracker) end() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.active--
+	if t.closing && t.active == 0 {
+		close(t.done)
+	}
+}
+
+// drain 停止接受新的执行，并等待进行中的执行完成或ctx到期
+func (t *inflightTracker) drain(ctx context.Context) error {
+	t.mu.Lock()
+	if !t.closing {
+		t.closing = true
+		t.done = make(chan struct{})
+		if t.active == 0 {
+			close(t.done)
+		}
+	}
+	active, done := t.active, t.done
+	t.mu.Unlock()
+
+	if active > 0 {
+		log.Printf("Superset客户端关闭: 等待 %d 个进行中的SQL查询完成", active)
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		t.mu.Lock()
+		remaining := t.active
+		t.mu.Unlock()
+		return fmt.Errorf("等待进行中的SQL查询超时，仍有 %d 个未完成: %w", remaining, ctx.Err())
+	}
+}
+
+// Close 停止接受新的SQL执行，并在ctx截止前等待进行中的执行完成
+func (c *Client) Close(ctx context.Context) error {
+	return c.inflight.drain(ctx)
+}
@@ -0,0 +1,85 @@
+package superset
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSuperset 测试用Superset服务器，处理登录、会话确认和CSRF令牌，其余路径交给注册的处理函数
+type fakeSuperset struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	handlers map[string]http.HandlerFunc
+	requests map[string]int // 路径 -> 请求次数
+}
+
+// newFakeSuperset 创建并启动测试用Superset服务器，测试结束时自动关闭
+func newFakeSuperset(t *testing.T) *fakeSuperset {
+	t.Helper()
+
+	f := &fakeSuperset{
+		handlers: make(map[string]http.HandlerFunc),
+		requests: make(map[string]int),
+	}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// handle 注册路径的处理函数，覆盖默认行为
+func (f *fakeSuperset) handle(path string, handler http.HandlerFunc) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[path] = handler
+}
+
+// count 返回路径收到的请求次数
+func (f *fakeSuperset) count(path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[path]
+}
+
+func (f *fakeSuperset) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests[r.URL.Path]++
+	handler := f.handlers[r.URL.Path]
+	f.mu.Unlock()
+
+	switch {
+	case handler != nil:
+		handler(w, r)
+	case r.URL.Path == loginEndpoint && r.Method == http.MethodPost:
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s", Path: "/"})
+		w.WriteHeader(http.StatusOK)
+	case r.URL.Path == meEndpoint:
+		writeJSON(w, map[string]any{"result": map[string]any{"username": "admin"}})
+	default:
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<input id="csrf_token" name="csrf_token" type="hidden" value="tok">`))
+	}
+}
+
+// newClient 创建连接到测试服务器的客户端
+func (f *fakeSuperset) newClient(t *testing.T, opts ClientOptions) *Client {
+	t.Helper()
+
+	client, err := NewClient(f.URL, "admin", "admin", 5*time.Second, opts)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { client.Close(context.Background()) })
+	return client
+}
+
+// writeJSON 以JSON格式写入响应
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", contentTypeJSON)
+	json.NewEncoder(w).Encode(value)
+}
@@ -0,0 +1,109 @@
+package superset
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"mcp-server/internal/common"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// SQL结果输出格式
+const (
+	formatJSON     = "json"
+	formatCSV      = "csv"
+	formatMarkdown = common.FormatMarkdown
+)
+
+// validateFormat 校验输出格式，为空表示默认的JSON
+func validateFormat(format string) error {
+	switch format {
+	case "", formatJSON, formatCSV, formatMarkdown:
+		return nil
+	}
+	return fmt.Errorf("不支持的输出格式: %s，可选值为 json、csv 或 markdown", format)
+}
+
+// renderSQLResult 按指定格式生成SQL结果响应
+func renderSQLResult(result *SQLResult, format string) (*mcp.CallToolResultFor[any], error) {
+	switch format {
+	case formatCSV:
+		text, err := formatCSVTable(result.Columns, result.Data)
+		if err != nil {
+			return common.CreateErrorResponse("生成CSV失败: %v", err)
+		}
+		return common.CreateSimpleSuccessResponse(text)
+	case formatMarkdown:
+		return common.CreateSimpleSuccessResponse(formatMarkdownTable(result.Columns, result.Data))
+	default:
+		return common.CreateSuccessResponse(result)
+	}
+}
+
+// formatMarkdownTable 将列和数据行渲染为Markdown表格，null单元格输出为空
+func formatMarkdownTable(columns []string, data [][]any) string {
+	rows := make([][]string, 0, len(data))
+	for _, row := range data {
+		cells := make([]string, len(row))
+		for i, value := range row {
+			cells[i] = formatCell(value)
+		}
+		rows = append(rows, cells)
+	}
+	return common.FormatMarkdownTable(columns, rows)
+}
+
+// formatCSVTable 将列和数据行序列化为CSV，首行为列名，null单元格输出为空字段
+func formatCSVTable(columns []string, data [][]any) (string, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(columns); err != nil {
+		return "", err
+	}
+
+	record := make([]string, len(columns))
+	for _, row := range data {
+		for i := range record {
+			record[i] = ""
+			if i < len(row) {
+				record[i] = formatCell(row[i])
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return "", err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// formatCell 将单元格值转换为文本，数字不使用科学计数法，复合值输出为JSON
+func formatCell(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case json.Number:
+		return v.String()
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
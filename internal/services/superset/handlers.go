@@ -2,39 +2,119 @@ package superset
 
 import (
 	"context"
+	"fmt"
 	"strconv"
+	"time"
 
 	"mcp-server/internal/common"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+// 常量定义
+const (
+	defaultMyQueriesLimit = 20
+	maxMyQueriesLimit     = 100
+)
+
+// toolOptions 工具处理器配置
+type toolOptions struct {
+	sqlTimeout     time.Duration // SQL执行超时，超时后请求Superset停止查询
+	requestTimeout time.Duration // 列表、元数据等非SQL执行请求的超时
+	enableAdminAPI bool          // 是否注册superset_logout等管理工具
+}
+
 // 工具参数结构体
-type ListDatabasesParams struct{}
+type ListDatabasesParams struct {
+	Refresh bool `json:"refresh,omitempty" jsonschema:"跳过缓存重新获取数据库列表，刚在Superset中新增数据库时使用"`
+}
 
 type ExecuteSQLParams struct {
 	SQL        string `json:"sql" jsonschema:"要执行的SQL查询语句"`
 	DatabaseID string `json:"database_id" jsonschema:"数据库ID (数字)"`
+	PageSize   int    `json:"page_size,omitempty" jsonschema:"分页大小，可选，设置后返回第一页和next_cursor，最大1000"`
+	Format     string `json:"format,omitempty" jsonschema:"输出格式，json(默认)、csv 或 markdown，csv和markdown不支持与page_size同时使用"`
 }
 
 type ExecuteSQLWithSchemaParams struct {
 	SQL        string `json:"sql" jsonschema:"要执行的SQL查询语句"`
 	DatabaseID string `json:"database_id" jsonschema:"数据库ID (数字)"`
 	Schema     string `json:"schema" jsonschema:"数据库schema名称"`
+	PageSize   int    `json:"page_size,omitempty" jsonschema:"分页大小，可选，设置后返回第一页和next_cursor，最大1000"`
+	Format     string `json:"format,omitempty" jsonschema:"输出格式，json(默认)、csv 或 markdown，csv和markdown不支持与page_size同时使用"`
+}
+
+type NextPageParams struct {
+	Cursor string `json:"cursor" jsonschema:"上一页结果中的next_cursor"`
 }
 
 type StatusParams struct{}
 
+type VersionParams struct{}
+
+type LogoutParams struct{}
+
+type ValidateSQLParams struct {
+	SQL        string `json:"sql" jsonschema:"要校验的SQL语句"`
+	DatabaseID string `json:"database_id" jsonschema:"数据库ID (数字)"`
+	Schema     string `json:"schema,omitempty" jsonschema:"数据库schema名称，可选"`
+}
+
+type MyQueriesParams struct {
+	Limit int `json:"limit,omitempty" jsonschema:"返回的最大查询数，默认20，最大100"`
+}
+
+type DatabasePermissionsParams struct {
+	DatabaseID string `json:"database_id" jsonschema:"数据库ID (数字)"`
+}
+
+type ExportCSVParams struct {
+	QueryID string `json:"query_id" jsonschema:"SQL Lab查询ID (数字，来自SQL执行结果的query_id)"`
+}
+
+type ListChartsParams struct {
+	NameFilter string `json:"name_filter,omitempty" jsonschema:"按图表标题模糊过滤，可选"`
+}
+
+type ListDashboardsParams struct {
+	TitleFilter string `json:"title_filter,omitempty" jsonschema:"按看板标题模糊过滤，可选"`
+}
+
+type QueryErrorParams struct {
+	QueryID string `json:"query_id" jsonschema:"SQL Lab查询ID (数字，来自SQL执行结果或superset_my_queries的id)"`
+}
+
+type ListSavedQueriesParams struct {
+	DatabaseID string `json:"database_id" jsonschema:"数据库ID (数字)"`
+}
+
+type RunSavedQueryParams struct {
+	SavedQueryID string `json:"saved_query_id" jsonschema:"已保存查询ID (数字，来自superset_list_saved_queries)"`
+	Format       string `json:"format,omitempty" jsonschema:"输出格式，json(默认)、csv 或 markdown"`
+}
+
+type GetChartDataParams struct {
+	ChartID string `json:"chart_id" jsonschema:"图表ID (数字)"`
+}
+
 // createListDatabasesHandler 创建数据库列表处理器
-func createListDatabasesHandler(client *Client) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[ListDatabasesParams]) (*mcp.CallToolResultFor[any], error) {
+func createListDatabasesHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[ListDatabasesParams]) (*mcp.CallToolResultFor[any], error) {
 	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[ListDatabasesParams]) (*mcp.CallToolResultFor[any], error) {
 		if client == nil {
 			return common.CreateErrorResponse("Superset客户端不可用")
 		}
 
-		databases, err := client.GetDatabases(ctx)
+		getDatabases := client.GetDatabases
+		if params.Arguments.Refresh {
+			getDatabases = client.RefreshDatabases
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.requestTimeout)
+		defer cancel()
+
+		databases, err := getDatabases(queryCtx)
 		if err != nil {
-			return common.CreateErrorResponse("获取数据库列表失败: %v", err)
+			return common.CreateErrorResponse("获取数据库列表失败: %v", deadline.Explain(err))
 		}
 
 		dbInfo := map[string]any{
@@ -47,7 +127,7 @@ func createListDatabasesHandler(client *Client) func(context.Context, *mcp.Serve
 }
 
 // createExecuteSQLHandler 创建SQL执行处理器
-func createExecuteSQLHandler(client *Client) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[ExecuteSQLParams]) (*mcp.CallToolResultFor[any], error) {
+func createExecuteSQLHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[ExecuteSQLParams]) (*mcp.CallToolResultFor[any], error) {
 	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[ExecuteSQLParams]) (*mcp.CallToolResultFor[any], error) {
 		if client == nil {
 			return common.CreateErrorResponse("Superset客户端不可用")
@@ -59,17 +139,37 @@ func createExecuteSQLHandler(client *Client) func(context.Context, *mcp.ServerSe
 			return common.CreateErrorResponse("无效的数据库ID格式: %v", err)
 		}
 
-		result, err := client.ExecuteSQL(ctx, params.Arguments.SQL, databaseID)
+		format := params.Arguments.Format
+		if err := validateFormat(format); err != nil {
+			return common.CreateErrorResponse("%v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.sqlTimeout)
+		defer cancel()
+
+		// 指定分页大小时返回第一页和续页游标
+		if params.Arguments.PageSize > 0 {
+			if format == formatCSV || format == formatMarkdown {
+				return common.CreateErrorResponse("%s格式不支持分页，请去掉page_size或使用json格式", format)
+			}
+			page, err := client.ExecuteSQLPage(queryCtx, params.Arguments.SQL, databaseID, "", params.Arguments.PageSize)
+			if err != nil {
+				return sqlErrorResponse("执行SQL失败", err, deadline)
+			}
+			return common.CreateSuccessResponse(page)
+		}
+
+		result, err := client.ExecuteSQL(queryCtx, params.Arguments.SQL, databaseID)
 		if err != nil {
-			return common.CreateErrorResponse("执行SQL失败: %v", err)
+			return sqlErrorResponse("执行SQL失败", err, deadline)
 		}
 
-		return common.CreateSuccessResponse(result)
+		return renderSQLResult(result, format)
 	}
 }
 
 // createExecuteSQLWithSchemaHandler 创建带schema的SQL执行处理器
-func createExecuteSQLWithSchemaHandler(client *Client) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[ExecuteSQLWithSchemaParams]) (*mcp.CallToolResultFor[any], error) {
+func createExecuteSQLWithSchemaHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[ExecuteSQLWithSchemaParams]) (*mcp.CallToolResultFor[any], error) {
 	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[ExecuteSQLWithSchemaParams]) (*mcp.CallToolResultFor[any], error) {
 		if client == nil {
 			return common.CreateErrorResponse("Superset客户端不可用")
@@ -81,12 +181,51 @@ func createExecuteSQLWithSchemaHandler(client *Client) func(context.Context, *mc
 			return common.CreateErrorResponse("无效的数据库ID格式: %v", err)
 		}
 
-		result, err := client.ExecuteSQLWithSchema(ctx, params.Arguments.SQL, databaseID, params.Arguments.Schema)
+		format := params.Arguments.Format
+		if err := validateFormat(format); err != nil {
+			return common.CreateErrorResponse("%v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.sqlTimeout)
+		defer cancel()
+
+		// 指定分页大小时返回第一页和续页游标
+		if params.Arguments.PageSize > 0 {
+			if format == formatCSV || format == formatMarkdown {
+				return common.CreateErrorResponse("%s格式不支持分页，请去掉page_size或使用json格式", format)
+			}
+			page, err := client.ExecuteSQLPage(queryCtx, params.Arguments.SQL, databaseID, params.Arguments.Schema, params.Arguments.PageSize)
+			if err != nil {
+				return sqlErrorResponse("执行SQL失败", err, deadline)
+			}
+			return common.CreateSuccessResponse(page)
+		}
+
+		result, err := client.ExecuteSQLWithSchema(queryCtx, params.Arguments.SQL, databaseID, params.Arguments.Schema)
 		if err != nil {
-			return common.CreateErrorResponse("执行SQL失败: %v", err)
+			return sqlErrorResponse("执行SQL失败", err, deadline)
 		}
 
-		return common.CreateSuccessResponse(result)
+		return renderSQLResult(result, format)
+	}
+}
+
+// createNextPageHandler 创建分页续查处理器
+func createNextPageHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[NextPageParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[NextPageParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Superset客户端不可用")
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.sqlTimeout)
+		defer cancel()
+
+		page, err := client.NextPage(queryCtx, params.Arguments.Cursor)
+		if err != nil {
+			return sqlErrorResponse("获取下一页失败", err, deadline)
+		}
+
+		return common.CreateSuccessResponse(page)
 	}
 }
 
@@ -124,3 +263,332 @@ func createStatusHandler(client *Client) func(context.Context, *mcp.ServerSessio
 		return common.CreateSuccessResponse(status)
 	}
 }
+
+// createExportCSVHandler 创建CSV导出链接处理器
+func createExportCSVHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[ExportCSVParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[ExportCSVParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Superset客户端不可用")
+		}
+
+		// 解析查询ID
+		queryID, err := strconv.Atoi(params.Arguments.QueryID)
+		if err != nil {
+			return common.CreateErrorResponse("无效的查询ID格式: %v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.requestTimeout)
+		defer cancel()
+
+		export, err := client.GetResultsCSVURL(queryCtx, queryID)
+		if err != nil {
+			return common.CreateErrorResponse("获取CSV导出链接失败: %v", deadline.Explain(err))
+		}
+
+		return common.CreateSuccessResponse(export)
+	}
+}
+
+// createQueryErrorHandler 创建查询失败详情处理器
+func createQueryErrorHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[QueryErrorParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[QueryErrorParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Superset客户端不可用")
+		}
+
+		queryID, err := strconv.Atoi(params.Arguments.QueryID)
+		if err != nil {
+			return common.CreateErrorResponse("无效的查询ID格式: %v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.requestTimeout)
+		defer cancel()
+
+		queryError, err := client.GetQueryError(queryCtx, queryID)
+		if err != nil {
+			return common.CreateErrorResponse("获取查询失败详情失败: %v", deadline.Explain(err))
+		}
+
+		return common.CreateSuccessResponse(queryError)
+	}
+}
+
+// queryHistoryInfo 查询历史信息
+type queryHistoryInfo struct {
+	ID         int     `json:"id"`
+	Statement  string  `json:"statement"`
+	Status     string  `json:"status"`
+	Rows       int     `json:"rows"`
+	Database   string  `json:"database"`
+	Schema     string  `json:"schema"`
+	StartTime  string  `json:"start_time,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+// createMyQueriesHandler 创建当前用户查询历史处理器
+func createMyQueriesHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[MyQueriesParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[MyQueriesParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Superset客户端不可用")
+		}
+
+		limit := params.Arguments.Limit
+		if limit <= 0 {
+			limit = defaultMyQueriesLimit
+		}
+		if limit > maxMyQueriesLimit {
+			limit = maxMyQueriesLimit
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.requestTimeout)
+		defer cancel()
+
+		queries, err := client.GetRecentQueries(queryCtx, limit)
+		if err != nil {
+			return common.CreateErrorResponse("获取查询历史失败: %v", deadline.Explain(err))
+		}
+
+		history := make([]queryHistoryInfo, 0, len(queries))
+		for _, query := range queries {
+			info := queryHistoryInfo{
+				ID:        query.ID,
+				Statement: query.SQL,
+				Status:    query.Status,
+				Rows:      query.Rows,
+				Database:  query.Database.DatabaseName,
+				Schema:    query.Schema,
+			}
+			if query.StartTime > 0 {
+				info.StartTime = time.UnixMilli(int64(query.StartTime)).UTC().Format(time.RFC3339)
+				if query.EndTime >= query.StartTime {
+					info.DurationMS = float64(query.EndTime - query.StartTime)
+				}
+			}
+			history = append(history, info)
+		}
+
+		result := map[string]any{
+			"count":   len(history),
+			"queries": history,
+		}
+
+		return common.CreateSuccessResponse(result)
+	}
+}
+
+// createDatabasePermissionsHandler 创建数据库权限查询处理器
+func createDatabasePermissionsHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[DatabasePermissionsParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[DatabasePermissionsParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Superset客户端不可用")
+		}
+
+		databaseID, err := strconv.Atoi(params.Arguments.DatabaseID)
+		if err != nil {
+			return common.CreateErrorResponse("无效的数据库ID格式: %v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.requestTimeout)
+		defer cancel()
+
+		permissions, err := client.GetDatabasePermissions(queryCtx, databaseID)
+		if err != nil {
+			return common.CreateErrorResponse("获取数据库权限失败: %v", deadline.Explain(err))
+		}
+
+		return common.CreateSuccessResponse(permissions)
+	}
+}
+
+// createVersionHandler 创建版本信息查询处理器
+func createVersionHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[VersionParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, _ *mcp.CallToolParamsFor[VersionParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Superset客户端不可用")
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.requestTimeout)
+		defer cancel()
+
+		version, err := client.GetVersion(queryCtx)
+		if err != nil {
+			return common.CreateErrorResponse("获取版本信息失败: %v", deadline.Explain(err))
+		}
+
+		return common.CreateSuccessResponse(version)
+	}
+}
+
+// createLogoutHandler 创建登出处理器
+func createLogoutHandler(client *Client) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[LogoutParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, _ *mcp.CallToolParamsFor[LogoutParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Superset客户端不可用")
+		}
+
+		if err := client.Logout(ctx); err != nil {
+			return common.CreateErrorResponse("登出失败: %v", err)
+		}
+
+		return common.CreateSimpleSuccessResponse("已登出并清除会话，下次请求时将重新登录")
+	}
+}
+
+// createValidateSQLHandler 创建SQL校验处理器
+func createValidateSQLHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[ValidateSQLParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[ValidateSQLParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Superset客户端不可用")
+		}
+
+		databaseID, err := strconv.Atoi(params.Arguments.DatabaseID)
+		if err != nil {
+			return common.CreateErrorResponse("无效的数据库ID格式: %v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.requestTimeout)
+		defer cancel()
+
+		result, err := client.ValidateSQLWithSchema(queryCtx, params.Arguments.SQL, databaseID, params.Arguments.Schema)
+		if err != nil {
+			return common.CreateErrorResponse("%v", deadline.Explain(err))
+		}
+
+		return common.CreateSuccessResponse(result)
+	}
+}
+
+// createGetChartDataHandler 创建图表数据处理器
+func createGetChartDataHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[GetChartDataParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[GetChartDataParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Superset客户端不可用")
+		}
+
+		chartID, err := strconv.Atoi(params.Arguments.ChartID)
+		if err != nil {
+			return common.CreateErrorResponse("无效的图表ID格式: %v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.requestTimeout)
+		defer cancel()
+
+		chartData, err := client.GetChartData(queryCtx, chartID)
+		if err != nil {
+			return common.CreateErrorResponse("%v", deadline.Explain(err))
+		}
+
+		return common.CreateSuccessResponse(chartData)
+	}
+}
+
+// createListChartsHandler 创建图表列表处理器
+func createListChartsHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[ListChartsParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[ListChartsParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Superset客户端不可用")
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.requestTimeout)
+		defer cancel()
+
+		charts, err := client.GetCharts(queryCtx, params.Arguments.NameFilter)
+		if err != nil {
+			return common.CreateErrorResponse("%v", deadline.Explain(err))
+		}
+
+		chartInfo := map[string]any{
+			"count":  len(charts),
+			"charts": charts,
+		}
+
+		return common.CreateSuccessResponse(chartInfo)
+	}
+}
+
+// createListDashboardsHandler 创建看板列表处理器
+func createListDashboardsHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[ListDashboardsParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[ListDashboardsParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Superset客户端不可用")
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.requestTimeout)
+		defer cancel()
+
+		dashboards, err := client.GetDashboards(queryCtx, params.Arguments.TitleFilter)
+		if err != nil {
+			return common.CreateErrorResponse("%v", deadline.Explain(err))
+		}
+
+		dashboardInfo := map[string]any{
+			"count":      len(dashboards),
+			"dashboards": dashboards,
+		}
+
+		return common.CreateSuccessResponse(dashboardInfo)
+	}
+}
+
+// createListSavedQueriesHandler 创建已保存查询列表处理器
+func createListSavedQueriesHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[ListSavedQueriesParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[ListSavedQueriesParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Superset客户端不可用")
+		}
+
+		databaseID, err := strconv.Atoi(params.Arguments.DatabaseID)
+		if err != nil {
+			return common.CreateErrorResponse("无效的数据库ID格式: %v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.requestTimeout)
+		defer cancel()
+
+		queries, err := client.GetSavedQueries(queryCtx, databaseID)
+		if err != nil {
+			return common.CreateErrorResponse("%v", deadline.Explain(err))
+		}
+
+		queryInfo := map[string]any{
+			"count":         len(queries),
+			"saved_queries": queries,
+		}
+
+		return common.CreateSuccessResponse(queryInfo)
+	}
+}
+
+// createRunSavedQueryHandler 创建已保存查询执行处理器
+func createRunSavedQueryHandler(client *Client, opts *toolOptions) func(context.Context, *mcp.ServerSession, *mcp.CallToolParamsFor[RunSavedQueryParams]) (*mcp.CallToolResultFor[any], error) {
+	return func(ctx context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[RunSavedQueryParams]) (*mcp.CallToolResultFor[any], error) {
+		if client == nil {
+			return common.CreateErrorResponse("Superset客户端不可用")
+		}
+
+		savedQueryID, err := strconv.Atoi(params.Arguments.SavedQueryID)
+		if err != nil {
+			return common.CreateErrorResponse("无效的已保存查询ID格式: %v", err)
+		}
+
+		format := params.Arguments.Format
+		if err := validateFormat(format); err != nil {
+			return common.CreateErrorResponse("%v", err)
+		}
+
+		savedQuery, err := client.GetSavedQuery(ctx, savedQueryID)
+		if err != nil {
+			return common.CreateErrorResponse("%v", err)
+		}
+
+		queryCtx, cancel, deadline := common.WithUpstreamTimeout(ctx, opts.sqlTimeout)
+		defer cancel()
+
+		result, err := client.ExecuteSQLWithSchema(queryCtx, savedQuery.SQL, savedQuery.DatabaseID, savedQuery.Schema)
+		if err != nil {
+			return sqlErrorResponse(fmt.Sprintf("执行已保存查询 %q 失败", savedQuery.Label), err, deadline)
+		}
+
+		return renderSQLResult(result, format)
+	}
+}
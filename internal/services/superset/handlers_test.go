@@ -0,0 +1,179 @@
+package superset
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-server/internal/common"
+	"mcp-server/internal/core"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// connectInMemory 通过内存传输连接MCP服务器，返回客户端会话
+func connectInMemory(t *testing.T, server *mcp.Server) *mcp.ClientSession {
+	t.Helper()
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := server.Connect(ctx, serverTransport)
+	if err != nil {
+		t.Fatalf("server connect: %v", err)
+	}
+	t.Cleanup(func() { serverSession.Close() })
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "test"}, nil)
+	session, err := client.Connect(ctx, clientTransport)
+	if err != nil {
+		t.Fatalf("client connect: %v", err)
+	}
+	t.Cleanup(func() { session.Close() })
+	return session
+}
+
+func TestToolsWithNilClient(t *testing.T) {
+	ctx := context.Background()
+	server := mcp.NewServer(&mcp.Implementation{Name: "superset", Version: "test"}, nil)
+	if _, err := registerTools(server, nil, &toolOptions{sqlTimeout: time.Second, requestTimeout: time.Second, enableAdminAPI: true}); err != nil {
+		t.Fatalf("registerTools: %v", err)
+	}
+	session := connectInMemory(t, server)
+
+	tools, err := session.ListTools(ctx, nil)
+	if err != nil {
+		t.Fatalf("list tools: %v", err)
+	}
+	for _, tool := range tools.Tools {
+		t.Run(tool.Name, func(t *testing.T) {
+			result, err := session.CallTool(ctx, &mcp.CallToolParams{Name: tool.Name, Arguments: map[string]any{}})
+			if err != nil {
+				t.Fatalf("call tool: %v", err)
+			}
+			text := result.Content[0].(*mcp.TextContent).Text
+			if !result.IsError || !strings.Contains(text, "客户端不可用") {
+				t.Errorf("result = %q (IsError=%v), want service unavailable error", text, result.IsError)
+			}
+		})
+	}
+
+	service := &serviceImpl{server: server}
+	var unavailable *core.ServiceUnavailableError
+	if err := service.TestConnection(ctx); !errors.As(err, &unavailable) {
+		t.Errorf("TestConnection() = %v, want ServiceUnavailableError", err)
+	}
+}
+
+func TestHandlersExplainTightClientDeadline(t *testing.T) {
+	f := newFakeSuperset(t)
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		// 读完请求体，服务端才能感知客户端断开
+		_, _ = io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(2 * time.Second):
+		}
+		writeJSON(w, map[string]any{"result": []any{}})
+	}
+	f.handle(databaseEndpoint, slow)
+	f.handle(sqlExecuteEndpoint, slow)
+	client := f.newClient(t, ClientOptions{})
+	// 预先登录，使截止时间只作用于被测请求
+	if err := client.ensureLoggedIn(context.Background()); err != nil {
+		t.Fatalf("ensureLoggedIn: %v", err)
+	}
+	opts := &toolOptions{sqlTimeout: 5 * time.Second, requestTimeout: 5 * time.Second}
+
+	call := func(name string, run func(ctx context.Context) (*mcp.CallToolResultFor[any], error)) {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+			defer cancel()
+			result, err := run(ctx)
+			if err != nil {
+				t.Fatalf("handler: %v", err)
+			}
+			text := result.Content[0].(*mcp.TextContent).Text
+			if !result.IsError || !strings.Contains(text, "调用方") {
+				t.Errorf("result = %q (IsError=%v), want client deadline explanation", text, result.IsError)
+			}
+		})
+	}
+
+	call("list databases", func(ctx context.Context) (*mcp.CallToolResultFor[any], error) {
+		return createListDatabasesHandler(client, opts)(ctx, nil, &mcp.CallToolParamsFor[ListDatabasesParams]{
+			Arguments: ListDatabasesParams{Refresh: true},
+		})
+	})
+	call("execute sql", func(ctx context.Context) (*mcp.CallToolResultFor[any], error) {
+		return createExecuteSQLHandler(client, opts)(ctx, nil, &mcp.CallToolParamsFor[ExecuteSQLParams]{
+			Arguments: ExecuteSQLParams{SQL: "SELECT 1", DatabaseID: "1"},
+		})
+	})
+}
+
+func TestExecuteSQLResponseExceedsCap(t *testing.T) {
+	previous := common.MaxResponseBytes()
+	common.SetMaxResponseBytes(1024)
+	t.Cleanup(func() { common.SetMaxResponseBytes(previous) })
+
+	f := newFakeSuperset(t)
+	f.handle(sqlExecuteEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"query_id": 1,
+			"status":   "success",
+			"data":     []map[string]any{{"payload": strings.Repeat("x", 4096)}},
+		})
+	})
+	client := f.newClient(t, ClientOptions{})
+
+	result, err := createExecuteSQLHandler(client, &toolOptions{sqlTimeout: 5 * time.Second})(context.Background(), nil, &mcp.CallToolParamsFor[ExecuteSQLParams]{
+		Arguments: ExecuteSQLParams{SQL: "SELECT payload FROM big", DatabaseID: "1"},
+	})
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	if text := result.Content[0].(*mcp.TextContent).Text; !result.IsError || !strings.Contains(text, "响应过大") {
+		t.Errorf("result = %q (IsError=%v), want response too large error", text, result.IsError)
+	}
+}
+
+func TestExecuteSQLTimeoutDuringLogin(t *testing.T) {
+	f := newFakeSuperset(t)
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+	f.handle(loginEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<input id="csrf_token" name="csrf_token" type="hidden" value="tok">`))
+			return
+		}
+		// 读完请求体后服务器才能感知客户端断开
+		io.Copy(io.Discard, r.Body)
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	})
+	client := f.newClient(t, ClientOptions{})
+
+	result, err := createExecuteSQLHandler(client, &toolOptions{sqlTimeout: 100 * time.Millisecond})(context.Background(), nil, &mcp.CallToolParamsFor[ExecuteSQLParams]{
+		Arguments: ExecuteSQLParams{SQL: "SELECT 1", DatabaseID: "1"},
+	})
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	text := result.Content[0].(*mcp.TextContent).Text
+	if !result.IsError || !strings.Contains(text, "SQL查询超时") || !strings.Contains(text, "查询尚未提交") {
+		t.Errorf("result = %q (IsError=%v), want a timeout before the query was submitted", text, result.IsError)
+	}
+	// 查询未提交，不执行也不请求停止
+	if n := f.count(sqlExecuteEndpoint); n != 0 {
+		t.Errorf("execute requests = %d, want 0", n)
+	}
+	if n := f.count(queryStopEndpoint); n != 0 {
+		t.Errorf("stop requests = %d, want 0", n)
+	}
+}
@@ -0,0 +1,200 @@
+package superset
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// 分页相关常量
+const (
+	maxPageSize = 1000
+)
+
+// pageCursor 分页游标，编码全部续页所需信息，服务端无需保存状态
+type pageCursor struct {
+	SQL        string `json:"sql"`
+	DatabaseID int    `json:"db"`
+	Schema     string `json:"schema,omitempty"`
+	PageSize   int    `json:"size"`
+	Offset     int    `json:"offset"`
+}
+
+// PagedResult 分页查询结果
+type PagedResult struct {
+	QueryID    int      `json:"query_id"`
+	Columns    []string `json:"columns"`
+	Data       [][]any  `json:"data"`
+	Query      string   `json:"query"`
+	Status     string   `json:"status"`
+	RowCount   int      `json:"row_count"`
+	DurationMS int64    `json:"duration_ms"`
+	Page       int      `json:"page"`
+	PageSize   int      `json:"page_size"`
+	HasMore    bool     `json:"has_more"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+	Warning    string   `json:"warning,omitempty"`
+}
+
+// encode 编码为URL安全的base64字符串
+func (c pageCursor) encode() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("编码游标失败: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodePageCursor 解析分页游标
+func decodePageCursor(cursor string) (pageCursor, error) {
+	var c pageCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimSpace(cursor))
+	if err != nil {
+		return c, fmt.Errorf("无效的游标: %w", err)
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("无效的游标: %w", err)
+	}
+	if c.SQL == "" || c.PageSize <= 0 || c.Offset < 0 {
+		return c, fmt.Errorf("无效的游标: 缺少必要字段")
+	}
+	return c, nil
+}
+
+// buildPagedSQL 为SQL添加LIMIT/OFFSET，顶层未指定ORDER BY时按第一列排序以保证分页稳定
+// 先去除注释，避免末尾的 -- 注释吞掉追加的子句；多取一行用于判断是否还有下一页
+func buildPagedSQL(sql string, pageSize, offset int) (string, string, error) {
+	stripped, hasOrderBy, hasLimit := scanPagedSQL(sql)
+	base := strings.TrimRight(strings.TrimSpace(stripped), "; \t\r\n")
+	if hasLimit {
+		return "", "", fmt.Errorf("分页查询的SQL不能包含LIMIT/OFFSET，请去掉后使用page_size")
+	}
+
+	warning := ""
+	if !hasOrderBy {
+		base = "SELECT * FROM (" + base + ") AS mcp_page ORDER BY 1"
+		warning = "SQL未指定ORDER BY，已自动按第一列排序以保证分页结果稳定；建议显式指定唯一的排序列"
+	}
+
+	return fmt.Sprintf("%s LIMIT %d OFFSET %d", base, pageSize+1, offset), warning, nil
+}
+
+// scanPagedSQL 去除SQL中的注释，并检查顶层（不在括号、字符串或注释中）是否包含ORDER BY和LIMIT/OFFSET/FETCH
+// 子查询、窗口函数以及字符串中的ORDER BY和LIMIT不影响分页SQL的构建
+func scanPagedSQL(sql string) (stripped string, hasOrderBy, hasLimit bool) {
+	var (
+		builder  strings.Builder
+		previous string // 上一个顶层关键字
+		depth    int
+		dialect  = sqlDialect{name: "ansi"}
+	)
+
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+		switch {
+		case strings.HasPrefix(sql[i:], "--"):
+			i = skipUntil(sql, i+2, "\n")
+			builder.WriteByte('\n')
+		case strings.HasPrefix(sql[i:], "/*"):
+			i = skipUntil(sql, i+2, "*/")
+			builder.WriteByte(' ')
+		case ch == '\'' || ch == '"' || ch == '`':
+			end := dialect.skipQuoted(sql, i+1, ch)
+			builder.WriteString(sql[i:end])
+			i = end
+		case isWordChar(ch):
+			start := i
+			for i < len(sql) && isWordChar(sql[i]) {
+				i++
+			}
+			builder.WriteString(sql[start:i])
+			if depth > 0 {
+				continue
+			}
+			word := strings.ToUpper(sql[start:i])
+			switch {
+			case word == "BY" && previous == "ORDER":
+				hasOrderBy = true
+			case word == "LIMIT" || word == "OFFSET" || word == "FETCH":
+				hasLimit = true
+			}
+			previous = word
+		default:
+			switch ch {
+			case '(':
+				depth++
+			case ')':
+				depth = max(depth-1, 0)
+			}
+			builder.WriteByte(ch)
+			i++
+		}
+	}
+
+	return builder.String(), hasOrderBy, hasLimit
+}
+
+// executePage 执行一页查询
+func (c *Client) executePage(ctx context.Context, cursor pageCursor) (*PagedResult, error) {
+	if cursor.PageSize > maxPageSize {
+		return nil, fmt.Errorf("page_size不能超过%d", maxPageSize)
+	}
+
+	pagedSQL, warning, err := buildPagedSQL(cursor.SQL, cursor.PageSize, cursor.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := c.executeSQLInternal(ctx, pagedSQL, cursor.DatabaseID, cursor.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &PagedResult{
+		QueryID:    result.QueryID,
+		Columns:    result.Columns,
+		Data:       result.Data,
+		Query:      result.Query,
+		Status:     result.Status,
+		DurationMS: result.DurationMS,
+		Page:       cursor.Offset/cursor.PageSize + 1,
+		PageSize:   cursor.PageSize,
+		Warning:    warning,
+	}
+
+	if len(page.Data) > cursor.PageSize {
+		page.Data = page.Data[:cursor.PageSize]
+		page.HasMore = true
+
+		next := cursor
+		next.Offset += cursor.PageSize
+		if page.NextCursor, err = next.encode(); err != nil {
+			return nil, err
+		}
+	}
+	page.RowCount = len(page.Data)
+
+	return page, nil
+}
+
+// ExecuteSQLPage 执行分页SQL查询，返回第一页及续页游标
+func (c *Client) ExecuteSQLPage(ctx context.Context, sql string, databaseID int, schema string, pageSize int) (*PagedResult, error) {
+	return c.executePage(ctx, pageCursor{
+		SQL:        sql,
+		DatabaseID: databaseID,
+		Schema:     schema,
+		PageSize:   pageSize,
+	})
+}
+
+// NextPage 根据游标获取下一页
+func (c *Client) NextPage(ctx context.Context, cursor string) (*PagedResult, error) {
+	decoded, err := decodePageCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	return c.executePage(ctx, decoded)
+}
@@ -0,0 +1,159 @@
+package superset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBuildPagedSQL(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		sql     string
+		want    string
+		wrapped bool
+		wantErr bool
+	}{
+		{
+			name: "top-level order by",
+			sql:  "SELECT id FROM users ORDER BY id;",
+			want: "SELECT id FROM users ORDER BY id LIMIT 11 OFFSET 20",
+		},
+		{
+			name:    "no order by",
+			sql:     "SELECT id FROM users",
+			want:    "SELECT * FROM (SELECT id FROM users) AS mcp_page ORDER BY 1 LIMIT 11 OFFSET 20",
+			wrapped: true,
+		},
+		{
+			name:    "order by only in window function",
+			sql:     "SELECT id, ROW_NUMBER() OVER (ORDER BY created) AS n FROM users",
+			want:    "SELECT * FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY created) AS n FROM users) AS mcp_page ORDER BY 1 LIMIT 11 OFFSET 20",
+			wrapped: true,
+		},
+		{
+			name:    "order by only in subquery and string literal",
+			sql:     "SELECT * FROM (SELECT id FROM users ORDER BY id LIMIT 5) t WHERE note = 'order by x'",
+			want:    "SELECT * FROM (SELECT * FROM (SELECT id FROM users ORDER BY id LIMIT 5) t WHERE note = 'order by x') AS mcp_page ORDER BY 1 LIMIT 11 OFFSET 20",
+			wrapped: true,
+		},
+		{
+			name:    "trailing line comment",
+			sql:     "SELECT id FROM users -- all users",
+			want:    "SELECT * FROM (SELECT id FROM users) AS mcp_page ORDER BY 1 LIMIT 11 OFFSET 20",
+			wrapped: true,
+		},
+		{
+			name: "order by hidden after block comment",
+			sql:  "SELECT id FROM users /* note */ ORDER BY id -- trailing",
+			want: "SELECT id FROM users   ORDER BY id LIMIT 11 OFFSET 20",
+		},
+		{
+			name:    "top-level limit",
+			sql:     "SELECT id FROM users ORDER BY id LIMIT 10",
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, warning, err := buildPagedSQL(tc.sql, 10, 20)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("buildPagedSQL: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("sql = %q\nwant  %q", got, tc.want)
+			}
+			if (warning != "") != tc.wrapped {
+				t.Errorf("warning = %q, wrapped = %v", warning, tc.wrapped)
+			}
+		})
+	}
+}
+
+// pagePattern 提取分页SQL中的LIMIT和OFFSET
+var pagePattern = regexp.MustCompile(`LIMIT (\d+) OFFSET (\d+)$`)
+
+func TestPagingThroughMultiPageResult(t *testing.T) {
+	const totalRows = 25
+
+	fake := newFakeSuperset(t)
+	fake.handle(sqlExecuteEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			SQL string `json:"sql"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+
+		match := pagePattern.FindStringSubmatch(payload.SQL)
+		if match == nil {
+			http.Error(w, "missing LIMIT/OFFSET: "+payload.SQL, http.StatusBadRequest)
+			return
+		}
+		limit, _ := strconv.Atoi(match[1])
+		offset, _ := strconv.Atoi(match[2])
+
+		data := make([]map[string]any, 0, limit)
+		for id := offset + 1; id <= min(offset+limit, totalRows); id++ {
+			data = append(data, map[string]any{"id": id})
+		}
+		writeJSON(w, map[string]any{
+			"query_id": 1,
+			"status":   "success",
+			"data":     data,
+			"columns":  []map[string]any{{"name": "id", "column_name": "id"}},
+			"query":    map[string]any{"sql": payload.SQL},
+		})
+	})
+
+	client := fake.newClient(t, ClientOptions{})
+	ctx := context.Background()
+
+	page, err := client.ExecuteSQLPage(ctx, "SELECT id FROM users ORDER BY id", 1, "", 10)
+	if err != nil {
+		t.Fatalf("ExecuteSQLPage: %v", err)
+	}
+
+	var ids []string
+	pages := 0
+	for {
+		pages++
+		if page.Page != pages {
+			t.Errorf("page number = %d, want %d", page.Page, pages)
+		}
+		for _, row := range page.Data {
+			ids = append(ids, fmt.Sprint(row[0]))
+		}
+		if !page.HasMore {
+			break
+		}
+		if page.NextCursor == "" {
+			t.Fatal("has_more without next_cursor")
+		}
+		if page, err = client.NextPage(ctx, page.NextCursor); err != nil {
+			t.Fatalf("NextPage: %v", err)
+		}
+	}
+
+	if pages != 3 {
+		t.Errorf("pages = %d, want 3", pages)
+	}
+	if page.RowCount != 5 {
+		t.Errorf("last page rows = %d, want 5", page.RowCount)
+	}
+	want := make([]string, 0, totalRows)
+	for id := 1; id <= totalRows; id++ {
+		want = append(want, strconv.Itoa(id))
+	}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Errorf("ids = %v, want 1..%d without gaps or duplicates", ids, totalRows)
+	}
+}
@@ -0,0 +1,105 @@
+package superset
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// risonPagePattern 从rison查询参数中解析页码
+var risonPagePattern = regexp.MustCompile(`page:(\d+)`)
+
+// serveRoles 注册分页的角色列表及每个角色的权限接口，perms返回角色ID对应的权限
+func serveRoles(f *fakeSuperset, total int, perms func(id int) []map[string]any) {
+	f.handle(rolesEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		page := 0
+		if m := risonPagePattern.FindStringSubmatch(r.URL.Query().Get("q")); m != nil {
+			page, _ = strconv.Atoi(m[1])
+		}
+		roles := []map[string]any{}
+		for id := page*maxRolesPageSize + 1; id <= min(total, (page+1)*maxRolesPageSize); id++ {
+			roles = append(roles, map[string]any{"id": id, "name": "role" + strconv.Itoa(id)})
+		}
+		writeJSON(w, map[string]any{"count": total, "result": roles})
+	})
+	for id := 1; id <= total; id++ {
+		f.handle(rolesEndpoint+strconv.Itoa(id)+"/permissions/", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, map[string]any{"result": perms(id)})
+		})
+	}
+	f.handle(databaseEndpoint+"1", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"result": map[string]any{"database_name": "examples"}})
+	})
+}
+
+func TestGetDatabasePermissions(t *testing.T) {
+	f := newFakeSuperset(t)
+	serveRoles(f, 150, func(id int) []map[string]any {
+		switch id {
+		case 7:
+			return []map[string]any{{"permission_name": permDatabaseAccess, "view_menu_name": "[examples].(id:1)"}}
+		case 120:
+			return []map[string]any{{"permission_name": permAllDatabaseAccess, "view_menu_name": "all_database_access"}}
+		case 130:
+			return []map[string]any{{"permission_name": permDatabaseAccess, "view_menu_name": "[other].(id:2)"}}
+		default:
+			return nil
+		}
+	})
+	client := f.newClient(t, ClientOptions{})
+
+	permissions, err := client.GetDatabasePermissions(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetDatabasePermissions: %v", err)
+	}
+	want := []RoleAccess{
+		{ID: 7, Name: "role7", Permission: permDatabaseAccess},
+		{ID: 120, Name: "role120", Permission: permAllDatabaseAccess},
+	}
+	if !slices.Equal(permissions.Roles, want) {
+		t.Errorf("roles = %+v, want %+v", permissions.Roles, want)
+	}
+	if !permissions.Visible || permissions.Truncated {
+		t.Errorf("visible = %v, truncated = %v, want visible and not truncated", permissions.Visible, permissions.Truncated)
+	}
+	if got := f.count(rolesEndpoint); got != 2 {
+		t.Errorf("role list requests = %d, want 2 pages", got)
+	}
+}
+
+func TestGetDatabasePermissionsTruncated(t *testing.T) {
+	f := newFakeSuperset(t)
+	serveRoles(f, maxRoles+50, func(int) []map[string]any { return nil })
+	client := f.newClient(t, ClientOptions{})
+
+	permissions, err := client.GetDatabasePermissions(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetDatabasePermissions: %v", err)
+	}
+	if !permissions.Truncated || permissions.Note == "" {
+		t.Errorf("truncated = %v, note = %q, want truncation reported", permissions.Truncated, permissions.Note)
+	}
+	if got := f.count(rolesEndpoint + strconv.Itoa(maxRoles+1) + "/permissions/"); got != 0 {
+		t.Errorf("role beyond limit was queried %d times", got)
+	}
+}
+
+func TestGetDatabasePermissionsForbidden(t *testing.T) {
+	f := newFakeSuperset(t)
+	serveRoles(f, 1, nil)
+	f.handle(rolesEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Forbidden"}`, http.StatusForbidden)
+	})
+	client := f.newClient(t, ClientOptions{})
+
+	permissions, err := client.GetDatabasePermissions(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetDatabasePermissions: %v", err)
+	}
+	if permissions.Visible || permissions.Note == "" {
+		t.Errorf("visible = %v, note = %q, want hidden with note", permissions.Visible, permissions.Note)
+	}
+}
@@ -0,0 +1,159 @@
+package superset
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestGetResultsCSVURL(t *testing.T) {
+	f := newFakeSuperset(t)
+	f.handle(queryEndpoint+"42", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"result": map[string]any{
+			"id":        42,
+			"client_id": "abc 1",
+			"status":    "success",
+			"rows":      1000,
+			"sql":       "SELECT * FROM logs",
+		}})
+	})
+	client := f.newClient(t, ClientOptions{})
+
+	export, err := client.GetResultsCSVURL(context.Background(), 42)
+	if err != nil {
+		t.Fatalf("GetResultsCSVURL: %v", err)
+	}
+	if want := f.URL + csvExportEndpoint + "abc%201/"; export.URL != want {
+		t.Errorf("URL = %q, want %q", export.URL, want)
+	}
+	if export.QueryID != 42 || export.ClientID != "abc 1" || export.Status != "success" || export.Rows != 1000 || export.SQL != "SELECT * FROM logs" {
+		t.Errorf("export = %+v, want metadata from the query record", export)
+	}
+	if !export.AuthRequired || export.AuthNote == "" {
+		t.Errorf("export = %+v, want auth requirement noted", export)
+	}
+}
+
+func TestGetResultsCSVURLWithoutClientID(t *testing.T) {
+	f := newFakeSuperset(t)
+	f.handle(queryEndpoint+"7", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"result": map[string]any{"id": 7, "status": "success"}})
+	})
+	client := f.newClient(t, ClientOptions{})
+
+	if _, err := client.GetResultsCSVURL(context.Background(), 7); err == nil {
+		t.Error("GetResultsCSVURL succeeded without client_id")
+	}
+}
+
+func TestMyQueriesHistory(t *testing.T) {
+	f := newFakeSuperset(t)
+	f.handle(meEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"result": map[string]any{"id": 7, "username": "admin"}})
+	})
+	var risonQuery string
+	f.handle(queryEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		risonQuery = r.URL.Query().Get("q")
+		writeJSON(w, map[string]any{"result": []map[string]any{
+			{
+				"id": 2, "sql": "SELECT 2", "status": "success", "rows": 10, "schema": "public",
+				"start_time": 1700000000000.0, "end_time": "1700000001500",
+				"database": map[string]any{"database_name": "examples"},
+			},
+			{"id": 1, "sql": "SELECT 1", "status": "failed"},
+		}})
+	})
+	client := f.newClient(t, ClientOptions{})
+
+	result, err := createMyQueriesHandler(client, &toolOptions{requestTimeout: 5 * time.Second})(context.Background(), nil, &mcp.CallToolParamsFor[MyQueriesParams]{
+		Arguments: MyQueriesParams{Limit: 5},
+	})
+	if err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	text := result.Content[0].(*mcp.TextContent).Text
+	if result.IsError {
+		t.Fatalf("handler error: %s", text)
+	}
+
+	// 按当前用户过滤，按开始时间倒序，使用指定的条数
+	for _, want := range []string{"col:user", "value:7", "order_column:start_time", "order_direction:desc", "page_size:5"} {
+		if !strings.Contains(risonQuery, want) {
+			t.Errorf("rison query %q missing %q", risonQuery, want)
+		}
+	}
+
+	var history struct {
+		Count   int                `json:"count"`
+		Queries []queryHistoryInfo `json:"queries"`
+	}
+	if err := json.Unmarshal([]byte(text), &history); err != nil {
+		t.Fatalf("unmarshal %s: %v", text, err)
+	}
+	if history.Count != 2 || len(history.Queries) != 2 {
+		t.Fatalf("history = %+v, want 2 queries", history)
+	}
+	want := queryHistoryInfo{
+		ID: 2, Statement: "SELECT 2", Status: "success", Rows: 10, Database: "examples", Schema: "public",
+		StartTime: "2023-11-14T22:13:20Z", DurationMS: 1500,
+	}
+	if history.Queries[0] != want {
+		t.Errorf("queries[0] = %+v, want %+v", history.Queries[0], want)
+	}
+	if got := history.Queries[1]; got.Status != "failed" || got.StartTime != "" || got.DurationMS != 0 {
+		t.Errorf("queries[1] = %+v, want failed query without timing", got)
+	}
+}
+
+func TestGetQueryError(t *testing.T) {
+	f := newFakeSuperset(t)
+	f.handle(queryEndpoint+"13", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"result": map[string]any{
+			"id":            13,
+			"status":        "failed",
+			"sql":           "SELECT * FROM missing_table",
+			"executed_sql":  "SELECT * FROM missing_table LIMIT 1001",
+			"schema":        "public",
+			"error_message": "relation \"missing_table\" does not exist",
+			"tracking_url":  "https://trino.example.com/ui/query.html?q1",
+			"database":      map[string]any{"database_name": "warehouse"},
+		}})
+	})
+	f.handle(queryEndpoint+"14", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"result": map[string]any{"id": 14, "status": "success", "sql": "SELECT 1"}})
+	})
+	client := f.newClient(t, ClientOptions{})
+
+	queryError, err := client.GetQueryError(context.Background(), 13)
+	if err != nil {
+		t.Fatalf("GetQueryError: %v", err)
+	}
+	want := QueryError{
+		QueryID:      13,
+		Status:       "failed",
+		Failed:       true,
+		ErrorMessage: "relation \"missing_table\" does not exist",
+		TrackingURL:  "https://trino.example.com/ui/query.html?q1",
+		SQL:          "SELECT * FROM missing_table",
+		ExecutedSQL:  "SELECT * FROM missing_table LIMIT 1001",
+		Database:     "warehouse",
+		Schema:       "public",
+	}
+	if *queryError != want {
+		t.Errorf("GetQueryError = %+v, want %+v", *queryError, want)
+	}
+
+	// 成功的查询不标记为失败
+	queryError, err = client.GetQueryError(context.Background(), 14)
+	if err != nil {
+		t.Fatalf("GetQueryError(14): %v", err)
+	}
+	if queryError.Failed || queryError.ErrorMessage != "" {
+		t.Errorf("successful query = %+v, want not failed", *queryError)
+	}
+}
@@ -0,0 +1,169 @@
+package superset
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 只读模式下允许作为语句开头的关键字
+var readOnlyLeadingKeywords = map[string]bool{
+	"SELECT":  true,
+	"WITH":    true,
+	"EXPLAIN": true,
+}
+
+// 只读模式下语句中不允许出现的关键字
+// 用于拦截 WITH ... DELETE、EXPLAIN ANALYZE INSERT、SELECT ... INTO 等以只读关键字开头的写操作
+var writeKeywords = map[string]bool{
+	"INSERT":   true,
+	"UPDATE":   true,
+	"DELETE":   true,
+	"MERGE":    true,
+	"UPSERT":   true,
+	"INTO":     true,
+	"DROP":     true,
+	"CREATE":   true,
+	"ALTER":    true,
+	"TRUNCATE": true,
+	"RENAME":   true,
+	"GRANT":    true,
+	"REVOKE":   true,
+	"COPY":     true,
+	"CALL":     true,
+	"EXEC":     true,
+	"EXECUTE":  true,
+	"ATTACH":   true,
+	"DETACH":   true,
+	"VACUUM":   true,
+	"LOCK":     true,
+	"SET":      true,
+}
+
+// errEmptySQL SQL中没有任何语句
+var errEmptySQL = errors.New("SQL语句为空")
+
+// sqlDialect 词法差异较大的SQL方言
+// 不同数据库对注释和转义的处理不一致，同一段SQL需要在每种方言下都通过检查，
+// 避免利用方言差异把写语句藏进其他方言眼中的注释或字符串里
+type sqlDialect struct {
+	name string
+	// backslashEscapes 字符串中的反斜杠转义下一个字符（MySQL）
+	backslashEscapes bool
+	// mysqlComments #注释、--后需跟空白才是注释、/*! */中的内容会被执行（MySQL）
+	mysqlComments bool
+}
+
+var sqlDialects = []sqlDialect{
+	{name: "ansi"},
+	{name: "mysql", backslashEscapes: true, mysqlComments: true},
+}
+
+// checkReadOnlySQL 检查SQL是否只包含只读语句
+// 忽略注释和字符串/标识符引号中的内容，按分号拆分多条语句逐条检查
+func checkReadOnlySQL(sql string) error {
+	for _, dialect := range sqlDialects {
+		statements := dialect.tokenize(sql)
+		if len(statements) == 0 {
+			return errEmptySQL
+		}
+
+		for _, words := range statements {
+			if !readOnlyLeadingKeywords[words[0]] {
+				return fmt.Errorf("只读模式仅允许SELECT、WITH和EXPLAIN语句，拒绝执行 %s 语句", words[0])
+			}
+			for _, word := range words[1:] {
+				if writeKeywords[word] {
+					return fmt.Errorf("只读模式拒绝执行包含 %s 的语句", word)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// tokenize 将SQL拆分为语句，每条语句为大写的关键字/标识符序列
+// 跳过注释、字符串以及带引号的标识符，空语句被忽略
+func (d sqlDialect) tokenize(sql string) [][]string {
+	var (
+		statements [][]string
+		words      []string
+	)
+
+	flush := func() {
+		if len(words) > 0 {
+			statements = append(statements, words)
+			words = nil
+		}
+	}
+
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+		switch {
+		case ch == '-' && strings.HasPrefix(sql[i:], "--") && d.isDashComment(sql, i+2):
+			i = skipUntil(sql, i+2, "\n")
+		case ch == '#' && d.mysqlComments:
+			i = skipUntil(sql, i+1, "\n")
+		case ch == '/' && strings.HasPrefix(sql[i:], "/*!") && d.mysqlComments:
+			// MySQL可执行注释，其中的内容按普通SQL检查
+			i += 3
+		case ch == '/' && strings.HasPrefix(sql[i:], "/*"):
+			i = skipUntil(sql, i+2, "*/")
+		case ch == '\'' || ch == '"' || ch == '`':
+			i = d.skipQuoted(sql, i+1, ch)
+		case ch == ';':
+			flush()
+			i++
+		case isWordChar(ch):
+			start := i
+			for i < len(sql) && isWordChar(sql[i]) {
+				i++
+			}
+			words = append(words, strings.ToUpper(sql[start:i]))
+		default:
+			i++
+		}
+	}
+	flush()
+
+	return statements
+}
+
+// isDashComment 判断 -- 是否开始注释，MySQL要求其后为空白或行尾
+func (d sqlDialect) isDashComment(sql string, after int) bool {
+	if !d.mysqlComments || after >= len(sql) {
+		return true
+	}
+	return sql[after] <= ' '
+}
+
+// skipUntil 返回terminator之后的位置，未找到时返回末尾
+func skipUntil(sql string, from int, terminator string) int {
+	idx := strings.Index(sql[from:], terminator)
+	if idx < 0 {
+		return len(sql)
+	}
+	return from + idx + len(terminator)
+}
+
+// skipQuoted 跳过引号内容，连续两个引号视为转义
+func (d sqlDialect) skipQuoted(sql string, from int, quote byte) int {
+	for i := from; i < len(sql); i++ {
+		switch {
+		case sql[i] == '\\' && d.backslashEscapes:
+			i++
+		case sql[i] != quote:
+		case i+1 < len(sql) && sql[i+1] == quote:
+			i++
+		default:
+			return i + 1
+		}
+	}
+	return len(sql)
+}
+
+// isWordChar 判断是否为关键字或标识符字符
+func isWordChar(ch byte) bool {
+	return ch == '_' || ch == '$' || ch >= 0x80 ||
+		(ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') || (ch >= '0' && ch <= '9')
+}
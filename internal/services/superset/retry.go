@@ -0,0 +1,102 @@
+package superset
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// 重试默认配置
+const (
+	defaultRetryAttempts = 3
+	defaultRetryBackoff  = 500 * time.Millisecond
+)
+
+// do 发送请求，遇到连接错误或5xx响应时按指数退避重试，4xx不重试
+// 请求体需可重放（通过http.NewRequest创建的bytes.Reader请求体会自动设置GetBody）
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	attempts := c.retryAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	backoff := c.retryBackoff
+
+	for attempt := 1; ; attempt++ {
+		resp, err := c.httpClient.Do(req)
+		if !shouldRetry(resp, err) || attempt >= attempts {
+			if attempt > 1 {
+				log.Printf("Superset请求 %s %s 共尝试 %d 次", req.Method, req.URL.Path, attempt)
+			}
+			return resp, err
+		}
+
+		reason := describeRetryReason(resp, err)
+		if resp != nil {
+			// 丢弃响应体以便复用连接
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if !waitBackoff(req.Context(), backoff) {
+			return nil, fmt.Errorf("重试被取消（已尝试%d次，最后一次: %s）: %w", attempt, reason, req.Context().Err())
+		}
+		log.Printf("Superset请求 %s %s 第%d/%d次失败: %s，%v后重试", req.Method, req.URL.Path, attempt, attempts, reason, backoff)
+
+		if req, err = rewindRequest(req); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
+
+// shouldRetry 判断是否需要重试：连接错误或5xx
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// describeRetryReason 描述重试原因
+func describeRetryReason(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("状态码 %d", resp.StatusCode)
+}
+
+// waitBackoff 等待退避时间，上下文剩余期限不足或已取消时返回false
+func waitBackoff(ctx context.Context, backoff time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < backoff {
+		return false
+	}
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// rewindRequest 重置请求体以便重新发送
+func rewindRequest(req *http.Request) (*http.Request, error) {
+	if req.Body == nil || req.GetBody == nil {
+		return req, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("重置请求体失败: %w", err)
+	}
+
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	return retry, nil
+}
@@ -0,0 +1,74 @@
+package superset
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Superset列表API使用rison编码的q参数，这里只实现查询所需的最小子集
+
+// 列表API翻页参数
+const (
+	// listPageSize 每页数量，Superset默认最大为100
+	listPageSize = 100
+	// maxListPages 最多翻页数，避免对象过多时无限翻页
+	maxListPages = 50
+)
+
+// risonFilter rison过滤条件
+type risonFilter struct {
+	Col   string
+	Opr   string
+	Value string // 已编码的rison值
+}
+
+// risonQuery rison列表查询参数
+type risonQuery struct {
+	Filters        []risonFilter
+	OrderColumn    string
+	OrderDirection string
+	Page           int
+	PageSize       int
+}
+
+// risonString 编码rison字符串值
+func risonString(value string) string {
+	escaped := strings.NewReplacer("!", "!!", "'", "!'").Replace(value)
+	return "'" + escaped + "'"
+}
+
+// risonInt 编码rison整数值
+func risonInt(value int) string {
+	return strconv.Itoa(value)
+}
+
+// encode 编码为rison对象
+func (q risonQuery) encode() string {
+	parts := make([]string, 0, 5)
+
+	if len(q.Filters) > 0 {
+		filters := make([]string, 0, len(q.Filters))
+		for _, f := range q.Filters {
+			filters = append(filters, "(col:"+f.Col+",opr:"+f.Opr+",value:"+f.Value+")")
+		}
+		parts = append(parts, "filters:!("+strings.Join(filters, ",")+")")
+	}
+	if q.OrderColumn != "" {
+		parts = append(parts, "order_column:"+q.OrderColumn)
+	}
+	if q.OrderDirection != "" {
+		parts = append(parts, "order_direction:"+q.OrderDirection)
+	}
+	parts = append(parts, "page:"+strconv.Itoa(q.Page))
+	if q.PageSize > 0 {
+		parts = append(parts, "page_size:"+strconv.Itoa(q.PageSize))
+	}
+
+	return "(" + strings.Join(parts, ",") + ")"
+}
+
+// endpoint 构建带q参数的列表API地址
+func (q risonQuery) endpoint(base string) string {
+	return base + "?q=" + url.QueryEscape(q.encode())
+}
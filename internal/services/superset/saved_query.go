@@ -0,0 +1,98 @@
+package superset
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+)
+
+// savedQueryEndpoint 已保存查询端点
+const savedQueryEndpoint = "/api/v1/saved_query/"
+
+// SavedQuery 已保存的查询
+type SavedQuery struct {
+	ID          int    `json:"id"`
+	Label       string `json:"label"`
+	Description string `json:"description,omitempty"`
+	SQL         string `json:"sql"`
+	Schema      string `json:"schema"`
+	DatabaseID  int    `json:"database_id"`
+	Database    string `json:"database"`
+}
+
+// savedQueryResult 已保存查询的API响应项，列表和详情接口结构相同
+type savedQueryResult struct {
+	ID          int     `json:"id"`
+	Label       string  `json:"label"`
+	Description *string `json:"description"`
+	SQL         string  `json:"sql"`
+	Schema      *string `json:"schema"`
+	Database    struct {
+		ID           int    `json:"id"`
+		DatabaseName string `json:"database_name"`
+	} `json:"database"`
+}
+
+// toSavedQuery 转换为SavedQuery，null字段转为空字符串
+func (r savedQueryResult) toSavedQuery() SavedQuery {
+	query := SavedQuery{
+		ID:         r.ID,
+		Label:      r.Label,
+		SQL:        r.SQL,
+		DatabaseID: r.Database.ID,
+		Database:   r.Database.DatabaseName,
+	}
+	if r.Description != nil {
+		query.Description = *r.Description
+	}
+	if r.Schema != nil {
+		query.Schema = *r.Schema
+	}
+	return query
+}
+
+// GetSavedQueries 获取指定数据库的已保存查询
+func (c *Client) GetSavedQueries(ctx context.Context, databaseID int) ([]SavedQuery, error) {
+	query := risonQuery{
+		Filters:        []risonFilter{{Col: "database", Opr: "rel_o_m", Value: risonInt(databaseID)}},
+		OrderColumn:    "label",
+		OrderDirection: "asc",
+		PageSize:       listPageSize,
+	}
+
+	queries := make([]SavedQuery, 0)
+	for page := 0; page < maxListPages; page++ {
+		query.Page = page
+
+		var response struct {
+			Count  int                `json:"count"`
+			Result []savedQueryResult `json:"result"`
+		}
+		if err := c.getJSON(ctx, query.endpoint(savedQueryEndpoint), &response); err != nil {
+			return nil, fmt.Errorf("获取已保存查询失败: %w", err)
+		}
+
+		for _, item := range response.Result {
+			queries = append(queries, item.toSavedQuery())
+		}
+
+		if len(response.Result) == 0 || len(queries) >= response.Count {
+			break
+		}
+	}
+
+	return queries, nil
+}
+
+// GetSavedQuery 获取单个已保存查询
+func (c *Client) GetSavedQuery(ctx context.Context, savedQueryID int) (*SavedQuery, error) {
+	var response struct {
+		Result savedQueryResult `json:"result"`
+	}
+	if err := c.getJSON(ctx, savedQueryEndpoint+strconv.Itoa(savedQueryID), &response); err != nil {
+		return nil, fmt.Errorf("获取已保存查询失败: %w", err)
+	}
+
+	query := response.Result.toSavedQuery()
+	return &query, nil
+}
@@ -3,9 +3,11 @@ package superset
 import (
 	"context"
 	"fmt"
+	"log"
 	"time"
 
 	"mcp-server/config"
+	"mcp-server/internal/common"
 	"mcp-server/internal/core"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -16,6 +18,10 @@ type serviceImpl struct {
 	client   *Client
 	server   *mcp.Server
 	endpoint string
+	port     string // 独立监听端口，为空表示使用共享端口
+
+	toolNames     []string // 已注册的工具名称
+	readinessMode core.ReadinessMode
 }
 
 // CreateService 创建Superset服务实例（工厂函数）
@@ -25,8 +31,29 @@ func CreateService(serviceConfig core.ServiceConfig, timeout time.Duration) (cor
 		return nil, fmt.Errorf("配置类型错误: 期望SupersetConfig，得到%T", serviceConfig)
 	}
 
+	// 服务级超时优先于全局超时
+	if supersetConfig.Timeout > 0 {
+		timeout = supersetConfig.Timeout
+	}
+
 	// 创建客户端
-	client, err := NewClient(supersetConfig.URL, supersetConfig.User, supersetConfig.Pass, timeout)
+	client, err := NewClient(supersetConfig.URL, supersetConfig.User, supersetConfig.Pass, timeout, ClientOptions{
+		MaxConnsPerHost:  supersetConfig.MaxConnsPerHost,
+		RetryAttempts:    supersetConfig.RetryAttempts,
+		RetryBackoff:     supersetConfig.RetryBackoff,
+		ReadOnly:         supersetConfig.ReadOnly,
+		ColumnNameSource: supersetConfig.ColumnNameSource,
+		DatabaseCacheTTL: supersetConfig.DatabaseCacheTTL,
+		CSRFTokenTTL:     supersetConfig.CSRFTokenTTL,
+		HealthPath:       supersetConfig.HealthPath,
+		CoalesceQueries:  supersetConfig.CoalesceQueries,
+		TLS: common.TLSOptions{
+			CAFile:             supersetConfig.CAFile,
+			ClientCertFile:     supersetConfig.ClientCertFile,
+			ClientKeyFile:      supersetConfig.ClientKeyFile,
+			InsecureSkipVerify: supersetConfig.InsecureSkipVerify,
+		},
+	})
 	if err != nil {
 		return nil, core.NewServiceCreationError(core.ServiceTypeSuperset, err)
 	}
@@ -41,10 +68,39 @@ func CreateService(serviceConfig core.ServiceConfig, timeout time.Duration) (cor
 		client:   client,
 		server:   server,
 		endpoint: supersetConfig.GetEndpoint(),
+		port:     supersetConfig.GetPort(),
+
+		readinessMode: supersetConfig.GetReadinessMode(),
+	}
+
+	// SQL执行超时：未配置时使用服务超时，且不能超过HTTP客户端的超时，否则超时由HTTP客户端触发而无法停止远端查询
+	sqlTimeout := supersetConfig.SQLTimeout
+	if timeout > 0 && (sqlTimeout <= 0 || sqlTimeout > timeout) {
+		if sqlTimeout > timeout {
+			log.Printf("Superset sql_timeout %v 超过服务超时 %v，按 %v 生效", sqlTimeout, timeout, timeout)
+		}
+		sqlTimeout = timeout
+	}
+	if sqlTimeout <= 0 {
+		sqlTimeout = defaultSQLTimeout
+	}
+
+	// 列表和元数据请求的超时与服务超时一致
+	requestTimeout := timeout
+	if requestTimeout <= 0 {
+		requestTimeout = defaultSQLTimeout
 	}
 
 	// 注册工具
-	registerTools(server, client)
+	toolNames, err := registerTools(server, client, &toolOptions{
+		sqlTimeout:     sqlTimeout,
+		requestTimeout: requestTimeout,
+		enableAdminAPI: supersetConfig.EnableAdminAPI,
+	})
+	if err != nil {
+		return nil, core.NewServiceCreationError(core.ServiceTypeSuperset, err)
+	}
+	service.toolNames = toolNames
 
 	return service, nil
 }
@@ -57,15 +113,44 @@ func (s *serviceImpl) GetServer() *mcp.Server {
 // TestConnection 实现Service接口
 func (s *serviceImpl) TestConnection(ctx context.Context) error {
 	if s.client == nil {
-		return fmt.Errorf("客户端未初始化")
+		return core.NewServiceUnavailableError(core.ServiceTypeSuperset)
 	}
 	return s.client.TestConnection(ctx)
 }
 
-// Close 实现Service接口
+// ReadinessMode 实现core.FunctionalChecker接口
+func (s *serviceImpl) ReadinessMode() core.ReadinessMode {
+	return s.readinessMode
+}
+
+// CheckFunctionality 实现core.FunctionalChecker接口，在连接测试基础上登录并获取数据库列表
+// 功能验证需要真正访问Superset，不使用数据库列表缓存
+func (s *serviceImpl) CheckFunctionality(ctx context.Context) error {
+	if err := s.TestConnection(ctx); err != nil {
+		return err
+	}
+	_, err := s.client.RefreshDatabases(ctx)
+	return err
+}
+
+// Close 实现Service接口，最多等待defaultDrainTimeout让进行中的SQL查询完成
 func (s *serviceImpl) Close() error {
-	// Superset客户端无需特殊清理
-	return nil
+	ctx, cancel := context.WithTimeout(context.Background(), defaultDrainTimeout)
+	defer cancel()
+	return s.CloseContext(ctx)
+}
+
+// CloseContext 实现core.ContextCloser接口，在ctx截止前等待进行中的SQL查询完成
+func (s *serviceImpl) CloseContext(ctx context.Context) error {
+	if s.client == nil {
+		return nil
+	}
+	return s.client.Close(ctx)
+}
+
+// ToolNames 实现core.ToolLister接口
+func (s *serviceImpl) ToolNames() []string {
+	return s.toolNames
 }
 
 // GetType 实现Service接口
@@ -78,29 +163,118 @@ func (s *serviceImpl) GetEndpoint() string {
 	return s.endpoint
 }
 
+// GetPort 实现core.PortProvider接口
+func (s *serviceImpl) GetPort() string {
+	return s.port
+}
+
 // registerTools 注册所有Superset工具
-func registerTools(server *mcp.Server, client *Client) {
+func registerTools(server *mcp.Server, client *Client, opts *toolOptions) ([]string, error) {
+	registrar := core.NewToolRegistrar(server)
+
 	// 注册数据库列表工具
-	mcp.AddTool(server, &mcp.Tool{
+	core.AddTool(registrar, &mcp.Tool{
 		Name:        "superset_list_databases",
 		Description: "获取所有可用的数据库列表",
-	}, createListDatabasesHandler(client))
+	}, createListDatabasesHandler(client, opts))
 
 	// 注册SQL执行工具
-	mcp.AddTool(server, &mcp.Tool{
+	core.AddTool(registrar, &mcp.Tool{
 		Name:        "superset_execute_sql",
 		Description: "在指定数据库中执行SQL查询",
-	}, createExecuteSQLHandler(client))
+	}, createExecuteSQLHandler(client, opts))
 
 	// 注册带schema的SQL执行工具
-	mcp.AddTool(server, &mcp.Tool{
+	core.AddTool(registrar, &mcp.Tool{
 		Name:        "superset_execute_sql_with_schema",
 		Description: "在指定数据库和schema中执行SQL查询",
-	}, createExecuteSQLWithSchemaHandler(client))
+	}, createExecuteSQLWithSchemaHandler(client, opts))
+
+	// 注册SQL校验工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "superset_validate_sql",
+		Description: "校验SQL语法但不执行，返回带行列号的错误列表，SQL有效时错误列表为空；需要Superset为该数据库引擎配置SQL校验器",
+	}, createValidateSQLHandler(client, opts))
 
 	// 注册状态检查工具
-	mcp.AddTool(server, &mcp.Tool{
+	core.AddTool(registrar, &mcp.Tool{
 		Name:        "superset_status",
 		Description: "检查Superset服务状态和连接",
 	}, createStatusHandler(client))
+
+	// 注册分页续查工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "superset_next_page",
+		Description: "使用superset_execute_sql返回的next_cursor获取下一页结果",
+	}, createNextPageHandler(client, opts))
+
+	// 注册查询历史工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "superset_my_queries",
+		Description: "获取当前用户最近的SQL Lab查询历史（语句、状态、行数和耗时）",
+	}, createMyQueriesHandler(client, opts))
+
+	// 注册CSV导出工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "superset_export_csv",
+		Description: "获取SQL Lab查询结果的CSV下载链接（适用于大结果集）",
+	}, createExportCSVHandler(client, opts))
+
+	// 注册已保存查询列表工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "superset_list_saved_queries",
+		Description: "获取指定数据库的已保存查询（名称、SQL和schema），优先复用分析师审核过的查询而不是从头编写SQL",
+	}, createListSavedQueriesHandler(client, opts))
+
+	// 注册已保存查询执行工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "superset_run_saved_query",
+		Description: "按ID执行已保存查询的SQL，使用查询保存时的数据库和schema",
+	}, createRunSavedQueryHandler(client, opts))
+
+	// 注册看板列表工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "superset_list_dashboards",
+		Description: "获取看板列表（ID、标题、slug和发布状态），可按标题过滤",
+	}, createListDashboardsHandler(client, opts))
+
+	// 注册图表列表工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "superset_list_charts",
+		Description: "获取图表列表（ID、名称、数据源和可视化类型），可按标题过滤，用于查找superset_get_chart_data所需的chart_id",
+	}, createListChartsHandler(client, opts))
+
+	// 注册图表数据工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "superset_get_chart_data",
+		Description: "使用图表保存的查询获取图表数据（列名和数据行），无需编写SQL即可获取已审核的指标",
+	}, createGetChartDataHandler(client, opts))
+
+	// 注册查询失败详情工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "superset_query_error",
+		Description: "获取SQL Lab查询记录中保存的错误信息和tracking_url，用于排查异步查询失败的原因",
+	}, createQueryErrorHandler(client, opts))
+
+	// 注册数据库权限查询工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "superset_database_permissions",
+		Description: "获取可以访问指定数据库的角色列表，用于排查查询被拒绝的原因",
+	}, createDatabasePermissionsHandler(client, opts))
+
+	// 注册版本信息工具
+	core.AddTool(registrar, &mcp.Tool{
+		Name:        "superset_version",
+		Description: "获取Superset服务端版本号和已启用的功能开关",
+	}, createVersionHandler(client, opts))
+
+	// 注册登出工具（需显式开启管理接口）
+	if opts.enableAdminAPI {
+		core.AddTool(registrar, &mcp.Tool{
+			Name:        "superset_logout",
+			Description: "管理工具：登出Superset并清除本地会话（cookie、CSRF令牌缓存），下次请求时重新登录，用于切换凭据或排查会话问题",
+		}, createLogoutHandler(client))
+	}
+
+	return registrar.Names(), registrar.Err()
 }
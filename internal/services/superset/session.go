@@ -0,0 +1,200 @@
+package superset
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+
+	"mcp-server/internal/common"
+)
+
+// logoutEndpoint Superset登出端点
+const logoutEndpoint = "/logout/"
+
+// resettableJar 可整体重置的cookie jar
+// http.Client并发读取Jar字段，不能直接替换，因此在内部替换底层jar
+type resettableJar struct {
+	mu  sync.RWMutex
+	jar *cookiejar.Jar
+}
+
+// newResettableJar 创建可重置的cookie jar
+func newResettableJar() (*resettableJar, error) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &resettableJar{jar: jar}, nil
+}
+
+// SetCookies 实现http.CookieJar接口
+func (j *resettableJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	j.jar.SetCookies(u, cookies)
+}
+
+// Cookies 实现http.CookieJar接口
+func (j *resettableJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.jar.Cookies(u)
+}
+
+// reset 丢弃所有cookie，替换为新的空jar
+func (j *resettableJar) reset() error {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	j.jar = jar
+	j.mu.Unlock()
+	return nil
+}
+
+// doAuthenticated 发送已认证的请求，遇到会话或CSRF令牌失效时恢复后重试一次
+// 会话过期（401，或403且/api/v1/me/确认会话未认证）时清除登录状态和CSRF令牌缓存并重新登录；CSRF令牌被拒绝（400）时只重新获取令牌
+// 只重试一次，重试后仍被拒绝时直接返回该响应，避免无限重试
+func (c *Client) doAuthenticated(req *http.Request) (*http.Response, error) {
+	c.mu.RLock()
+	gen := c.sessionGen
+	c.mu.RUnlock()
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := req.Context()
+	switch {
+	case c.isSessionExpired(ctx, resp):
+		log.Printf("Superset请求 %s %s 返回状态码 %d，会话可能已过期，重新登录后重试", req.Method, req.URL.Path, resp.StatusCode)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		c.invalidateSession(gen)
+		if err := c.ensureLoggedIn(ctx); err != nil {
+			return nil, fmt.Errorf("会话过期后重新登录失败: %w", err)
+		}
+	case isCSRFRejected(resp):
+		log.Printf("Superset请求 %s %s 的CSRF令牌被拒绝，重新获取令牌后重试", req.Method, req.URL.Path)
+		resp.Body.Close()
+
+		c.invalidateCSRFToken(req.Header.Get(headerCSRF))
+	default:
+		return resp, nil
+	}
+
+	retry, err := rewindRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	// http.Client会把jar中的cookie写入请求头，去掉旧会话的cookie，由jar重新填充
+	retry.Header.Del("Cookie")
+	if retry.Header.Get(headerCSRF) != "" {
+		csrfToken, err := c.getCSRFToken(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("获取CSRF令牌失败: %w", err)
+		}
+		retry.Header.Set(headerCSRF, csrfToken)
+	}
+	return c.do(retry)
+}
+
+// isSessionExpired 判断响应是否表示会话失效
+// 403也可能是当前用户对该资源没有权限，此时请求/api/v1/me/确认会话已失效，无法确认时按权限不足处理，不重新登录
+func (c *Client) isSessionExpired(ctx context.Context, resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return true
+	case http.StatusForbidden:
+		authenticated, err := c.verifyLogin(ctx)
+		return err == nil && !authenticated
+	default:
+		return false
+	}
+}
+
+// isCSRFRejected 判断响应是否为CSRF令牌无效或过期（Flask-WTF返回400，例如 "The CSRF token has expired."）
+// 需要读取响应体判断，不是CSRF错误时恢复响应体供调用方读取
+func isCSRFRejected(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusBadRequest {
+		return false
+	}
+
+	body, err := common.ReadAllLimited(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return err == nil && strings.Contains(strings.ToLower(string(body)), "csrf")
+}
+
+// invalidateCSRFToken 清除CSRF令牌缓存，缓存的令牌已被其他请求刷新时保留新令牌
+func (c *Client) invalidateCSRFToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.csrfCache.token == token {
+		c.csrfCache = csrfTokenCache{}
+	}
+}
+
+// invalidateSession 清除登录状态和CSRF令牌缓存，gen与当前会话不一致说明其他请求已重新登录，保留新会话
+func (c *Client) invalidateSession(gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sessionGen != gen {
+		return
+	}
+	c.loggedIn = false
+	c.csrfCache = csrfTokenCache{}
+}
+
+// Logout 登出Superset并清除本地会话状态（cookie、登录状态和CSRF令牌缓存）
+// 无论服务端登出是否成功都会清除本地状态，下次请求时使用当前凭据重新登录
+func (c *Client) Logout(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var logoutErr error
+	if c.loggedIn {
+		logoutErr = c.requestLogout(ctx)
+	}
+
+	c.loggedIn = false
+	c.csrfCache = csrfTokenCache{}
+	if err := c.jar.reset(); err != nil {
+		return fmt.Errorf("重置cookie jar失败: %w", err)
+	}
+
+	if logoutErr != nil {
+		return fmt.Errorf("本地会话已清除，但服务端登出失败: %w", logoutErr)
+	}
+	return nil
+}
+
+// requestLogout 请求服务端登出端点，使服务端会话失效
+func (c *Client) requestLogout(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+logoutEndpoint, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return fmt.Errorf("登出失败，状态码: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package superset
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// countLogins 覆盖登录端点，统计登录（POST）次数，GET请求返回包含CSRF令牌的登录页
+func countLogins(f *fakeSuperset) *atomic.Int64 {
+	var logins atomic.Int64
+	f.handle(loginEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			logins.Add(1)
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s", Path: "/"})
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<input id="csrf_token" name="csrf_token" type="hidden" value="tok">`))
+	})
+	return &logins
+}
+
+func TestForbiddenWithValidSessionDoesNotRelogin(t *testing.T) {
+	f := newFakeSuperset(t)
+	logins := countLogins(f)
+	f.handle(databaseEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Forbidden"}`, http.StatusForbidden)
+	})
+	client := f.newClient(t, ClientOptions{})
+
+	if _, err := client.RefreshDatabases(context.Background()); err == nil {
+		t.Fatal("RefreshDatabases succeeded, want permission error")
+	}
+	if got := logins.Load(); got != 1 {
+		t.Errorf("logins = %d, want 1", got)
+	}
+	if got := f.count(databaseEndpoint); got != 1 {
+		t.Errorf("database requests = %d, want 1 (no retry)", got)
+	}
+}
+
+func TestForbiddenWithExpiredSessionRelogins(t *testing.T) {
+	f := newFakeSuperset(t)
+	logins := countLogins(f)
+	// 第一次登录的会话在首次请求数据库列表时过期
+	f.handle(databaseEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		if logins.Load() < 2 {
+			http.Error(w, `{"message":"Forbidden"}`, http.StatusForbidden)
+			return
+		}
+		writeJSON(w, map[string]any{"result": []map[string]any{{"id": 1, "database_name": "examples"}}})
+	})
+	f.handle(meEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		if logins.Load() < 2 && f.count(databaseEndpoint) > 0 {
+			http.Error(w, `{"msg":"Not authorized"}`, http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, map[string]any{"result": map[string]any{"username": "admin"}})
+	})
+	client := f.newClient(t, ClientOptions{})
+
+	databases, err := client.RefreshDatabases(context.Background())
+	if err != nil {
+		t.Fatalf("RefreshDatabases: %v", err)
+	}
+	if len(databases) != 1 {
+		t.Errorf("databases = %+v, want 1 entry", databases)
+	}
+	if got := logins.Load(); got != 2 {
+		t.Errorf("logins = %d, want 2", got)
+	}
+}
+
+func TestLogoutClearsSessionState(t *testing.T) {
+	f := newFakeSuperset(t)
+	logins := countLogins(f)
+	client := f.newClient(t, ClientOptions{})
+	ctx := context.Background()
+
+	if err := client.ensureLoggedIn(ctx); err != nil {
+		t.Fatalf("ensureLoggedIn: %v", err)
+	}
+	if _, err := client.getCSRFToken(ctx); err != nil {
+		t.Fatalf("getCSRFToken: %v", err)
+	}
+
+	if err := client.Logout(ctx); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if got := f.count(logoutEndpoint); got != 1 {
+		t.Errorf("logout requests = %d, want 1", got)
+	}
+
+	client.mu.RLock()
+	loggedIn, csrfToken := client.loggedIn, client.csrfCache.token
+	client.mu.RUnlock()
+	if loggedIn {
+		t.Error("loggedIn = true after logout")
+	}
+	if csrfToken != "" {
+		t.Errorf("csrf token = %q after logout, want empty", csrfToken)
+	}
+	baseURL, err := url.Parse(f.URL)
+	if err != nil {
+		t.Fatalf("parse url: %v", err)
+	}
+	if cookies := client.jar.Cookies(baseURL); len(cookies) != 0 {
+		t.Errorf("cookies = %v after logout, want none", cookies)
+	}
+
+	// 下次请求时重新登录
+	if err := client.ensureLoggedIn(ctx); err != nil {
+		t.Fatalf("ensureLoggedIn after logout: %v", err)
+	}
+	if got := logins.Load(); got != 2 {
+		t.Errorf("logins = %d, want 2", got)
+	}
+}
+
+func TestLogoutToolRequiresAdminAPI(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		server := mcp.NewServer(&mcp.Implementation{Name: "superset", Version: "test"}, nil)
+		if _, err := registerTools(server, nil, &toolOptions{sqlTimeout: time.Second, requestTimeout: time.Second, enableAdminAPI: enabled}); err != nil {
+			t.Fatalf("registerTools: %v", err)
+		}
+		tools, err := connectInMemory(t, server).ListTools(context.Background(), nil)
+		if err != nil {
+			t.Fatalf("list tools: %v", err)
+		}
+		registered := slices.ContainsFunc(tools.Tools, func(tool *mcp.Tool) bool { return tool.Name == "superset_logout" })
+		if registered != enabled {
+			t.Errorf("enable_admin_api=%v: superset_logout registered = %v", enabled, registered)
+		}
+	}
+}
+
+func TestExpiredCSRFTokenRetried(t *testing.T) {
+	f := newFakeSuperset(t)
+	// 每次获取登录页都签发新令牌
+	var logins, issued atomic.Int64
+	f.handle(loginEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			logins.Add(1)
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "s", Path: "/"})
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprintf(w, `<input id="csrf_token" name="csrf_token" type="hidden" value="tok-%d">`, issued.Add(1))
+	})
+	// 首次执行时令牌已过期，之后只接受最新签发的令牌
+	var tokens []string
+	f.handle(sqlExecuteEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(headerCSRF)
+		tokens = append(tokens, token)
+		if len(tokens) == 1 || token != fmt.Sprintf("tok-%d", issued.Load()) {
+			http.Error(w, `{"errors":[{"message":"400 Bad Request: The CSRF token has expired."}]}`, http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, map[string]any{"query_id": 3, "status": "success", "data": []map[string]any{}})
+	})
+	client := f.newClient(t, ClientOptions{})
+
+	result, err := client.ExecuteSQL(context.Background(), "SELECT 1", 1)
+	if err != nil {
+		t.Fatalf("ExecuteSQL: %v", err)
+	}
+	if result.QueryID != 3 {
+		t.Errorf("QueryID = %d, want 3", result.QueryID)
+	}
+	// 重新获取令牌后重试一次，不重新登录
+	if len(tokens) != 2 || tokens[0] == tokens[1] {
+		t.Errorf("execute tokens = %v, want a retry with a fresh token", tokens)
+	}
+	if got := logins.Load(); got != 1 {
+		t.Errorf("logins = %d, want 1", got)
+	}
+}
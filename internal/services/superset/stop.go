@@ -0,0 +1,99 @@
+package superset
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"mcp-server/internal/common"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// SQL执行超时相关常量
+const (
+	queryStopEndpoint = "/api/v1/query/stop"
+
+	// defaultSQLTimeout 未配置sql_timeout且没有服务超时时的SQL执行超时
+	defaultSQLTimeout = 30 * time.Second
+	// stopQueryTimeout 请求Superset停止查询的超时，使用独立上下文，不受已取消的请求影响
+	stopQueryTimeout = 5 * time.Second
+
+	// clientIDLength SQL Lab查询的client_id长度，Superset限制为11个字符以内
+	clientIDLength  = 10
+	clientIDCharset = "abcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// errSQLTimeout SQL执行超过超时限制，调用方可以缩小查询后重试
+var errSQLTimeout = errors.New("SQL查询超时")
+
+// errSQLNotSubmitted 登录或获取CSRF令牌期间超时，查询尚未提交到Superset
+var errSQLNotSubmitted = errors.New("查询尚未提交")
+
+// newClientID 生成SQL Lab查询的client_id，用于在超时时定位并停止查询
+func newClientID() string {
+	buf := make([]byte, clientIDLength)
+	rand.Read(buf)
+	for i, b := range buf {
+		buf[i] = clientIDCharset[int(b)%len(clientIDCharset)]
+	}
+	return string(buf)
+}
+
+// interrupted 处理因上下文结束而中断的SQL执行：请求Superset停止远端查询，超时返回errSQLTimeout
+func (c *Client) interrupted(ctx context.Context, clientID string, err error) error {
+	if ctx.Err() == nil {
+		return err
+	}
+
+	if stopErr := c.stopQuery(clientID); stopErr != nil {
+		log.Printf("停止Superset查询失败 [client_id=%s]: %v", clientID, stopErr)
+	} else {
+		log.Printf("已请求Superset停止查询 [client_id=%s]", clientID)
+	}
+
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", errSQLTimeout, err)
+	}
+	return err
+}
+
+// timedOutBeforeSubmit 处理提交SQL前（登录、获取CSRF令牌）的错误，超时返回errSQLTimeout
+// 查询尚未提交，无需请求Superset停止
+func timedOutBeforeSubmit(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w: %w", errSQLTimeout, errSQLNotSubmitted, err)
+	}
+	return err
+}
+
+// stopQuery 请求Superset停止指定client_id的查询
+func (c *Client) stopQuery(clientID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), stopQueryTimeout)
+	defer cancel()
+
+	var response struct {
+		Result string `json:"result"`
+	}
+	return c.postJSON(ctx, queryStopEndpoint, map[string]string{"client_id": clientID}, &response)
+}
+
+// sqlErrorResponse 生成SQL执行失败的响应，超时单独说明以便调用方缩小查询后重试
+func sqlErrorResponse(prefix string, err error, deadline common.UpstreamDeadline) (*mcp.CallToolResultFor[any], error) {
+	if errors.Is(err, errSQLTimeout) {
+		stopped := "已请求Superset停止查询"
+		if errors.Is(err, errSQLNotSubmitted) {
+			stopped = "超时发生在登录Superset期间，查询尚未提交"
+		}
+		if deadline.ClientLimited {
+			return common.CreateErrorResponse("%s: SQL查询超时（调用方剩余期限 %v 短于sql_timeout %v），%s，请放宽客户端超时或缩小查询范围后重试",
+				prefix, deadline.Effective.Round(time.Millisecond), deadline.Configured, stopped)
+		}
+		return common.CreateErrorResponse("%s: SQL查询超时（限制 %v），%s，请缩小查询范围（例如增加LIMIT或过滤条件）后重试",
+			prefix, deadline.Configured, stopped)
+	}
+	return common.CreateErrorResponse("%s: %v", prefix, err)
+}
@@ -0,0 +1,97 @@
+package superset
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// validateSQLPath 数据库SQL校验端点后缀，完整路径为 /api/v1/database/{id}/validate_sql/
+const validateSQLPath = "/validate_sql/"
+
+// SQLValidationError 单条SQL校验错误，行列号由数据库的校验器给出
+type SQLValidationError struct {
+	Line        int    `json:"line"`
+	StartColumn int    `json:"start_column"`
+	EndColumn   int    `json:"end_column"`
+	Message     string `json:"message"`
+}
+
+// ValidationResult SQL校验结果，SQL有效时Errors为空列表
+type ValidationResult struct {
+	DatabaseID int                  `json:"database_id"`
+	Valid      bool                 `json:"valid"`
+	Errors     []SQLValidationError `json:"errors"`
+}
+
+// ValidateSQL 校验SQL语法但不执行
+// 依赖Superset为该数据库引擎配置的SQL校验器（SQL_VALIDATORS_BY_ENGINE），未配置时返回错误
+func (c *Client) ValidateSQL(ctx context.Context, sql string, databaseID int) (*ValidationResult, error) {
+	return c.validateSQLInternal(ctx, sql, databaseID, "")
+}
+
+// ValidateSQLWithSchema 在指定schema下校验SQL语法但不执行
+func (c *Client) ValidateSQLWithSchema(ctx context.Context, sql string, databaseID int, schema string) (*ValidationResult, error) {
+	return c.validateSQLInternal(ctx, sql, databaseID, schema)
+}
+
+// validateSQLInternal 内部SQL校验方法
+func (c *Client) validateSQLInternal(ctx context.Context, sql string, databaseID int, schema string) (*ValidationResult, error) {
+	payload := map[string]any{"sql": sql}
+	if schema != "" {
+		payload["schema"] = schema
+	}
+
+	var response struct {
+		Result []struct {
+			LineNumber  *int   `json:"line_number"`
+			StartColumn *int   `json:"start_column"`
+			EndColumn   *int   `json:"end_column"`
+			Message     string `json:"message"`
+		} `json:"result"`
+	}
+
+	endpoint := databaseEndpoint + strconv.Itoa(databaseID) + validateSQLPath
+	if err := c.postJSON(ctx, endpoint, payload, &response); err != nil {
+		if isValidatorMissing(err) {
+			return nil, fmt.Errorf("该数据库引擎未配置SQL校验器，需要在Superset的SQL_VALIDATORS_BY_ENGINE中启用: %w", err)
+		}
+		return nil, fmt.Errorf("校验SQL失败: %w", err)
+	}
+
+	result := &ValidationResult{
+		DatabaseID: databaseID,
+		Valid:      len(response.Result) == 0,
+		Errors:     make([]SQLValidationError, 0, len(response.Result)),
+	}
+	for _, item := range response.Result {
+		result.Errors = append(result.Errors, SQLValidationError{
+			Line:        derefInt(item.LineNumber),
+			StartColumn: derefInt(item.StartColumn),
+			EndColumn:   derefInt(item.EndColumn),
+			Message:     item.Message,
+		})
+	}
+
+	return result, nil
+}
+
+// isValidatorMissing 判断是否因数据库未配置校验器而失败
+func isValidatorMissing(err error) bool {
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusBadRequest && strings.Contains(strings.ToLower(apiErr.Body), "validator")
+}
+
+// derefInt 读取可能为null的整数，null视为0
+func derefInt(value *int) int {
+	if value == nil {
+		return 0
+	}
+	return *value
+}
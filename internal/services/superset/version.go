@@ -0,0 +1,136 @@
+package superset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+
+	"mcp-server/internal/common"
+)
+
+// 版本信息相关端点
+const (
+	menuEndpoint    = "/api/v1/menu/"
+	welcomeEndpoint = "/superset/welcome/"
+)
+
+// bootstrapDataRegex 匹配页面中嵌入的bootstrap数据，其中包含功能开关
+var bootstrapDataRegex = regexp.MustCompile(`data-bootstrap="([^"]*)"`)
+
+// ServerVersion Superset服务端版本和功能开关
+type ServerVersion struct {
+	Version      string          `json:"version"`
+	SHA          string          `json:"sha,omitempty"`
+	BuildNumber  string          `json:"build_number,omitempty"`
+	FeatureFlags map[string]bool `json:"feature_flags,omitempty"`
+	Note         string          `json:"note,omitempty"`
+}
+
+// menuResponse /api/v1/menu/ 响应中与版本相关的部分
+type menuResponse struct {
+	Result struct {
+		NavbarRight struct {
+			VersionString string `json:"version_string"`
+			VersionSHA    string `json:"version_sha"`
+			BuildNumber   any    `json:"build_number"`
+		} `json:"navbar_right"`
+	} `json:"result"`
+}
+
+// bootstrapData 页面bootstrap数据中与功能开关相关的部分
+type bootstrapData struct {
+	Common struct {
+		FeatureFlags map[string]bool `json:"feature_flags"`
+	} `json:"common"`
+}
+
+// GetVersion 获取Superset版本号和已启用的功能开关
+// 功能开关只能从页面bootstrap数据中读取，获取失败时仅在结果中说明
+func (c *Client) GetVersion(ctx context.Context) (*ServerVersion, error) {
+	var menu menuResponse
+	if err := c.getJSON(ctx, menuEndpoint, &menu); err != nil {
+		return nil, fmt.Errorf("获取版本信息失败: %w", err)
+	}
+
+	version := parseMenuVersion(&menu)
+
+	flags, err := c.getFeatureFlags(ctx)
+	if err != nil {
+		version.Note = fmt.Sprintf("无法获取功能开关: %v", err)
+		return version, nil
+	}
+	version.FeatureFlags = enabledFlags(flags)
+
+	return version, nil
+}
+
+// parseMenuVersion 从菜单响应中提取版本信息
+func parseMenuVersion(menu *menuResponse) *ServerVersion {
+	navbar := menu.Result.NavbarRight
+	version := &ServerVersion{
+		Version: navbar.VersionString,
+		SHA:     navbar.VersionSHA,
+	}
+	if navbar.BuildNumber != nil {
+		version.BuildNumber = fmt.Sprint(navbar.BuildNumber)
+	}
+	return version
+}
+
+// getFeatureFlags 从欢迎页的bootstrap数据中读取功能开关
+func (c *Client) getFeatureFlags(ctx context.Context) (map[string]bool, error) {
+	if err := c.ensureLoggedIn(ctx); err != nil {
+		return nil, fmt.Errorf("登录失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+welcomeEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	resp, err := c.doAuthenticated(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := common.ReadAllLimited(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &apiError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return parseFeatureFlags(string(body))
+}
+
+// parseFeatureFlags 解析页面中HTML转义的bootstrap JSON
+func parseFeatureFlags(page string) (map[string]bool, error) {
+	matches := bootstrapDataRegex.FindStringSubmatch(page)
+	if len(matches) < 2 {
+		return nil, fmt.Errorf("页面中未找到bootstrap数据")
+	}
+
+	var data bootstrapData
+	if err := json.Unmarshal([]byte(html.UnescapeString(matches[1])), &data); err != nil {
+		return nil, fmt.Errorf("解析bootstrap数据失败: %w", err)
+	}
+
+	return data.Common.FeatureFlags, nil
+}
+
+// enabledFlags 只保留已启用的功能开关
+func enabledFlags(flags map[string]bool) map[string]bool {
+	enabled := make(map[string]bool, len(flags))
+	for name, on := range flags {
+		if on {
+			enabled[name] = true
+		}
+	}
+	return enabled
+}
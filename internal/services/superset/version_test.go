@@ -0,0 +1,59 @@
+package superset
+
+import (
+	"context"
+	"html"
+	"maps"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetVersion(t *testing.T) {
+	f := newFakeSuperset(t)
+	f.handle(menuEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"result": map[string]any{"navbar_right": map[string]any{
+			"version_string": "4.0.1",
+			"version_sha":    "abc123",
+			"build_number":   42,
+		}}})
+	})
+	f.handle(welcomeEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		bootstrap := `{"common":{"feature_flags":{"SQLLAB_BACKEND_PERSISTENCE":true,"GLOBAL_ASYNC_QUERIES":false,"ALERT_REPORTS":true}}}`
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<div id="app" data-bootstrap="` + html.EscapeString(bootstrap) + `"></div>`))
+	})
+	client := f.newClient(t, ClientOptions{})
+
+	version, err := client.GetVersion(context.Background())
+	if err != nil {
+		t.Fatalf("GetVersion: %v", err)
+	}
+	if version.Version != "4.0.1" || version.SHA != "abc123" || version.BuildNumber != "42" || version.Note != "" {
+		t.Errorf("version = %+v, want 4.0.1 (abc123, build 42)", version)
+	}
+	// 只返回已启用的功能开关
+	if want := map[string]bool{"SQLLAB_BACKEND_PERSISTENCE": true, "ALERT_REPORTS": true}; !maps.Equal(version.FeatureFlags, want) {
+		t.Errorf("feature flags = %v, want %v", version.FeatureFlags, want)
+	}
+}
+
+func TestGetVersionWithoutFeatureFlags(t *testing.T) {
+	f := newFakeSuperset(t)
+	f.handle(menuEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"result": map[string]any{"navbar_right": map[string]any{"version_string": "3.1.0"}}})
+	})
+	f.handle(welcomeEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(`<html></html>`))
+	})
+	client := f.newClient(t, ClientOptions{})
+
+	version, err := client.GetVersion(context.Background())
+	if err != nil {
+		t.Fatalf("GetVersion: %v", err)
+	}
+	if version.Version != "3.1.0" || version.FeatureFlags != nil || !strings.Contains(version.Note, "bootstrap") {
+		t.Errorf("version = %+v, want version with a note about missing feature flags", version)
+	}
+}
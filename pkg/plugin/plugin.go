@@ -0,0 +1,80 @@
+// Package plugin 提供注册外部服务类型的公开API
+//
+// 外部程序引入本模块后，可以在启动前注册自定义的MCP服务类型，
+// 注册的服务会和内置的Prometheus、Superset服务一样挂载到多路复用服务器并展示在信息页面中：
+//
+//	func main() {
+//		err := plugin.Register("myapi", parseMyAPIConfig, createMyAPIService)
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		plugin.Run()
+//	}
+//
+// 服务配置写在配置文件的plugins段中，键为服务类型：
+//
+//	plugins:
+//	  myapi:
+//	    enabled: true
+//	    url: "http://internal-api:8000"
+//	    endpoint: "/myapi/mcp"
+package plugin
+
+import (
+	"fmt"
+
+	"mcp-server/internal/app"
+	"mcp-server/internal/core"
+	_ "mcp-server/internal/services" // 确保内置服务先于外部服务注册
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// 服务相关接口和类型，外部服务实现这些接口即可接入多路复用服务器
+type (
+	ServiceType       = core.ServiceType
+	ServiceConfig     = core.ServiceConfig
+	Service           = core.Service
+	ServiceFactory    = core.ServiceFactory
+	ConfigParser      = core.ConfigParser
+	ServiceDescriber  = core.ServiceDescriber
+	PortProvider      = core.PortProvider
+	FunctionalChecker = core.FunctionalChecker
+	ReadinessMode     = core.ReadinessMode
+	ToolRegistrar     = core.ToolRegistrar
+)
+
+// Register 注册外部服务类型
+// parser用于解析配置文件plugins段中该类型的配置，factory根据解析出的配置创建服务实例
+// 服务类型不能为空，也不能与内置或已注册的类型重复
+func Register(serviceType ServiceType, parser ConfigParser, factory ServiceFactory) error {
+	if serviceType == "" {
+		return fmt.Errorf("服务类型不能为空")
+	}
+	if parser == nil || factory == nil {
+		return fmt.Errorf("服务类型 %s 的配置解析函数和工厂函数不能为空", serviceType)
+	}
+	if core.IsServiceTypeSupported(serviceType) {
+		return fmt.Errorf("服务类型 %s 已注册", serviceType)
+	}
+
+	core.RegisterConfigParser(serviceType, parser)
+	core.RegisterServiceFactory(serviceType, factory)
+	return nil
+}
+
+// Run 加载配置并启动MCP服务器，阻塞直到收到关闭信号
+// 命令行参数与内置程序相同，例如 -config 指定配置文件路径
+func Run() {
+	app.Run()
+}
+
+// NewToolRegistrar 创建工具注册器，注册时检测重名，并将SDK注册时的panic转换为错误
+func NewToolRegistrar(server *mcp.Server) *ToolRegistrar {
+	return core.NewToolRegistrar(server)
+}
+
+// AddTool 通过注册器注册工具，注册失败的错误可以通过registrar.Err()获取
+func AddTool[In, Out any](registrar *ToolRegistrar, tool *mcp.Tool, handler mcp.ToolHandlerFor[In, Out]) {
+	core.AddTool(registrar, tool, handler)
+}
@@ -0,0 +1,130 @@
+package plugin_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mcp-server/config"
+	"mcp-server/internal/core"
+	"mcp-server/internal/multiplexer"
+	"mcp-server/pkg/plugin"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// inventoryType 示例的第三种服务类型：内部资产管理API
+const inventoryType plugin.ServiceType = "inventory"
+
+// inventoryConfig 配置文件plugins.inventory段的配置
+type inventoryConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	URL      string `yaml:"url"`
+	Endpoint string `yaml:"endpoint"`
+}
+
+func (c *inventoryConfig) GetType() plugin.ServiceType { return inventoryType }
+func (c *inventoryConfig) GetEndpoint() string         { return c.Endpoint }
+func (c *inventoryConfig) IsEnabled() bool             { return c.Enabled && c.URL != "" }
+func (c *inventoryConfig) Validate() error             { return nil }
+
+// parseInventoryConfig 解析配置并补充默认端点
+func parseInventoryConfig(decode func(out any) error) (plugin.ServiceConfig, error) {
+	cfg := &inventoryConfig{}
+	if err := decode(cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "/inventory/mcp"
+	}
+	return cfg, nil
+}
+
+// inventoryService 示例服务，提供一个查询资产的工具
+type inventoryService struct {
+	cfg    *inventoryConfig
+	server *mcp.Server
+}
+
+type lookupParams struct {
+	Host string `json:"host"`
+}
+
+// createInventoryService 服务工厂函数
+func createInventoryService(serviceConfig plugin.ServiceConfig, _ time.Duration) (plugin.Service, error) {
+	cfg := serviceConfig.(*inventoryConfig)
+	server := mcp.NewServer(&mcp.Implementation{Name: "inventory", Version: "test"}, nil)
+
+	registrar := plugin.NewToolRegistrar(server)
+	plugin.AddTool(registrar, &mcp.Tool{Name: "inventory_lookup", Description: "按主机名查询资产"},
+		func(_ context.Context, _ *mcp.ServerSession, params *mcp.CallToolParamsFor[lookupParams]) (*mcp.CallToolResultFor[any], error) {
+			return &mcp.CallToolResultFor[any]{Content: []mcp.Content{&mcp.TextContent{Text: params.Arguments.Host + ": rack-7"}}}, nil
+		})
+	if err := registrar.Err(); err != nil {
+		return nil, err
+	}
+	return &inventoryService{cfg: cfg, server: server}, nil
+}
+
+func (s *inventoryService) GetServer() *mcp.Server               { return s.server }
+func (s *inventoryService) TestConnection(context.Context) error { return nil }
+func (s *inventoryService) Close() error                         { return nil }
+func (s *inventoryService) GetType() plugin.ServiceType          { return inventoryType }
+func (s *inventoryService) GetEndpoint() string                  { return s.cfg.Endpoint }
+func (s *inventoryService) Description() string                  { return "内部资产管理API" }
+func (s *inventoryService) ToolSummaries() []string {
+	return []string{"inventory_lookup - 按主机名查询资产"}
+}
+
+func TestRegisterThirdServiceType(t *testing.T) {
+	if err := plugin.Register(inventoryType, parseInventoryConfig, createInventoryService); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	// 同一类型及内置类型不能重复注册
+	if err := plugin.Register(inventoryType, parseInventoryConfig, createInventoryService); err == nil {
+		t.Error("Register accepted a duplicate service type")
+	}
+	if err := plugin.Register(core.ServiceTypePrometheus, parseInventoryConfig, createInventoryService); err == nil {
+		t.Error("Register accepted a built-in service type")
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := []byte(`
+plugins:
+  inventory:
+    enabled: true
+    url: http://inventory:8000
+`)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := config.LoadConfigFromYAML(path)
+	if err != nil {
+		t.Fatalf("LoadConfigFromYAML: %v", err)
+	}
+
+	// 外部服务和内置服务一样被创建并挂载到多路复用服务器
+	server := multiplexer.NewServer("0")
+	for _, serviceConfig := range config.FilterEnabledServices(cfg) {
+		service, err := core.CreateService(serviceConfig, cfg.Timeout)
+		if err != nil {
+			t.Fatalf("CreateService(%s): %v", serviceConfig.GetType(), err)
+		}
+		server.AddService(service)
+	}
+
+	infos := server.GetServiceInfo(context.Background())
+	if len(infos) != 1 {
+		t.Fatalf("infos = %+v, want the inventory service only", infos)
+	}
+	info := infos[0]
+	if info.Type != inventoryType || info.Endpoint != "/inventory/mcp" || info.Description != "内部资产管理API" {
+		t.Errorf("info = %+v, want inventory service on /inventory/mcp", info)
+	}
+	if len(info.Tools) != 1 || !strings.HasPrefix(info.Tools[0], "inventory_lookup") {
+		t.Errorf("tools = %v, want inventory_lookup", info.Tools)
+	}
+}